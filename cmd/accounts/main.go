@@ -0,0 +1,62 @@
+// Accounts creates users and station devices allowed to request API tokens.
+//
+// Usage:
+//
+//	go run ./cmd/accounts -username station-12 -password <secret> -station 12 -facility SLC1 -roles operator
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/database"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	username := flag.String("username", "", "account username, for station devices usually the station ID")
+	password := flag.String("password", "", "account password")
+	stationID := flag.String("station", "", "station ID included in the account's tokens")
+	facility := flag.String("facility", "", "facility included in the account's tokens")
+	roles := flag.String("roles", "", "comma separated roles included in the account's tokens: operator, supervisor or admin")
+	flag.Parse()
+
+	db, err := database.NewMongo(context.Background(), os.Getenv("MONGO_URI"), os.Getenv("MONGO_DATABASE"))
+	if err != nil {
+		log.Fatalf("Failed to connect to Mongo: %v", err)
+	}
+	repository, err := auth.NewRepository(context.Background(), db)
+	if err != nil {
+		log.Fatalf("Failed to initialize accounts: %v", err)
+	}
+	keys, err := auth.LoadKeys(os.Getenv("JWT_SIGNING_KEY"), os.Getenv("JWT_PUBLIC_KEY"), os.Getenv("JWT_PRIVATE_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to load keys: %v", err)
+	}
+
+	newAccount := models.NewAccount{
+		Username:  *username,
+		Password:  *password,
+		StationID: *stationID,
+		Facility:  *facility,
+	}
+	if *roles != "" {
+		newAccount.Roles = strings.Split(*roles, ",")
+	}
+
+	account, err := auth.NewManager(repository, keys, time.Hour).CreateAccount(context.Background(), newAccount)
+	if err != nil {
+		log.Fatalf("Failed to create account: %v", err)
+	}
+
+	fmt.Printf("created account %s (%s)\n", account.Username, account.ID.Hex())
+}
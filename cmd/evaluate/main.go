@@ -0,0 +1,395 @@
+// Evaluate runs a labelled corpus of label images through a GPT provider and
+// reports extraction accuracy, latency and token cost.
+//
+// The corpus directory must contain a corpus.json file listing the images and
+// the expected values read from them:
+//
+//	[{"image": "label-001.jpg", "trackingNumber": "1Z...", "address": {"addressLine1": "..."}}]
+//
+// Usage:
+//
+//	go run ./cmd/evaluate -corpus ./testdata/labels -out run.json -baseline previous.json
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping"
+	"github.com/JoshuaPackardHR/shipping-label-validator/prompts"
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+	"github.com/joho/godotenv"
+)
+
+var fields = []string{"addressLine1", "addressLine2", "city", "stateProvince", "postalCode", "trackingNumber"}
+
+type corpusItem struct {
+	Image          string      `json:"image"`
+	TrackingNumber string      `json:"trackingNumber"`
+	Address        ups.Address `json:"address"`
+}
+
+type itemResult struct {
+	Image      string          `json:"image"`
+	Fields     map[string]bool `json:"fields"`
+	ExactMatch bool            `json:"exactMatch"`
+	Error      string          `json:"error,omitempty"`
+	Latency    time.Duration   `json:"latency"`
+	Usage      gpt.Usage       `json:"usage"`
+	Cost       float64         `json:"cost"`
+}
+
+type summary struct {
+	Items            int                `json:"items"`
+	Errors           int                `json:"errors"`
+	ExactMatchRate   float64            `json:"exactMatchRate"`
+	FieldAccuracy    map[string]float64 `json:"fieldAccuracy"`
+	AverageLatency   time.Duration      `json:"averageLatency"`
+	P95Latency       time.Duration      `json:"p95Latency"`
+	PromptTokens     int                `json:"promptTokens"`
+	CompletionTokens int                `json:"completionTokens"`
+	Cost             float64            `json:"cost"`
+}
+
+type report struct {
+	Provider      string       `json:"provider"`
+	Model         string       `json:"model"`
+	PromptVersion string       `json:"promptVersion"`
+	RunAt         time.Time    `json:"runAt"`
+	Summary       summary      `json:"summary"`
+	Items         []itemResult `json:"items"`
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	corpusDir := flag.String("corpus", "", "directory containing corpus.json and the label images")
+	provider := flag.String("provider", os.Getenv("GPT"), "gpt provider: openai, gemini or fake")
+	model := flag.String("model", "", "model name, defaults to OPENAI_MODEL or GEMINI_MODEL")
+	recordingsDir := flag.String("recordings", "", "recordings directory replayed by the fake provider or written with -record")
+	record := flag.Bool("record", false, "record provider responses to the recordings directory")
+	outPath := flag.String("out", "", "write the report as JSON to this path")
+	baselinePath := flag.String("baseline", "", "previous report to diff against")
+	pricesPath := flag.String("prices", os.Getenv("GPT_PRICES_FILE"), "JSON price table overriding the default model prices")
+	promptVersion := flag.String("prompt-version", os.Getenv("PROMPT_VERSION"), "prompt template version")
+	promptsDir := flag.String("prompts", os.Getenv("PROMPTS_DIR"), "directory of prompt templates overriding the embedded ones")
+	carrier := flag.String("carrier", "ups", "carrier used to render the prompt")
+	locale := flag.String("locale", "", "locale used to render the prompt")
+	timeout := flag.Duration("timeout", time.Minute, "timeout for each prompt")
+	flag.Parse()
+
+	if *corpusDir == "" {
+		log.Fatal("-corpus is required")
+	}
+
+	client, modelName, err := initGPTClient(*provider, *model, *recordingsDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize GPT client: %v", err)
+	}
+	if *record {
+		if *recordingsDir == "" {
+			log.Fatal("-record requires -recordings")
+		}
+		if client, err = gpt.NewRecorder(client, *recordingsDir); err != nil {
+			log.Fatalf("Failed to initialize recorder: %v", err)
+		}
+	}
+
+	promptRegistry, err := prompts.NewRegistry(*promptsDir, *promptVersion, nil)
+	if err != nil {
+		log.Fatalf("Failed to initialize prompts: %v", err)
+	}
+	if *promptVersion == "" {
+		*promptVersion = "v1"
+	}
+	prompt, err := promptRegistry.Render(*promptVersion, prompts.Vars{
+		Carrier: *carrier,
+		Locale:  *locale,
+	})
+	if err != nil {
+		log.Fatalf("Failed to render prompt: %v", err)
+	}
+
+	prices, err := gpt.LoadPriceTable(*pricesPath)
+	if err != nil {
+		log.Fatalf("Failed to load prices: %v", err)
+	}
+
+	corpus, err := loadCorpus(*corpusDir)
+	if err != nil {
+		log.Fatalf("Failed to load corpus: %v", err)
+	}
+
+	r := report{
+		Provider:      *provider,
+		Model:         modelName,
+		PromptVersion: prompt.Version,
+		RunAt:         time.Now().UTC(),
+	}
+	for _, item := range corpus {
+		result := evaluateItem(client, prices, prompt.Text, *corpusDir, item, *timeout)
+		r.Items = append(r.Items, result)
+	}
+	r.Summary = summarize(r.Items)
+
+	printSummary(os.Stdout, r)
+
+	if *baselinePath != "" {
+		baseline, err := loadReport(*baselinePath)
+		if err != nil {
+			log.Fatalf("Failed to load baseline: %v", err)
+		}
+		printDiff(os.Stdout, baseline, r)
+	}
+
+	if *outPath != "" {
+		reportBytes, err := json.MarshalIndent(r, "", "    ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*outPath, reportBytes, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func initGPTClient(provider, model, recordingsDir string) (gpt.GPT, string, error) {
+	switch provider {
+	case "fake":
+		client, err := gpt.NewFake(recordingsDir)
+		return client, "fake", err
+	case "gemini":
+		if model == "" {
+			model = os.Getenv("GEMINI_MODEL")
+		}
+		client, err := gpt.NewGemini(model, os.Getenv("GEMINI_API_KEY"))
+		return client, model, err
+	case "openai", "":
+		if model == "" {
+			model = os.Getenv("OPENAI_MODEL")
+		}
+		client, err := gpt.NewOpenAI(model, os.Getenv("OPENAI_API_KEY"))
+		return client, model, err
+	}
+
+	return nil, "", fmt.Errorf("unknown provider %q", provider)
+}
+
+func loadCorpus(dir string) ([]corpusItem, error) {
+	corpusBytes, err := os.ReadFile(filepath.Join(dir, "corpus.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	corpus := []corpusItem{}
+	if err := json.Unmarshal(corpusBytes, &corpus); err != nil {
+		return nil, err
+	}
+	if len(corpus) == 0 {
+		return nil, errors.New("corpus is empty")
+	}
+
+	return corpus, nil
+}
+
+func loadReport(path string) (*report, error) {
+	reportBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := report{}
+	if err := json.Unmarshal(reportBytes, &r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func evaluateItem(client gpt.GPT, prices gpt.PriceTable, prompt string, dir string, item corpusItem, timeout time.Duration) itemResult {
+	result := itemResult{
+		Image:  item.Image,
+		Fields: map[string]bool{},
+	}
+	for _, field := range fields {
+		result.Fields[field] = false
+	}
+
+	// normalize the image the same way the validation endpoint does so
+	// recordings match between the harness and the service
+	imageBytes, err := loadImage(filepath.Join(dir, item.Image))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	extraction, gptResult, err := shipping.Extract(ctx, client, prompt, imageBytes)
+	result.Latency = time.Since(start)
+	if gptResult != nil {
+		result.Usage = gptResult.Usage
+		result.Cost = prices.Cost(gptResult.Model, gptResult.Usage)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	expected := map[string]string{
+		"addressLine1":   item.Address.AddressLine1,
+		"addressLine2":   item.Address.AddressLine2,
+		"city":           item.Address.City,
+		"stateProvince":  item.Address.StateProvince,
+		"postalCode":     item.Address.PostalCode,
+		"trackingNumber": item.TrackingNumber,
+	}
+	actual := map[string]string{
+		"addressLine1":   extraction.AddressLine1,
+		"addressLine2":   extraction.AddressLine2,
+		"city":           extraction.City,
+		"stateProvince":  extraction.StateProvince,
+		"postalCode":     extraction.PostalCode,
+		"trackingNumber": extraction.TrackingNumber,
+	}
+
+	result.ExactMatch = true
+	for _, field := range fields {
+		match := strings.EqualFold(strings.TrimSpace(expected[field]), strings.TrimSpace(actual[field]))
+		result.Fields[field] = match
+		result.ExactMatch = result.ExactMatch && match
+	}
+
+	return result
+}
+
+func loadImage(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	imageBytes := new(bytes.Buffer)
+	if err := jpeg.Encode(imageBytes, img, nil); err != nil {
+		return nil, err
+	}
+
+	return imageBytes.Bytes(), nil
+}
+
+func summarize(items []itemResult) summary {
+	s := summary{
+		Items:         len(items),
+		FieldAccuracy: map[string]float64{},
+	}
+	if len(items) == 0 {
+		return s
+	}
+
+	exactMatches := 0
+	fieldMatches := map[string]int{}
+	latencies := make([]time.Duration, 0, len(items))
+	var totalLatency time.Duration
+	for _, item := range items {
+		if item.Error != "" {
+			s.Errors++
+		}
+		if item.ExactMatch {
+			exactMatches++
+		}
+		for field, match := range item.Fields {
+			if match {
+				fieldMatches[field]++
+			}
+		}
+		latencies = append(latencies, item.Latency)
+		totalLatency += item.Latency
+		s.PromptTokens += item.Usage.PromptTokens
+		s.CompletionTokens += item.Usage.CompletionTokens
+		s.Cost += item.Cost
+	}
+
+	s.ExactMatchRate = float64(exactMatches) / float64(len(items))
+	for _, field := range fields {
+		s.FieldAccuracy[field] = float64(fieldMatches[field]) / float64(len(items))
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.AverageLatency = totalLatency / time.Duration(len(items))
+	s.P95Latency = latencies[(len(latencies)*95-1)/100]
+
+	return s
+}
+
+func printSummary(out io.Writer, r report) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "provider\t%s (%s)\n", r.Provider, r.Model)
+	fmt.Fprintf(w, "prompt\t%s\n", r.PromptVersion)
+	fmt.Fprintf(w, "items\t%d\n", r.Summary.Items)
+	fmt.Fprintf(w, "errors\t%d\n", r.Summary.Errors)
+	fmt.Fprintf(w, "exact match\t%.1f%%\n", r.Summary.ExactMatchRate*100)
+	for _, field := range fields {
+		fmt.Fprintf(w, "  %s\t%.1f%%\n", field, r.Summary.FieldAccuracy[field]*100)
+	}
+	fmt.Fprintf(w, "avg latency\t%s\n", r.Summary.AverageLatency.Round(time.Millisecond))
+	fmt.Fprintf(w, "p95 latency\t%s\n", r.Summary.P95Latency.Round(time.Millisecond))
+	fmt.Fprintf(w, "tokens\t%d prompt, %d completion\n", r.Summary.PromptTokens, r.Summary.CompletionTokens)
+	fmt.Fprintf(w, "cost\t$%.4f\n", r.Summary.Cost)
+}
+
+func printDiff(out io.Writer, baseline *report, r report) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "\nchange vs baseline (%s, %s, %s)\n", baseline.Model, baseline.PromptVersion, baseline.RunAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "exact match\t%+.1f%%\n", (r.Summary.ExactMatchRate-baseline.Summary.ExactMatchRate)*100)
+	for _, field := range fields {
+		fmt.Fprintf(w, "  %s\t%+.1f%%\n", field, (r.Summary.FieldAccuracy[field]-baseline.Summary.FieldAccuracy[field])*100)
+	}
+	fmt.Fprintf(w, "avg latency\t%s\n", (r.Summary.AverageLatency - baseline.Summary.AverageLatency).Round(time.Millisecond))
+	fmt.Fprintf(w, "cost\t%+.4f\n", r.Summary.Cost-baseline.Summary.Cost)
+
+	previous := map[string]itemResult{}
+	for _, item := range baseline.Items {
+		previous[item.Image] = item
+	}
+	for _, item := range r.Items {
+		before, ok := previous[item.Image]
+		if !ok {
+			continue
+		}
+		for _, field := range fields {
+			if before.Fields[field] && !item.Fields[field] {
+				fmt.Fprintf(w, "REGRESSION\t%s\t%s\n", item.Image, field)
+			} else if !before.Fields[field] && item.Fields[field] {
+				fmt.Fprintf(w, "fixed\t%s\t%s\n", item.Image, field)
+			}
+		}
+	}
+}
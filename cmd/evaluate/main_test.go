@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+)
+
+// writeCorpus writes a three label corpus to a temporary directory with
+// recordings for the fake provider. The first label is read correctly, the
+// second has the wrong city and the third has no recording.
+func writeCorpus(t *testing.T) (string, gpt.GPT) {
+	t.Helper()
+
+	dir := t.TempDir()
+	recordings := filepath.Join(dir, "recordings")
+	if err := os.Mkdir(recordings, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	address := ups.Address{
+		AddressLine1:  "123 Main St",
+		City:          "Atlanta",
+		StateProvince: "GA",
+		PostalCode:    "30301",
+	}
+	corpus := []corpusItem{
+		{Image: "label-001.png", TrackingNumber: "1Z999AA10123456784", Address: address},
+		{Image: "label-002.png", TrackingNumber: "1Z999AA10123456785", Address: address},
+		{Image: "label-003.png", TrackingNumber: "1Z999AA10123456786", Address: address},
+	}
+	contents := []string{
+		`{"addressLine1": "123 MAIN ST", "city": "atlanta", "stateProvince": "GA", "postalCode": "30301", "trackingNumber": "1Z999AA10123456784"}`,
+		`{"addressLine1": "123 Main St", "city": "Athens", "stateProvince": "GA", "postalCode": "30301", "trackingNumber": "1Z999AA10123456785"}`,
+	}
+
+	for i, item := range corpus {
+		img := image.NewGray(image.Rect(0, 0, 8, 8))
+		for p := range img.Pix {
+			img.Pix[p] = uint8(i * 80)
+		}
+		img.Set(i, i, color.White)
+
+		path := filepath.Join(dir, item.Image)
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(file, img); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+
+		if i >= len(contents) {
+			continue
+		}
+		// recordings are keyed by the re-encoded JPEG the harness sends
+		imageBytes, err := loadImage(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recording, _ := json.Marshal(gpt.Recording{
+			ImageHash: gpt.ImageHash(imageBytes),
+			Model:     "test-model",
+			Content:   contents[i],
+			Usage:     gpt.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100},
+		})
+		if err := os.WriteFile(filepath.Join(recordings, item.Image+".json"), recording, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	corpusBytes, _ := json.Marshal(corpus)
+	if err := os.WriteFile(filepath.Join(dir, "corpus.json"), corpusBytes, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := gpt.NewFake(recordings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, client
+}
+
+func TestEvaluateCorpus(t *testing.T) {
+	dir, client := writeCorpus(t)
+	prices := gpt.PriceTable{"test-model": {Prompt: 2, Completion: 10}}
+
+	corpus, err := loadCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []itemResult{}
+	for _, item := range corpus {
+		items = append(items, evaluateItem(client, prices, "prompt", dir, item, time.Second))
+	}
+
+	if !items[0].ExactMatch || items[0].Error != "" {
+		t.Errorf("label-001 = %+v, want an exact match", items[0])
+	}
+	if items[1].ExactMatch || items[1].Fields["city"] || !items[1].Fields["trackingNumber"] {
+		t.Errorf("label-002 = %+v, want only the city wrong", items[1])
+	}
+	if items[2].Error == "" {
+		t.Errorf("label-003 = %+v, want a missing recording error", items[2])
+	}
+
+	s := summarize(items)
+	if s.Items != 3 || s.Errors != 1 {
+		t.Errorf("items = %d, errors = %d, want 3 and 1", s.Items, s.Errors)
+	}
+	if !approx(s.ExactMatchRate, 1.0/3) {
+		t.Errorf("exact match rate = %v, want 1/3", s.ExactMatchRate)
+	}
+	if !approx(s.FieldAccuracy["city"], 1.0/3) || !approx(s.FieldAccuracy["trackingNumber"], 2.0/3) {
+		t.Errorf("field accuracy = %v", s.FieldAccuracy)
+	}
+	if s.PromptTokens != 2000 || s.CompletionTokens != 200 {
+		t.Errorf("tokens = %d prompt, %d completion, want 2000 and 200", s.PromptTokens, s.CompletionTokens)
+	}
+	// two recorded items at 1000 prompt tokens for $2/M and 100 completion tokens for $10/M
+	if !approx(s.Cost, 2*(0.002+0.001)) {
+		t.Errorf("cost = %v, want 0.006", s.Cost)
+	}
+}
+
+func TestSummarizeLatency(t *testing.T) {
+	items := []itemResult{}
+	// add out of order to check the latencies are sorted before picking p95
+	for i := 20; i >= 1; i-- {
+		items = append(items, itemResult{Latency: time.Duration(i) * time.Millisecond})
+	}
+
+	s := summarize(items)
+	if s.P95Latency != 19*time.Millisecond {
+		t.Errorf("p95 = %s, want 19ms", s.P95Latency)
+	}
+	if s.AverageLatency != 10500*time.Microsecond {
+		t.Errorf("average = %s, want 10.5ms", s.AverageLatency)
+	}
+
+	single := summarize([]itemResult{{Latency: time.Second}})
+	if single.P95Latency != time.Second {
+		t.Errorf("p95 of one item = %s, want 1s", single.P95Latency)
+	}
+
+	if empty := summarize(nil); empty.Items != 0 || empty.P95Latency != 0 {
+		t.Errorf("empty summary = %+v", empty)
+	}
+}
+
+func TestPrintDiff(t *testing.T) {
+	fieldsWith := func(match bool, overrides map[string]bool) map[string]bool {
+		result := map[string]bool{}
+		for _, field := range fields {
+			result[field] = match
+		}
+		for field, value := range overrides {
+			result[field] = value
+		}
+		return result
+	}
+
+	baseline := report{
+		Model:         "test-model",
+		PromptVersion: "v1",
+		Items: []itemResult{
+			{Image: "label-001.png", Fields: fieldsWith(true, nil)},
+			{Image: "label-002.png", Fields: fieldsWith(true, map[string]bool{"city": false})},
+			{Image: "removed.png", Fields: fieldsWith(true, nil)},
+		},
+	}
+	baseline.Summary = summarize(baseline.Items)
+	baseline.Summary.Cost = 0.01
+
+	current := report{
+		Items: []itemResult{
+			{Image: "label-001.png", Fields: fieldsWith(true, map[string]bool{"postalCode": false})},
+			{Image: "label-002.png", Fields: fieldsWith(true, nil)},
+			{Image: "added.png", Fields: fieldsWith(false, nil)},
+		},
+	}
+	current.Summary = summarize(current.Items)
+	current.Summary.Cost = 0.015
+
+	out := bytes.Buffer{}
+	printDiff(&out, &baseline, current)
+	lines := strings.Split(out.String(), "\n")
+
+	want := map[string]bool{
+		"REGRESSION label-001.png postalCode": false,
+		"fixed label-002.png city":            false,
+		"postalCode -66.7%":                   false,
+		"cost +0.0050":                        false,
+	}
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if _, ok := want[line]; ok {
+			want[line] = true
+		}
+		if strings.Contains(line, "added.png") || strings.Contains(line, "removed.png") {
+			t.Errorf("diff reported an item missing from one run: %q", line)
+		}
+	}
+	for line, found := range want {
+		if !found {
+			t.Errorf("diff is missing %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
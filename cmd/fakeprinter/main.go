@@ -0,0 +1,62 @@
+// Fakeprinter listens for raw print jobs like a Zebra printer on port 9100 and
+// writes each job it receives to a file, so printing can be tried without a
+// printer.
+//
+// Usage:
+//
+//	go run ./cmd/fakeprinter -addr 127.0.0.1:9100 -out ./printed
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9100", "address to listen on")
+	out := flag.String("out", "printed", "directory to write received jobs to")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	log.Printf("Listening for print jobs on %s", listener.Addr())
+
+	for job := 1; ; job++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Fatalf("Failed to accept connection: %v", err)
+		}
+
+		// printers handle one job at a time
+		if err := receive(conn, filepath.Join(*out, fmt.Sprintf("job-%d-%d.prn", job, time.Now().Unix()))); err != nil {
+			log.Printf("Failed to receive job %d: %v", job, err)
+		}
+	}
+}
+
+func receive(conn net.Conn, path string) error {
+	defer conn.Close()
+
+	data, err := io.ReadAll(conn)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+
+	log.Printf("Received %d bytes from %s, wrote %s", len(data), conn.RemoteAddr(), path)
+	return nil
+}
@@ -0,0 +1,127 @@
+// Fakesentry accepts events from the Sentry SDK, logs a summary of each and
+// writes them to files, so error reporting can be tried without a Sentry
+// project.
+//
+// Usage:
+//
+//	go run ./cmd/fakesentry -addr 127.0.0.1:9000 -out ./sentry-events
+//
+// and set SENTRY_DSN=http://public@127.0.0.1:9000/1
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+type event struct {
+	EventID   string            `json:"event_id"`
+	Level     string            `json:"level"`
+	Message   string            `json:"message"`
+	Tags      map[string]string `json:"tags"`
+	Exception []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"exception"`
+}
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9000", "address to listen on")
+	out := flag.String("out", "sentry-events", "directory to write received events to")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	var received atomic.Int64
+	http.HandleFunc("POST /api/{project}/envelope/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := readBody(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, payload := range eventPayloads(body) {
+			n := received.Add(1)
+			path := filepath.Join(*out, fmt.Sprintf("event-%d.json", n))
+			if err := os.WriteFile(path, payload, 0o644); err != nil {
+				log.Printf("Failed to write event %d: %v", n, err)
+			}
+			logEvent(payload, path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	})
+
+	log.Printf("Listening for Sentry events on %s, DSN http://public@%s/1", *addr, *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	return io.ReadAll(reader)
+}
+
+// eventPayloads returns the event items of an envelope, which is an envelope
+// header line followed by pairs of item header and payload lines
+func eventPayloads(envelope []byte) [][]byte {
+	scanner := bufio.NewScanner(bytes.NewReader(envelope))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	scanner.Scan()
+
+	payloads := [][]byte{}
+	for scanner.Scan() {
+		header := struct {
+			Type string `json:"type"`
+		}{}
+		if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || !scanner.Scan() {
+			break
+		}
+		if header.Type == "event" {
+			payloads = append(payloads, append([]byte(nil), scanner.Bytes()...))
+		}
+	}
+
+	return payloads
+}
+
+func logEvent(payload []byte, path string) {
+	e := event{}
+	if err := json.Unmarshal(payload, &e); err != nil {
+		log.Printf("Received an unreadable event, wrote %s: %v", path, err)
+		return
+	}
+
+	summary := e.Message
+	for _, exception := range e.Exception {
+		summary = exception.Type + ": " + exception.Value
+	}
+	tags := []string{}
+	for key, value := range e.Tags {
+		tags = append(tags, key+"="+value)
+	}
+
+	log.Printf("Received %s %s [%s], wrote %s", e.Level, summary, strings.Join(tags, " "), path)
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const connectTimeout = 10 * time.Second
+
+// NewMongo connects to the Mongo server at uri and returns the named database
+func NewMongo(ctx context.Context, uri string, database string) (*mongo.Database, error) {
+	if uri == "" {
+		return nil, errors.New("mongo uri is not set")
+	}
+	if database == "" {
+		return nil, errors.New("mongo database is not set")
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri).SetMonitor(newCommandMonitor()))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client.Database(database), nil
+}
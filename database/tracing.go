@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commandTracer starts a span for each command sent to Mongo
+type commandTracer struct {
+	spans sync.Map
+}
+
+func newCommandMonitor() *event.CommandMonitor {
+	t := &commandTracer{}
+	return &event.CommandMonitor{
+		Started: t.started,
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			t.finished(e.RequestID, nil)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			t.finished(e.RequestID, e.Failure)
+		},
+	}
+}
+
+func (t *commandTracer) started(ctx context.Context, e *event.CommandStartedEvent) {
+	// commands outside a trace, such as the driver's heartbeats, are not traced
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	name := "mongo." + e.CommandName
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.name", e.DatabaseName),
+		attribute.String("db.operation", e.CommandName),
+	}
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		name += " " + collection
+		attributes = append(attributes, attribute.String("db.mongodb.collection", collection))
+	}
+
+	_, span := tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	t.spans.Store(e.RequestID, span)
+}
+
+func (t *commandTracer) finished(requestID int64, err error) {
+	span, ok := t.spans.LoadAndDelete(requestID)
+	if !ok {
+		return
+	}
+
+	tracing.End(span.(trace.Span), err)
+}
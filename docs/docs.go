@@ -24,9 +24,55 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/shipping/label/validate": {
+        "/admin/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "list API keys including revoked keys",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ListAPIKeysResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "check a shipping label",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "create an API key for a station device. The key is only returned once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,84 +80,2773 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "shipping"
+                    "admin"
                 ],
-                "summary": "Check a shipping label",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "description": "Validation Request",
+                        "description": "API Key",
                         "name": "requestBody",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ValidationRequest"
+                            "$ref": "#/definitions/CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "revoke an API key so it can no longer authenticate",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/usage": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "get LLM token usage and cost grouped by day, provider and station",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get LLM usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day to include (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day to include (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "GPT provider",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UsageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/token": {
+            "post": {
+                "description": "exchange a user or station device's credentials for a bearer token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request an API token",
+                "parameters": [
+                    {
+                        "description": "Token Request",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "stream validation lifecycle events (received, extracting, looking_up, verdict, failed) as server-sent events. Operators subscribe to a station; supervisors may omit stationId to receive every station of their facility. Browsers may pass the bearer token as the access_token query parameter.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Subscribe to validation events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer token, for clients which cannot set headers",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/manifests": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "list manifests, newest first, without their tracking numbers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manifests"
+                ],
+                "summary": "List manifests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trailer ID",
+                        "name": "trailerId",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "OPEN",
+                            "CLOSED"
+                        ],
+                        "type": "string",
+                        "description": "Manifest status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum manifests to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ManifestsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
                         }
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/ValidationResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/ValidationError"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/ValidationError"
-                        }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "upload the CSV of tracking numbers expected on a trailer, as a multipart file or a text/csv body. The CSV has a tracking number column found by its header, or a single column without a header. The manifest becomes the open manifest of the station and scans at the station are reconciled against it.",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manifests"
+                ],
+                "summary": "Upload a manifest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trailer ID",
+                        "name": "trailerId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Station loading the trailer",
+                        "name": "stationId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Manifest CSV",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Manifest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/manifests/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "get a manifest with its tracking numbers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manifests"
+                ],
+                "summary": "Get a manifest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manifest ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Manifest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/manifests/{id}/close": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "close a manifest once its trailer is loaded so further scans at the station are not associated with it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manifests"
+                ],
+                "summary": "Close a manifest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manifest ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Manifest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/manifests/{id}/reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "compare the packages scanned against a manifest with the packages expected on it, reporting valid, invalid, missing, extra and duplicate-scanned packages",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "manifests"
+                ],
+                "summary": "Reconcile a manifest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manifest ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Report format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Reconciliation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/print-jobs": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "list print jobs, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "printing"
+                ],
+                "summary": "List print jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "QUEUED",
+                            "PRINTING",
+                            "COMPLETED",
+                            "FAILED"
+                        ],
+                        "type": "string",
+                        "description": "Job status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum jobs to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PrintJobsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "print the corrected label of a validation on the station's printer. The job is sent in the background; poll the job for its status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "printing"
+                ],
+                "summary": "Print a label",
+                "parameters": [
+                    {
+                        "description": "Print Job Request",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PrintJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/PrintJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/print-jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "get the status of a print job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "printing"
+                ],
+                "summary": "Get a print job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Print Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PrintJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/summary": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "aggregate stored validations into totals, validations per hour, invalid rate by station and operator, top mismatched address fields and override counts. Invalid counts the original verdicts, before overrides.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get a validation summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the report, a day (YYYY-MM-DD) or an RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the report, a day included in the report (YYYY-MM-DD) or an RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of days and hourly buckets",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operator username",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Report format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReportSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/validations": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "export the stored validation records, oldest first, as CSV or XLSX. At most 100000 validations are exported at once.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Export validations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the export, a day (YYYY-MM-DD) or an RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the export, a day included in the export (YYYY-MM-DD) or an RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of days",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operator username",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scanners": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "list the scanners of connected scanner agents",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scanners"
+                ],
+                "summary": "List scanners",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ScannersResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scanners/connect": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "upgrade to a websocket for a scanner agent. Agents send barcode, image, attached, detached and scanner_list messages; a barcode is answered with an image_capture command for the same scanner and an image with a validation or error message. Images are answered with a busy error while the agent has too many validations running. Browsers may pass the bearer token as the access_token query parameter.",
+                "tags": [
+                    "scanners"
+                ],
+                "summary": "Connect a scanner agent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station ID, required unless the credentials are bound to a station",
+                        "name": "stationId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer token, for clients which cannot set headers",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shipping/label/validate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "check a shipping label. With async or a callbackUrl the label is validated in the background and a job is returned to poll at /validations/jobs/{id}; the callback receives the finished job as JSON, signed in X-Signature-256 when a webhook secret is configured.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipping"
+                ],
+                "summary": "Check a shipping label",
+                "parameters": [
+                    {
+                        "description": "Validation Request",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ValidationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ValidationResponse"
+                        },
+                        "headers": {
+                            "X-RateLimit-Limit": {
+                                "type": "integer",
+                                "description": "Daily validation quota of the API key"
+                            },
+                            "X-RateLimit-Remaining": {
+                                "type": "integer",
+                                "description": "Validations remaining today for the API key"
+                            },
+                            "X-RateLimit-Reset": {
+                                "type": "integer",
+                                "description": "Unix time the quota resets"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ValidationJob"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the job"
+                            },
+                            "X-RateLimit-Limit": {
+                                "type": "integer",
+                                "description": "Daily validation quota of the API key"
+                            },
+                            "X-RateLimit-Remaining": {
+                                "type": "integer",
+                                "description": "Validations remaining today for the API key"
+                            },
+                            "X-RateLimit-Reset": {
+                                "type": "integer",
+                                "description": "Unix time the quota resets"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ValidationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        },
+                        "headers": {
+                            "X-RateLimit-Limit": {
+                                "type": "integer",
+                                "description": "Daily validation quota of the API key"
+                            },
+                            "X-RateLimit-Remaining": {
+                                "type": "integer",
+                                "description": "Validations remaining today for the API key"
+                            },
+                            "X-RateLimit-Reset": {
+                                "type": "integer",
+                                "description": "Unix time the quota resets"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shipping/labels/validate-batch": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "check many shipping labels concurrently for audits. Send JSON with base64 JPEG or PNG images, or a multipart form with a zip of images in the file field and stationId, locale and parallelism fields; images named after their tracking number are checked against it. Items which fail are reported in their result without failing the batch. Each item counts against the rate limit and daily quota of an API key.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipping"
+                ],
+                "summary": "Check a batch of shipping labels",
+                "parameters": [
+                    {
+                        "description": "Batch Request",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/validations": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "list stored validations, newest first, to audit verdicts and overrides",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "validations"
+                ],
+                "summary": "List validations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day to include (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day to include (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tracking number",
+                        "name": "trackingNumber",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Effective verdict",
+                        "name": "valid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only overridden or not overridden validations",
+                        "name": "overridden",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Override reason code",
+                        "name": "reasonCode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum validations to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ValidationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/validations/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "get the status, progress and, once finished, the result of an asynchronous validation",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "validations"
+                ],
+                "summary": "Get a validation job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ValidationJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/validations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "get a stored validation with its original and effective verdict",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "validations"
+                ],
+                "summary": "Get a validation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Validation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Validation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/validations/{id}/label": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "render a corrected 4x6 label for a validation from the carrier's data, as ZPL for thermal printers or PDF for laser printers",
+                "produces": [
+                    "application/zpl",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "validations"
+                ],
+                "summary": "Reprint a label",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Validation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "zpl",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "default": "zpl",
+                        "description": "Label format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/validations/{id}/override": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "override the verdict of an invalid validation, recording who overrode it, when and why",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "validations"
+                ],
+                "summary": "Override a validation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Validation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Override Request",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/OverrideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Validation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "APIKey": {
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "dailyQuota": {
+                    "type": "integer"
+                },
+                "facility": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rateLimit": {
+                    "type": "number"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "Address": {
+            "type": "object",
+            "properties": {
+                "addressLine1": {
+                    "type": "string"
+                },
+                "addressLine2": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "countryCode": {
+                    "type": "string"
+                },
+                "postalCode": {
+                    "type": "string"
+                },
+                "stateProvince": {
+                    "type": "string"
+                }
+            }
+        },
+        "BatchItemRequest": {
+            "type": "object",
+            "properties": {
+                "image": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                }
+            }
+        },
+        "BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/ErrorResponse"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/ValidationResult"
+                },
+                "status": {
+                    "$ref": "#/definitions/BatchItemStatus"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                }
+            }
+        },
+        "BatchItemStatus": {
+            "type": "string",
+            "enum": [
+                "VALID",
+                "INVALID",
+                "ERROR"
+            ],
+            "x-enum-varnames": [
+                "BatchItemStatusValid",
+                "BatchItemStatusInvalid",
+                "BatchItemStatusError"
+            ]
+        },
+        "BatchRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/BatchItemRequest"
+                    }
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "parallelism": {
+                    "type": "integer"
+                },
+                "stationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "BatchResult": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/BatchItemResult"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/BatchSummary"
+                }
+            }
+        },
+        "BatchSummary": {
+            "type": "object",
+            "properties": {
+                "durationMs": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "CallbackStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "DELIVERED",
+                "FAILED"
+            ],
+            "x-enum-varnames": [
+                "CallbackStatusPending",
+                "CallbackStatusDelivered",
+                "CallbackStatusFailed"
+            ]
+        },
+        "CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "burst": {
+                    "type": "integer"
+                },
+                "dailyQuota": {
+                    "type": "integer"
+                },
+                "facility": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rateLimit": {
+                    "type": "number"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "apiKey": {
+                    "$ref": "#/definitions/APIKey"
+                },
+                "key": {
+                    "description": "Key is only returned when the key is created",
+                    "type": "string"
+                }
+            }
+        },
+        "Duplicate": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "$ref": "#/definitions/DuplicateKind"
+                },
+                "previousScannedAt": {
+                    "type": "string"
+                },
+                "previousScans": {
+                    "description": "PreviousScans counts the earlier validations within the window",
+                    "type": "integer"
+                },
+                "previousStationId": {
+                    "type": "string"
+                },
+                "previousValidationId": {
+                    "description": "Previous is the latest earlier validation of the kind",
+                    "type": "string"
+                }
+            }
+        },
+        "DuplicateKind": {
+            "type": "string",
+            "enum": [
+                "RESCAN",
+                "SECOND_BOX"
+            ],
+            "x-enum-varnames": [
+                "DuplicateKindRescan",
+                "DuplicateKindSecondBox"
+            ]
+        },
+        "ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "Event": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/ErrorResponse"
+                },
+                "facility": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/ValidationResult"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/EventType"
+                },
+                "validationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "EventType": {
+            "type": "string",
+            "enum": [
+                "received",
+                "extracting",
+                "looking_up",
+                "verdict",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "EventTypeReceived",
+                "EventTypeExtracting",
+                "EventTypeLookingUp",
+                "EventTypeVerdict",
+                "EventTypeFailed"
+            ]
+        },
+        "ListAPIKeysResponse": {
+            "type": "object",
+            "properties": {
+                "apiKeys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/APIKey"
+                    }
+                }
+            }
+        },
+        "Manifest": {
+            "type": "object",
+            "properties": {
+                "closedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "facility": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/ManifestStatus"
+                },
+                "trackingNumbers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trailerId": {
+                    "type": "string"
+                }
+            }
+        },
+        "ManifestHeader": {
+            "type": "object",
+            "properties": {
+                "closedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/ManifestStatus"
+                },
+                "trailerId": {
+                    "type": "string"
+                }
+            }
+        },
+        "ManifestPackageStatus": {
+            "type": "string",
+            "enum": [
+                "VALID",
+                "INVALID",
+                "MISSING",
+                "EXTRA"
+            ],
+            "x-enum-varnames": [
+                "PackageStatusValid",
+                "PackageStatusInvalid",
+                "PackageStatusMissing",
+                "PackageStatusExtra"
+            ]
+        },
+        "ManifestStatus": {
+            "type": "string",
+            "enum": [
+                "OPEN",
+                "CLOSED"
+            ],
+            "x-enum-varnames": [
+                "StatusOpen",
+                "StatusClosed"
+            ]
+        },
+        "ManifestsResponse": {
+            "type": "object",
+            "properties": {
+                "manifests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Manifest"
+                    }
+                }
+            }
+        },
+        "Override": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "by": {
+                    "type": "string"
+                },
+                "originalValid": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reasonCode": {
+                    "$ref": "#/definitions/OverrideReasonCode"
+                }
+            }
+        },
+        "OverrideReasonCode": {
+            "type": "string",
+            "enum": [
+                "ADDRESS_MATCHES",
+                "CARRIER_DATA_OUTDATED",
+                "LABEL_REPRINTED",
+                "IMAGE_UNREADABLE",
+                "OTHER"
+            ],
+            "x-enum-varnames": [
+                "OverrideReasonAddressMatches",
+                "OverrideReasonCarrierDataOutdated",
+                "OverrideReasonLabelReprinted",
+                "OverrideReasonImageUnreadable",
+                "OverrideReasonOther"
+            ]
+        },
+        "OverrideRequest": {
+            "type": "object",
+            "required": [
+                "reasonCode"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "reasonCode": {
+                    "enum": [
+                        "ADDRESS_MATCHES",
+                        "CARRIER_DATA_OUTDATED",
+                        "LABEL_REPRINTED",
+                        "IMAGE_UNREADABLE",
+                        "OTHER"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/OverrideReasonCode"
+                        }
+                    ]
+                }
+            }
+        },
+        "PackageAddress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "$ref": "#/definitions/Address"
+                },
+                "attentionName": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "PrintJob": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "facility": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "printer": {
+                    "type": "string"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/PrintJobStatus"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "validationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "PrintJobRequest": {
+            "type": "object",
+            "required": [
+                "validationId"
+            ],
+            "properties": {
+                "printer": {
+                    "type": "string"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "validationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "PrintJobStatus": {
+            "type": "string",
+            "enum": [
+                "QUEUED",
+                "PRINTING",
+                "COMPLETED",
+                "FAILED"
+            ],
+            "x-enum-varnames": [
+                "JobStatusQueued",
+                "JobStatusPrinting",
+                "JobStatusCompleted",
+                "JobStatusFailed"
+            ]
+        },
+        "PrintJobsResponse": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PrintJob"
+                    }
+                }
+            }
+        },
+        "ReconciledPackage": {
+            "type": "object",
+            "properties": {
+                "duplicate": {
+                    "type": "boolean"
+                },
+                "expected": {
+                    "type": "boolean"
+                },
+                "lastScannedAt": {
+                    "type": "string"
+                },
+                "overridden": {
+                    "type": "boolean"
+                },
+                "scans": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/ManifestPackageStatus"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                },
+                "validationId": {
+                    "type": "string"
+                }
+            }
+        },
+        "Reconciliation": {
+            "type": "object",
+            "properties": {
+                "manifest": {
+                    "$ref": "#/definitions/ManifestHeader"
+                },
+                "packages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ReconciledPackage"
                     }
+                },
+                "summary": {
+                    "$ref": "#/definitions/ReconciliationSummary"
                 }
             }
-        }
-    },
-    "definitions": {
-        "Address": {
+        },
+        "ReconciliationSummary": {
             "type": "object",
             "properties": {
-                "addressLine1": {
+                "duplicate": {
+                    "type": "integer"
+                },
+                "expected": {
+                    "type": "integer"
+                },
+                "extra": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "type": "integer"
+                },
+                "scanned": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ReportCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "key": {
                     "type": "string"
+                }
+            }
+        },
+        "ReportGroup": {
+            "type": "object",
+            "properties": {
+                "averageLatencyMs": {
+                    "type": "number"
                 },
-                "addressLine2": {
+                "duplicates": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "invalidRate": {
+                    "type": "number"
+                },
+                "key": {
                     "type": "string"
                 },
-                "city": {
+                "overridden": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ReportSummary": {
+            "type": "object",
+            "properties": {
+                "from": {
                     "type": "string"
                 },
-                "country": {
+                "hourly": {
+                    "description": "Hourly is keyed by the start of the hour in the report time zone",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ReportGroup"
+                    }
+                },
+                "mismatchFields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ReportCount"
+                    }
+                },
+                "operators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ReportGroup"
+                    }
+                },
+                "overrideReasons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ReportCount"
+                    }
+                },
+                "stations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ReportGroup"
+                    }
+                },
+                "timezone": {
                     "type": "string"
                 },
-                "countryCode": {
+                "to": {
                     "type": "string"
                 },
-                "postalCode": {
+                "totals": {
+                    "$ref": "#/definitions/ReportGroup"
+                }
+            }
+        },
+        "Scanner": {
+            "type": "object",
+            "properties": {
+                "connectedAt": {
                     "type": "string"
                 },
-                "stateProvince": {
+                "facility": {
+                    "type": "string"
+                },
+                "lastScanAt": {
+                    "type": "string"
+                },
+                "scannerId": {
+                    "type": "integer"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "PackageAddress": {
+        "ScannersResponse": {
             "type": "object",
             "properties": {
-                "address": {
-                    "$ref": "#/definitions/Address"
+                "scanners": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Scanner"
+                    }
+                }
+            }
+        },
+        "Token": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "type": "string"
                 },
-                "attentionName": {
+                "expiresAt": {
                     "type": "string"
                 },
-                "name": {
+                "tokenType": {
+                    "type": "string"
+                }
+            }
+        },
+        "TokenRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
                     "type": "string"
                 },
-                "type": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "TokenResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "$ref": "#/definitions/Token"
+                }
+            }
+        },
+        "Usage": {
+            "type": "object",
+            "properties": {
+                "cachedTokens": {
+                    "type": "integer"
+                },
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "promptTokens": {
+                    "type": "integer"
+                },
+                "reasoningTokens": {
+                    "type": "integer"
+                },
+                "totalTokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "UsageResponse": {
+            "type": "object",
+            "properties": {
+                "summaries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UsageSummary"
+                    }
+                },
+                "total": {
+                    "$ref": "#/definitions/UsageSummary"
+                }
+            }
+        },
+        "UsageSummary": {
+            "type": "object",
+            "properties": {
+                "cachedRequests": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "day": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "usage": {
+                    "$ref": "#/definitions/Usage"
+                }
+            }
+        },
+        "Validation": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "durationMs": {
+                    "description": "DurationMs is the time taken to reach the verdict",
+                    "type": "integer"
+                },
+                "facility": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "manifestId": {
+                    "type": "string"
+                },
+                "override": {
+                    "$ref": "#/definitions/Override"
+                },
+                "perceptualHash": {
+                    "description": "PerceptualHash is the dHash of the label image, used to tell rescans\nof a label from copies of it on other boxes",
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/ValidationResult"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "trackingNumber": {
                     "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
                 }
             }
         },
@@ -123,12 +2858,85 @@ const docTemplate = `{
                 }
             }
         },
+        "ValidationJob": {
+            "type": "object",
+            "properties": {
+                "callbackStatus": {
+                    "$ref": "#/definitions/CallbackStatus"
+                },
+                "callbackUrl": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/ErrorResponse"
+                },
+                "facility": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/ValidationResult"
+                },
+                "stage": {
+                    "type": "string"
+                },
+                "stationId": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/ValidationJobStatus"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "ValidationJobStatus": {
+            "type": "string",
+            "enum": [
+                "QUEUED",
+                "RUNNING",
+                "COMPLETED",
+                "FAILED"
+            ],
+            "x-enum-varnames": [
+                "JobStatusQueued",
+                "JobStatusRunning",
+                "JobStatusCompleted",
+                "JobStatusFailed"
+            ]
+        },
         "ValidationRequest": {
             "type": "object",
             "properties": {
+                "async": {
+                    "description": "Async queues the validation and responds with a job to poll instead of the result",
+                    "type": "boolean"
+                },
+                "callbackUrl": {
+                    "description": "CallbackURL receives the finished job. Setting it implies async.",
+                    "type": "string"
+                },
                 "image": {
                     "type": "string"
                 },
+                "locale": {
+                    "type": "string"
+                },
+                "stationId": {
+                    "type": "string"
+                },
                 "trackingNumber": {
                     "type": "string"
                 }
@@ -145,9 +2953,25 @@ const docTemplate = `{
         "ValidationResult": {
             "type": "object",
             "properties": {
+                "duplicate": {
+                    "$ref": "#/definitions/Duplicate"
+                },
                 "expectedAddress": {
                     "$ref": "#/definitions/PackageAddress"
                 },
+                "id": {
+                    "type": "string"
+                },
+                "mismatches": {
+                    "description": "Mismatches lists the address fields which differ from the expected address",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "promptVersion": {
+                    "type": "string"
+                },
                 "scannedAddress": {
                     "$ref": "#/definitions/Address"
                 },
@@ -155,9 +2979,25 @@ const docTemplate = `{
                     "type": "boolean"
                 }
             }
+        },
+        "ValidationsResponse": {
+            "type": "object",
+            "properties": {
+                "validations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Validation"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ApiKey": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "Bearer": {
             "type": "apiKey",
             "name": "Authorization",
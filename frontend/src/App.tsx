@@ -1,27 +1,65 @@
 
 import useWebSocket, { ReadyState } from "react-use-websocket";
-import { useEffect, useRef, useState } from 'react';
-import type { ScannerResponse } from './BarcodeScanner';
+import { useRef, useState } from 'react';
+import type { GatewayCommand } from './BarcodeScanner';
 import { Shipping } from './generated/Shipping';
 import type { ValidationResponse, ValidationResult } from './generated/data-contracts';
 import { ClipLoader } from "react-spinners";
 import { toast, ToastContainer } from "react-toastify";
 import "react-toastify/dist/ReactToastify.css";
 import BarcodeListener from "./BarcodeListener";
+import Login, { type Token } from "./Login";
+
+const { VITE_API_BASE_URL, VITE_STATION_ID } = import.meta.env;
+const apiBaseUrl = VITE_API_BASE_URL || "/api/latest";
+
+// the bearer token from /auth/token is kept in local storage until it expires
+const storedToken = () => {
+    const expiresAt = localStorage.getItem("accessTokenExpiresAt");
+    if (!expiresAt || Date.parse(expiresAt) <= Date.now()) {
+        return "";
+    }
+    return localStorage.getItem("accessToken") ?? "";
+};
+
+const storeToken = (token?: Token) => {
+    if (!token) {
+        localStorage.removeItem("accessToken");
+        localStorage.removeItem("accessTokenExpiresAt");
+        return;
+    }
+    localStorage.setItem("accessToken", token.accessToken);
+    localStorage.setItem("accessTokenExpiresAt", token.expiresAt);
+};
+
+// scannerGatewayUrl returns the websocket URL of the scanner gateway, relative
+// to the page when the API base URL is a path. Browsers cannot set headers on
+// a websocket, so the gateway takes the token as access_token.
+const scannerGatewayUrl = (accessToken: string) => {
+    const url = new URL(`${apiBaseUrl}/scanners/connect`, window.location.href);
+    url.protocol = url.protocol === "https:" ? "wss:" : "ws:";
+    if (VITE_STATION_ID) {
+        url.searchParams.set("stationId", VITE_STATION_ID);
+    }
+    url.searchParams.set("access_token", accessToken);
+    return url.toString();
+};
 
 function App() {
-    const { VITE_API_BASE_URL } = import.meta.env;
+    const [accessToken, setAccessToken] = useState(storedToken);
     const shippingApi = new Shipping({
-        baseURL: `${VITE_API_BASE_URL}`,
+        baseURL: apiBaseUrl,
+        headers: { Authorization: `Bearer ${accessToken}` },
     });
+    // the gateway is only connected once logged in
     const {
-        lastMessage,
         sendJsonMessage,
         readyState,
-    } = useWebSocket("ws://172.20.10.2:8089", {
+    } = useWebSocket(accessToken ? scannerGatewayUrl(accessToken) : null, {
         shouldReconnect: () => true, // Always try to reconnect
         reconnectAttempts: 100000, // Always retry
         reconnectInterval: 3 * 1000, // Reconnect attempt interval in milliseconds
+        onMessage: (event) => onGatewayMessage(event.data),
     });
     const mobileCameraRef = useRef<HTMLInputElement>(null);
     const [scanState, setScanState] = useState<"idle" | "validating" | "invalid">("idle"); // Possible states: idle, scanning, processing
@@ -32,50 +70,60 @@ function App() {
     const successBeep = new Audio("/beep-success.mp3");
     const errorBeep = new Audio("/beep-error.mp3");
 
-    // Process image data from barcode scanner through websocket
-    useEffect(() => {
-        if (readyState === ReadyState.OPEN && lastMessage !== null && scanState === "idle") {
-            try {
-                const msg: ScannerResponse = JSON.parse(lastMessage.data);
-                if (msg.messageType === "image") {
-                    setScanState("validating");
-                    setBarcodeScannerImage(msg.image!);
-                    shippingApi.labelValidateCreate({
-                        trackingNumber: barcode,
-                        image: msg.image,
-                    })
-                        .then(({ data }: { data: ValidationResponse }) => {
-                            setResult(data.result);
-                            if (data.result?.valid) {
-                                successBeep.play();
-                                toast.success("Valid. Scan next label.");
-                                setScanState("idle");
-                            } else {
-                                errorBeep.play();
-                                setScanState("invalid");
-                            }
-                        }).catch((error) => {
-                            toast.error("Error validating:", error);
-                        });
-                }
-            } catch (e) {
-                if (e instanceof Error) {
-                    toast.error(`Error parsing scanner response JSON: ${e.message}`);
-                } else {
-                    toast.error("Unknown error parsing scanner response JSON");
-                }
+    const scannerIdRef = useRef(0);
+
+    const onLogin = (token: Token) => {
+        storeToken(token);
+        setAccessToken(token.accessToken);
+    };
+
+    const logout = () => {
+        storeToken();
+        setAccessToken("");
+    };
+
+    const showResult = (result?: ValidationResult) => {
+        setResult(result);
+        if (result?.valid) {
+            successBeep.play();
+            toast.success("Valid. Scan next label.");
+            setScanState("idle");
+        } else {
+            errorBeep.play();
+            setScanState("invalid");
+        }
+    };
+
+    // The gateway asks for an image after a barcode and answers an image with
+    // its validation
+    const onGatewayMessage = (data: string) => {
+        try {
+            const msg: GatewayCommand = JSON.parse(data);
+            if (msg.commandType === "image_capture") {
+                scannerIdRef.current = msg.scannerId;
+                toast.info("Take a picture of the label");
+            } else if (msg.messageType === "validation") {
+                showResult(msg.result);
+            } else if (msg.messageType === "error") {
+                toast.error(`Error validating: ${msg.error}`);
+                setScanState("idle");
+            }
+        } catch (e) {
+            if (e instanceof Error) {
+                toast.error(`Error parsing scanner gateway JSON: ${e.message}`);
+            } else {
+                toast.error("Unknown error parsing scanner gateway JSON");
             }
         }
-    }, [lastMessage]);
+    };
 
     const onBarcodeScan = (scannerId: number | undefined, barcode: string) => {
         setBarcode(barcode);
-        sendJsonMessage(
-            JSON.stringify({
-                scannerId: scannerId,
-                commandType: "image_capture",
-            })
-        );
+        sendJsonMessage({
+            messageType: "barcode",
+            scannerId: scannerId ?? 0,
+            barcode: barcode,
+        });
     }
 
     const convertBase64 = (file: File) => {
@@ -119,25 +167,33 @@ function App() {
                                 const file = e.target.files[0];
                                 const base64Image = await convertBase64(file);
                                 setScanState("validating");
-                                setBarcodeScannerImage(base64Image);
+                                setBarcodeScannerImage(base64Image.split(",")[1]);
+
+                                // the gateway pairs the image with the scanned
+                                // barcode; it only reads JPEGs
+                                if (readyState === ReadyState.OPEN && file.type === "image/jpeg") {
+                                    sendJsonMessage({
+                                        messageType: "image",
+                                        scannerId: scannerIdRef.current,
+                                        image: base64Image.split(",")[1],
+                                    });
+                                    return;
+                                }
 
                                 shippingApi.labelValidateCreate({
                                     trackingNumber: barcode,
                                     image: base64Image.split(",")[1],
                                 })
                                     .then(({ data }: { data: ValidationResponse }) => {
-                                        setResult(data.result);
-                                        if (data.result?.valid) {
-                                            successBeep.play();
-                                            toast.success("Valid. Scan next label.");
-                                            setScanState("idle");
-                                        } else {
-                                            errorBeep.play();
-                                            setScanState("invalid");
-                                        }
+                                        showResult(data.result);
                                     }).catch((error) => {
-                                        toast.error("Error validating:", error);
                                         setScanState("idle");
+                                        if (error.response?.status === 401) {
+                                            toast.error("Your session expired, log in again");
+                                            logout();
+                                            return;
+                                        }
+                                        toast.error("Error validating:", error);
                                     });
                             }}
                         />
@@ -201,13 +257,14 @@ function App() {
                 <div className="flex flex-row bg-[#301506] items-center">
                     <img src="/ups.svg" alt="UPS Logo" className="w-16 h-16 p-4" />
                     <div className="text-2xl text-[#FAB80A]">Validate</div>
+                    {accessToken && <div className="ml-auto p-4 text-xl text-[#FAB80A] cursor-pointer" onClick={logout}>Log out</div>}
                 </div>
                 {scanState === "invalid" && <div className="w-full text-2xl text-center bg-red-500 text-white p-2">Invalid label detected</div>}
                 <div className="flex flex-col h-full w-full items-center lg:justify-center p-4">
-                    {getBody()}
+                    {accessToken ? getBody() : <Login apiBaseUrl={apiBaseUrl} onLogin={onLogin} />}
                 </div>
             </div>
-            <BarcodeListener barcodeData={lastMessage?.data} onBarcodeScan={onBarcodeScan} />
+            <BarcodeListener onBarcodeScan={onBarcodeScan} />
             <ToastContainer
                 position="top-right"
                 autoClose={3000}
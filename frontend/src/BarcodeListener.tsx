@@ -1,14 +1,10 @@
 import { useEffect, useRef } from "react";
-import type { ScannerResponse } from "./BarcodeScanner";
-import { toast } from "react-toastify";
 
 type Props = {
-    barcodeData?: string;
     onBarcodeScan?: (scannerId: number | undefined, barcode: string) => void;
 };
 
 export default function BarcodeListener({
-    barcodeData,
     onBarcodeScan,
 }: Props) {
     const mountedRef = useRef<boolean>(false);
@@ -63,22 +59,5 @@ export default function BarcodeListener({
         };
     }, []);
 
-    useEffect(() => {
-        if (barcodeData) {
-            try {
-                const msg: ScannerResponse = JSON.parse(barcodeData!);
-                if (msg.messageType !== "barcode" || msg.barcode === undefined) {
-                    return;
-                }
-                processKeypresses(msg.scannerId, msg.barcode)
-            } catch (e) {
-                if (e instanceof Error) {
-                    toast.error(`Error parsing scanner response JSON: ${e.message}`);
-                }
-                toast.error("Unknown error parsing scanner response JSON");
-            }
-        }
-    }, [barcodeData]);
-
     return <></>;
 }
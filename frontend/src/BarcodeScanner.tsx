@@ -1,3 +1,5 @@
+import type { ValidationResult } from "./generated/data-contracts";
+
 export interface BarcodeScannerOption {
     scannerId: number;
     status: string;
@@ -10,4 +12,13 @@ export interface ScannerResponse {
     image?: string;
     scanners?: BarcodeScannerOption[];
     status: boolean;
-}
\ No newline at end of file
+}
+
+// GatewayCommand is a message from the scanner gateway
+export interface GatewayCommand {
+    scannerId: number;
+    commandType?: "image_capture";
+    messageType?: "validation" | "error";
+    result?: ValidationResult;
+    error?: string;
+}
@@ -0,0 +1,71 @@
+import { useState, type FormEvent } from "react";
+import axios from "axios";
+import { toast } from "react-toastify";
+
+export type Token = {
+    accessToken: string;
+    tokenType: string;
+    expiresAt: string;
+};
+
+type TokenResponse = {
+    token: Token;
+};
+
+type Props = {
+    apiBaseUrl: string;
+    onLogin: (token: Token) => void;
+};
+
+// Login exchanges a user's credentials for a bearer token at /auth/token
+export default function Login({
+    apiBaseUrl,
+    onLogin,
+}: Props) {
+    const [username, setUsername] = useState("");
+    const [password, setPassword] = useState("");
+    const [submitting, setSubmitting] = useState(false);
+
+    const onSubmit = (e: FormEvent) => {
+        e.preventDefault();
+        setSubmitting(true);
+        axios.post<TokenResponse>(`${apiBaseUrl}/auth/token`, { username, password })
+            .then(({ data }) => {
+                onLogin(data.token);
+            }).catch((error) => {
+                if (axios.isAxiosError(error) && error.response?.status === 401) {
+                    toast.error("Invalid username or password");
+                } else {
+                    toast.error(`Error logging in: ${error.message}`);
+                }
+            }).finally(() => {
+                setSubmitting(false);
+            });
+    };
+
+    return (
+        <form className="flex flex-col gap-4 w-full max-w-sm text-2xl" onSubmit={onSubmit}>
+            <input
+                className="p-4 border-2 border-black rounded-lg"
+                type="text"
+                placeholder="Username"
+                autoComplete="username"
+                value={username}
+                onChange={(e) => setUsername(e.target.value)}
+            />
+            <input
+                className="p-4 border-2 border-black rounded-lg"
+                type="password"
+                placeholder="Password"
+                autoComplete="current-password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+            />
+            <button
+                className="p-4 border-4 border-[#301506] bg-[#301506] rounded-lg text-4xl text-[#FAB80A] cursor-pointer text-center"
+                type="submit"
+                disabled={submitting || !username || !password}
+            >Log in</button>
+        </form>
+    );
+}
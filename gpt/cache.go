@@ -0,0 +1,175 @@
+package gpt
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedCallTimeout bounds a provider call shared by concurrent prompts, which
+// runs detached from the cancellation of the caller which started it
+const sharedCallTimeout = 2 * time.Minute
+
+// CacheStore stores prompt results by key
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*Result, bool, error)
+	Set(ctx context.Context, key string, result *Result, ttl time.Duration) error
+}
+
+type cache struct {
+	gpt   GPT
+	store CacheStore
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+// NewCache wraps a GPT so identical prompts for the same image return the
+// prior result instead of calling the provider again. Concurrent identical
+// prompts share a single provider call. Cached results report no token usage.
+func NewCache(gpt GPT, store CacheStore, ttl time.Duration) GPT {
+	return &cache{
+		gpt:   gpt,
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+func (c *cache) Prompt(ctx context.Context, prompt string, image []byte) (*Result, error) {
+	start := time.Now()
+	key := cacheKey(prompt, image)
+
+	if cached, ok, err := c.store.Get(ctx, key); err == nil && ok {
+		metrics.CacheLookup("llm", true)
+		return cachedResult(cached, start), nil
+	}
+
+	called := false
+	shared := c.group.DoChan(key, func() (any, error) {
+		called = true
+		// a caller leaving must not fail the others waiting on the call
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedCallTimeout)
+		defer cancel()
+
+		result, err := c.gpt.Prompt(ctx, prompt, image)
+		if err != nil {
+			return nil, err
+		}
+
+		// a failure to cache should not fail the prompt
+		_ = c.store.Set(ctx, key, result, c.ttl)
+		return result, nil
+	})
+
+	var value any
+	select {
+	case res := <-shared:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		value = res.Val
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// a prompt sharing a concurrent provider call is counted as a hit
+	metrics.CacheLookup("llm", !called)
+	result := value.(*Result)
+	if !called {
+		return cachedResult(result, start), nil
+	}
+
+	return result, nil
+}
+
+func (c *cache) Ping(ctx context.Context) error {
+	return c.gpt.Ping(ctx)
+}
+
+func cacheKey(prompt string, image []byte) string {
+	promptHash := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(promptHash[:8]) + ":" + ImageHash(image)
+}
+
+func cachedResult(result *Result, start time.Time) *Result {
+	return &Result{
+		Content:  result.Content,
+		Provider: result.Provider,
+		Model:    result.Model,
+		Latency:  time.Since(start),
+		Cached:   true,
+	}
+}
+
+type memoryCacheEntry struct {
+	key       string
+	result    *Result
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// NewMemoryCache returns a CacheStore holding at most size results, evicting
+// the least recently used
+func NewMemoryCache(size int) CacheStore {
+	return &memoryCache{
+		size:    size,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) (*Result, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.lru.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+
+	m.lru.MoveToFront(element)
+	return entry.result, true, nil
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, result *Result, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &memoryCacheEntry{
+		key:       key,
+		result:    result,
+		expiresAt: time.Now().Add(ttl),
+	}
+	if element, ok := m.entries[key]; ok {
+		element.Value = entry
+		m.lru.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.lru.PushFront(entry)
+	for m.size > 0 && m.lru.Len() > m.size {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package gpt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const cacheCollectionName = "gpt_cache"
+
+type mongoCacheEntry struct {
+	Key       string    `bson:"_id"`
+	Content   string    `bson:"content"`
+	Provider  string    `bson:"provider"`
+	Model     string    `bson:"model"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+type mongoCache struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCache returns a CacheStore backed by a Mongo collection. Expired
+// results are removed by a TTL index.
+func NewMongoCache(ctx context.Context, db *mongo.Database) (CacheStore, error) {
+	collection := db.Collection(cacheCollectionName)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoCache{
+		collection: collection,
+	}, nil
+}
+
+func (m *mongoCache) Get(ctx context.Context, key string) (*Result, bool, error) {
+	entry := mongoCacheEntry{}
+	err := m.collection.FindOne(ctx, bson.M{
+		"_id":       key,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Result{
+		Content:  entry.Content,
+		Provider: entry.Provider,
+		Model:    entry.Model,
+	}, true, nil
+}
+
+func (m *mongoCache) Set(ctx context.Context, key string, result *Result, ttl time.Duration) error {
+	_, err := m.collection.ReplaceOne(ctx, bson.M{"_id": key}, mongoCacheEntry{
+		Key:       key,
+		Content:   result.Content,
+		Provider:  result.Provider,
+		Model:     result.Model,
+		ExpiresAt: time.Now().Add(ttl),
+	}, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,123 @@
+package gpt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCache(2)
+
+	_ = store.Set(ctx, "a", &Result{Content: "a"}, time.Minute)
+	_ = store.Set(ctx, "b", &Result{Content: "b"}, time.Minute)
+	// reading a makes b the least recently used
+	if _, ok, _ := store.Get(ctx, "a"); !ok {
+		t.Fatal("a missing")
+	}
+	_ = store.Set(ctx, "c", &Result{Content: "c"}, time.Minute)
+	_ = store.Set(ctx, "expired", &Result{Content: "expired"}, -time.Second)
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"a", false}, // evicted by expired, the most recent set
+		{"b", false},
+		{"c", true},
+		{"expired", false},
+	}
+	for _, tt := range tests {
+		if _, ok, _ := store.Get(ctx, tt.key); ok != tt.want {
+			t.Errorf("Get(%s) found = %v, want %v", tt.key, ok, tt.want)
+		}
+	}
+}
+
+func TestCacheReturnsCachedResults(t *testing.T) {
+	stub := &stubGPT{}
+	c := NewCache(stub, NewMemoryCache(10), time.Minute)
+
+	first, err := c.Prompt(context.Background(), "prompt", []byte("image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Prompt(context.Background(), "prompt", []byte("image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Prompt(context.Background(), "prompt", []byte("other image")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := stub.calls.Load(); got != 2 {
+		t.Errorf("provider calls = %d, want 2", got)
+	}
+	if first.Cached || !second.Cached {
+		t.Errorf("cached = %v, %v, want false, true", first.Cached, second.Cached)
+	}
+	if second.Usage.TotalTokens != 0 {
+		t.Errorf("cached usage = %d tokens, want 0", second.Usage.TotalTokens)
+	}
+}
+
+func TestCacheDoesNotCacheErrors(t *testing.T) {
+	stub := &stubGPT{err: ErrUnavailable}
+	c := NewCache(stub, NewMemoryCache(10), time.Minute)
+
+	for range 2 {
+		if _, err := c.Prompt(context.Background(), "prompt", []byte("image")); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("err = %v, want %v", err, ErrUnavailable)
+		}
+	}
+	if got := stub.calls.Load(); got != 2 {
+		t.Errorf("provider calls = %d, want 2", got)
+	}
+}
+
+func TestCacheSharesConcurrentPrompts(t *testing.T) {
+	stub := &stubGPT{release: make(chan struct{})}
+	c := NewCache(stub, NewMemoryCache(10), time.Minute)
+
+	// the first caller leaves before the shared call finishes
+	leaving, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.Prompt(leaving, "prompt", []byte("image"))
+		first <- err
+	}()
+	for stub.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Prompt(context.Background(), "prompt", []byte("image"))
+			errs <- err
+		}()
+	}
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("leaving caller err = %v, want %v", err, context.Canceled)
+	}
+	close(stub.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("waiting caller err = %v, want nil", err)
+		}
+	}
+	if got := stub.calls.Load(); got != 1 {
+		t.Errorf("provider calls = %d, want 1", got)
+	}
+}
@@ -0,0 +1,73 @@
+package gpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+)
+
+var (
+	ErrQuotaExceeded  error = helpers.NewClientError("llm quota exceeded", http.StatusTooManyRequests, "llm_quota_exceeded")
+	ErrContentBlocked error = helpers.NewClientError("llm blocked the label image", http.StatusUnprocessableEntity, "llm_content_blocked")
+	ErrTimeout        error = helpers.NewClientError("llm request timed out", http.StatusGatewayTimeout, "llm_timeout")
+	ErrUnauthorized   error = helpers.NewClientError("llm provider rejected the credentials", http.StatusBadGateway, "llm_unauthorized")
+	ErrUnavailable    error = helpers.NewClientError("llm provider is unavailable", http.StatusBadGateway, "llm_unavailable")
+	ErrInvalidContent error = helpers.NewClientError("llm returned an invalid response", http.StatusBadGateway, "llm_invalid_response")
+)
+
+// Error is an error response from a provider. The body is kept for logging and
+// must not be returned to API clients.
+type Error struct {
+	Err        error
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s (%d): %s", e.Provider, e.Err, e.StatusCode, e.Body)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(provider string, statusCode int, body string) *Error {
+	var err error
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		err = ErrQuotaExceeded
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		err = ErrUnauthorized
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		err = ErrTimeout
+	default:
+		err = ErrUnavailable
+	}
+
+	return &Error{
+		Err:        err,
+		Provider:   provider,
+		StatusCode: statusCode,
+		Body:       body,
+	}
+}
+
+// transportError wraps an error sending a request to a provider as ErrTimeout
+// or ErrUnavailable
+func transportError(provider string, err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%s: %w: %w", provider, ErrTimeout, err)
+	}
+
+	return fmt.Errorf("%s: %w: %w", provider, ErrUnavailable, err)
+}
@@ -0,0 +1,156 @@
+package gpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrNoRecording = errors.New("no recording found for image")
+
+// Recording is a captured GPT response stored as <image hash>.json
+type Recording struct {
+	ImageHash string        `json:"imageHash"`
+	Model     string        `json:"model"`
+	Content   string        `json:"content"`
+	Usage     Usage         `json:"usage"`
+	Latency   time.Duration `json:"latency"`
+}
+
+type fake struct {
+	dir        string
+	recordings map[string]Recording
+}
+
+// NewFake returns a GPT that replays recordings from dir keyed by image hash
+func NewFake(dir string) (GPT, error) {
+	if dir == "" {
+		return nil, errors.New("recordings directory is not set")
+	}
+
+	f := &fake{
+		dir:        dir,
+		recordings: map[string]Recording{},
+	}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *fake) load() error {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		recordingBytes, err := os.ReadFile(filepath.Join(f.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		recording := Recording{}
+		if err := json.Unmarshal(recordingBytes, &recording); err != nil {
+			return fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if recording.ImageHash == "" {
+			recording.ImageHash = strings.TrimSuffix(entry.Name(), ".json")
+		}
+		f.recordings[recording.ImageHash] = recording
+	}
+
+	return nil
+}
+
+func (f *fake) Prompt(ctx context.Context, _ string, image []byte) (*Result, error) {
+	hash := ImageHash(image)
+
+	recording, ok := f.recordings[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoRecording, hash)
+	}
+
+	model := recording.Model
+	if model == "" {
+		model = "fake"
+	}
+
+	if recording.Latency > 0 {
+		select {
+		case <-time.After(recording.Latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return &Result{
+		Content:  recording.Content,
+		Provider: "fake",
+		Model:    model,
+		Latency:  recording.Latency,
+		Usage:    recording.Usage,
+		Raw:      recording,
+	}, nil
+}
+
+// Ping always succeeds as recordings are replayed from disk
+func (f *fake) Ping(context.Context) error {
+	return nil
+}
+
+type recorder struct {
+	gpt GPT
+	dir string
+}
+
+// NewRecorder wraps a GPT and saves every successful response to dir so it
+// can later be replayed with NewFake
+func NewRecorder(gpt GPT, dir string) (GPT, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &recorder{
+		gpt: gpt,
+		dir: dir,
+	}, nil
+}
+
+func (r *recorder) Prompt(ctx context.Context, prompt string, image []byte) (*Result, error) {
+	start := time.Now()
+	result, err := r.gpt.Prompt(ctx, prompt, image)
+	if err != nil {
+		return nil, err
+	}
+
+	recording := Recording{
+		ImageHash: ImageHash(image),
+		Model:     result.Model,
+		Content:   result.Content,
+		Usage:     result.Usage,
+		Latency:   time.Since(start),
+	}
+	recordingBytes, err := json.MarshalIndent(recording, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, recording.ImageHash+".json"), recordingBytes, 0o644); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *recorder) Ping(ctx context.Context) error {
+	return r.gpt.Ping(ctx)
+}
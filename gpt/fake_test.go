@@ -0,0 +1,143 @@
+package gpt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// stubGPT counts prompts and blocks each one until release is closed
+type stubGPT struct {
+	calls   atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (s *stubGPT) Prompt(ctx context.Context, prompt string, _ []byte) (*Result, error) {
+	s.calls.Add(1)
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return &Result{Content: prompt, Provider: "stub", Usage: Usage{TotalTokens: 10}}, nil
+}
+
+func (s *stubGPT) Ping(context.Context) error {
+	return nil
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	image := []byte("label image")
+
+	upstream := &stubGPT{}
+	recorder, err := NewRecorder(upstream, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded, err := recorder.Prompt(ctx, `{"trackingNumber":"1Z999AA10123456784"}`, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ImageHash(image)+".json")); err != nil {
+		t.Fatalf("recording not written: %v", err)
+	}
+
+	fake, err := NewFake(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := fake.Prompt(ctx, "a different prompt", image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Content != recorded.Content {
+		t.Errorf("content = %q, want %q", replayed.Content, recorded.Content)
+	}
+	if replayed.Usage != recorded.Usage {
+		t.Errorf("usage = %+v, want %+v", replayed.Usage, recorded.Usage)
+	}
+	if replayed.Provider != "fake" {
+		t.Errorf("provider = %q, want fake", replayed.Provider)
+	}
+	if calls := upstream.calls.Load(); calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+}
+
+func TestRecorderSkipsFailures(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(&stubGPT{err: errors.New("boom")}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := recorder.Prompt(context.Background(), "prompt", []byte("image")); err == nil {
+		t.Fatal("expected the upstream error")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("wrote %d recordings for a failed prompt", len(entries))
+	}
+}
+
+func TestFakeReplay(t *testing.T) {
+	dir := t.TempDir()
+	image := []byte("label image")
+	hash := ImageHash(image)
+
+	// the image hash falls back to the file name when it is not in the recording
+	recording := `{"model": "gpt-4o", "content": "{}", "usage": {"promptTokens": 100, "completionTokens": 20, "totalTokens": 120}}`
+	if err := os.WriteFile(filepath.Join(dir, hash+".json"), []byte(recording), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake, err := NewFake(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := fake.Prompt(context.Background(), "prompt", image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Model != "gpt-4o" || result.Content != "{}" || result.Usage.TotalTokens != 120 {
+		t.Errorf("result = %+v", result)
+	}
+
+	if _, err := fake.Prompt(context.Background(), "prompt", []byte("unknown image")); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("err = %v, want ErrNoRecording", err)
+	}
+}
+
+func TestFakeInvalidRecording(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFake(dir); err == nil {
+		t.Fatal("expected an error for an invalid recording")
+	}
+	if _, err := NewFake(""); err == nil {
+		t.Fatal("expected an error for an unset directory")
+	}
+}
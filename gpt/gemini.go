@@ -3,15 +3,20 @@ package gpt
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strings"
+	"time"
 
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
 type gemini struct {
 	model  string
 	apiKey string
+	retry  retry.Policy
 }
 
 func NewGemini(model, apiKey string) (GPT, error) {
@@ -25,10 +30,13 @@ func NewGemini(model, apiKey string) (GPT, error) {
 	return &gemini{
 		model:  model,
 		apiKey: apiKey,
+		retry:  retry.DefaultPolicy(),
 	}, nil
 }
 
 func (g *gemini) Prompt(ctx context.Context, prompt string, image []byte) (*Result, error) {
+	start := time.Now()
+
 	client, err := genai.NewClient(ctx, option.WithAPIKey(g.apiKey))
 	if err != nil {
 		return nil, err
@@ -42,11 +50,19 @@ func (g *gemini) Prompt(ctx context.Context, prompt string, image []byte) (*Resu
 
 	model := client.GenerativeModel(g.model)
 	model.ResponseMIMEType = "application/json"
-	resp, err := model.GenerateContent(ctx, requestParts...)
+	var resp *genai.GenerateContentResponse
+	_, err = g.retry.Do(ctx, "gemini", func(ctx context.Context) error {
+		var err error
+		resp, err = model.GenerateContent(ctx, requestParts...)
+		return retryableGeminiError(err)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, errors.New("no candidates in response")
+	}
 	part, ok := resp.Candidates[0].Content.Parts[0].(genai.Text)
 	if !ok {
 		return nil, errors.New("invalid response type")
@@ -55,7 +71,72 @@ func (g *gemini) Prompt(ctx context.Context, prompt string, image []byte) (*Resu
 	content = strings.TrimSuffix(content, "```")
 
 	return &Result{
-		Content: content,
-		Raw:     resp,
+		Content:  content,
+		Provider: "gemini",
+		Model:    g.model,
+		Latency:  time.Since(start),
+		Usage:    geminiUsage(resp.UsageMetadata),
+		Raw:      resp,
 	}, nil
 }
+
+func geminiUsage(metadata *genai.UsageMetadata) Usage {
+	usage := Usage{}
+	if metadata == nil {
+		return usage
+	}
+
+	usage.PromptTokens = int(metadata.PromptTokenCount)
+	usage.CachedTokens = int(metadata.CachedContentTokenCount)
+	usage.TotalTokens = int(metadata.TotalTokenCount)
+	// thinking tokens are only reported as part of the total
+	usage.ReasoningTokens = max(usage.TotalTokens-usage.PromptTokens-int(metadata.CandidatesTokenCount), 0)
+	usage.CompletionTokens = int(metadata.CandidatesTokenCount) + usage.ReasoningTokens
+
+	return usage
+}
+
+// Ping looks up the configured model, which needs a valid API key
+func (g *gemini) Ping(ctx context.Context) error {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(g.apiKey))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.GenerativeModel(g.model).Info(ctx)
+	return err
+}
+
+// retryableGeminiError converts Gemini errors to typed errors, marking errors
+// where Gemini did not process the request as retryable
+func retryableGeminiError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var blockedErr *genai.BlockedError
+	if errors.As(err, &blockedErr) {
+		return &Error{
+			Err:        ErrContentBlocked,
+			Provider:   "gemini",
+			StatusCode: http.StatusOK,
+			Body:       blockedErr.Error(),
+		}
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		geminiErr := newError("gemini", apiErr.Code, apiErr.Message)
+		if retry.ShouldRetryStatus(apiErr.Code, false) {
+			return retry.Retryable(geminiErr, retry.ParseRetryAfter(apiErr.Header.Get("Retry-After")))
+		}
+		return geminiErr
+	}
+
+	if retry.ShouldRetryError(err, false) {
+		return retry.Retryable(transportError("gemini", err), 0)
+	}
+
+	return transportError("gemini", err)
+}
@@ -1,12 +1,41 @@
-package gpt
-
-import "context"
-
-type GPT interface {
-	Prompt(ctx context.Context, prompt string, image []byte) (*Result, error)
-}
-
-type Result struct {
-	Content string
-	Raw     any
-}
+package gpt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+type GPT interface {
+	Prompt(ctx context.Context, prompt string, image []byte) (*Result, error)
+	// Ping checks the provider is reachable without prompting it
+	Ping(ctx context.Context) error
+}
+
+type Result struct {
+	Content  string
+	Provider string
+	Model    string
+	Latency  time.Duration
+	Usage    Usage
+	Cached   bool
+	Raw      any
+}
+
+// Usage is the token usage of a prompt normalized across providers. Cached
+// tokens are included in PromptTokens and reasoning tokens are included in
+// CompletionTokens.
+type Usage struct {
+	PromptTokens     int `json:"promptTokens" bson:"promptTokens"`
+	CompletionTokens int `json:"completionTokens" bson:"completionTokens"`
+	CachedTokens     int `json:"cachedTokens" bson:"cachedTokens"`
+	ReasoningTokens  int `json:"reasoningTokens" bson:"reasoningTokens"`
+	TotalTokens      int `json:"totalTokens" bson:"totalTokens"`
+} // @name Usage
+
+// ImageHash returns the hex encoded SHA-256 hash of the image bytes
+func ImageHash(image []byte) string {
+	sum := sha256.Sum256(image)
+	return hex.EncodeToString(sum[:])
+}
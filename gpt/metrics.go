@@ -0,0 +1,74 @@
+package gpt
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/metrics"
+)
+
+type instrumented struct {
+	gpt      GPT
+	provider string
+	model    string
+}
+
+// NewInstrumented wraps a provider's GPT to record the latency, token usage and
+// errors of its requests. Wrap the provider rather than a cache so cached
+// results are not counted as provider requests.
+func NewInstrumented(gpt GPT, provider, model string) GPT {
+	return &instrumented{
+		gpt:      gpt,
+		provider: provider,
+		model:    model,
+	}
+}
+
+func (i *instrumented) Prompt(ctx context.Context, prompt string, image []byte) (*Result, error) {
+	metrics.LLMInFlight.Inc()
+	result, err := i.gpt.Prompt(ctx, prompt, image)
+	metrics.LLMInFlight.Dec()
+	if err != nil {
+		metrics.LLMRequests.WithLabelValues(i.provider, i.model, errorKind(err)).Inc()
+		return nil, err
+	}
+
+	// providers report the versioned model which answered
+	model := result.Model
+	if model == "" {
+		model = i.model
+	}
+	metrics.LLMRequests.WithLabelValues(i.provider, model, "ok").Inc()
+	metrics.LLMDuration.WithLabelValues(i.provider, model).Observe(result.Latency.Seconds())
+	metrics.LLMTokens.WithLabelValues(i.provider, model, "prompt").Add(float64(result.Usage.PromptTokens))
+	metrics.LLMTokens.WithLabelValues(i.provider, model, "cached").Add(float64(result.Usage.CachedTokens))
+	metrics.LLMTokens.WithLabelValues(i.provider, model, "completion").Add(float64(result.Usage.CompletionTokens))
+	metrics.LLMTokens.WithLabelValues(i.provider, model, "reasoning").Add(float64(result.Usage.ReasoningTokens))
+
+	return result, nil
+}
+
+func (i *instrumented) Ping(ctx context.Context) error {
+	return i.gpt.Ping(ctx)
+}
+
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrQuotaExceeded):
+		return "quota_exceeded"
+	case errors.Is(err, ErrContentBlocked):
+		return "content_blocked"
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrUnavailable):
+		return "unavailable"
+	case errors.Is(err, ErrInvalidContent):
+		return "invalid_content"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+
+	return "error"
+}
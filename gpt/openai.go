@@ -1,140 +1,231 @@
-package gpt
-
-import (
-	"bytes"
-	"context"
-	"encoding/base64"
-	"encoding/json"
-	"errors"
-	"io"
-	"net/http"
-	"strings"
-)
-
-type chatCompletionRequest struct {
-	Model     string    `json:"model"`
-	Messages  []message `json:"messages"`
-	MaxTokens int       `json:"max_tokens"`
-}
-
-type message struct {
-	Role    string    `json:"role"`
-	Content []content `json:"content"`
-}
-
-type content struct {
-	Type     string   `json:"type"`
-	Text     string   `json:"text,omitempty"`
-	ImageURL imageURL `json:"image_url,omitempty"`
-}
-
-type imageURL struct {
-	URL string `json:"url"`
-}
-
-type chatCompletionResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role         string `json:"role"`
-			Content      string `json:"content"`
-			FinishReason string `json:"finish_reason"`
-		} `json:"message"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens       int `json:"prompt_tokens"`
-		CompletionTokens   int `json:"completion_tokens"`
-		TotalTokens        int `json:"total_tokens"`
-		PromptTokenDetails struct {
-			CachedTokens int `json:"cached_tokens"`
-		}
-		CompletionTokenDetails struct {
-			ReasoningTokens int `json:"reasoning_tokens"`
-		}
-	} `json:"usage"`
-	SystemFingerprint string `json:"system_fingerprint"`
-}
-
-type openAI struct {
-	model  string
-	apiKey string
-}
-
-func NewOpenAI(model, apiKey string) (GPT, error) {
-	if model == "" {
-		return nil, errors.New("model is not set")
-	}
-	if apiKey == "" {
-		return nil, errors.New("api key is not set")
-	}
-
-	return &openAI{
-		model:  model,
-		apiKey: apiKey,
-	}, nil
-}
-
-func (g *openAI) Prompt(_ context.Context, prompt string, image []byte) (*Result, error) {
-	// build request
-	request := chatCompletionRequest{
-		Model: g.model,
-		Messages: []message{
-			{
-				Role:    "system",
-				Content: []content{{Type: "text", Text: "You are a visual reasoning assistant."}},
-			},
-			{
-				Role: "user",
-				Content: []content{
-					{Type: "text", Text: prompt},
-					{
-						Type:     "image_url",
-						ImageURL: imageURL{URL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(image)},
-					},
-				},
-			},
-		},
-		MaxTokens: 300,
-	}
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
-		return nil, err
-	}
-
-	// send request
-	req, err := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(requestBytes))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+g.apiKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	respBodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	response := chatCompletionResponse{}
-	if err = json.Unmarshal(respBodyBytes, &response); err != nil {
-		return nil, err
-	}
-
-	if len(response.Choices) == 0 {
-		return nil, errors.New("no choices in response")
-	}
-
-	content := strings.TrimPrefix(response.Choices[0].Message.Content, "```json")
-	content = strings.TrimSuffix(content, "```")
-
-	return &Result{
-		Content: content,
-		Raw:     response,
-	}, nil
-}
+package gpt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+)
+
+type chatCompletionRequest struct {
+	Model     string    `json:"model"`
+	Messages  []message `json:"messages"`
+	MaxTokens int       `json:"max_tokens"`
+}
+
+type message struct {
+	Role    string    `json:"role"`
+	Content []content `json:"content"`
+}
+
+type content struct {
+	Type     string   `json:"type"`
+	Text     string   `json:"text,omitempty"`
+	ImageURL imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type chatCompletionResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index   int `json:"index"`
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens       int `json:"prompt_tokens"`
+		CompletionTokens   int `json:"completion_tokens"`
+		TotalTokens        int `json:"total_tokens"`
+		PromptTokenDetails struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+		CompletionTokenDetails struct {
+			ReasoningTokens int `json:"reasoning_tokens"`
+		} `json:"completion_tokens_details"`
+	} `json:"usage"`
+	SystemFingerprint string `json:"system_fingerprint"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+type openAI struct {
+	model  string
+	apiKey string
+	retry  retry.Policy
+}
+
+func NewOpenAI(model, apiKey string) (GPT, error) {
+	if model == "" {
+		return nil, errors.New("model is not set")
+	}
+	if apiKey == "" {
+		return nil, errors.New("api key is not set")
+	}
+
+	return &openAI{
+		model:  model,
+		apiKey: apiKey,
+		retry:  retry.DefaultPolicy(),
+	}, nil
+}
+
+func (g *openAI) Prompt(ctx context.Context, prompt string, image []byte) (*Result, error) {
+	start := time.Now()
+
+	// build request
+	request := chatCompletionRequest{
+		Model: g.model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: []content{{Type: "text", Text: "You are a visual reasoning assistant."}},
+			},
+			{
+				Role: "user",
+				Content: []content{
+					{Type: "text", Text: prompt},
+					{
+						Type:     "image_url",
+						ImageURL: imageURL{URL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(image)},
+					},
+				},
+			},
+		},
+		MaxTokens: 300,
+	}
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// send request
+	var response *chatCompletionResponse
+	_, err = g.retry.Do(ctx, "openai", func(ctx context.Context) error {
+		var err error
+		response, err = g.send(ctx, requestBytes)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Choices) == 0 {
+		return nil, errors.New("no choices in response")
+	}
+	if response.Choices[0].FinishReason == "content_filter" {
+		return nil, &Error{
+			Err:        ErrContentBlocked,
+			Provider:   "openai",
+			StatusCode: http.StatusOK,
+			Body:       response.Choices[0].Message.Content,
+		}
+	}
+
+	content := strings.TrimPrefix(response.Choices[0].Message.Content, "```json")
+	content = strings.TrimSuffix(content, "```")
+
+	model := response.Model
+	if model == "" {
+		model = g.model
+	}
+
+	return &Result{
+		Content:  content,
+		Provider: "openai",
+		Model:    model,
+		Latency:  time.Since(start),
+		Usage: Usage{
+			PromptTokens:     response.Usage.PromptTokens,
+			CompletionTokens: response.Usage.CompletionTokens,
+			CachedTokens:     response.Usage.PromptTokenDetails.CachedTokens,
+			ReasoningTokens:  response.Usage.CompletionTokenDetails.ReasoningTokens,
+			TotalTokens:      response.Usage.TotalTokens,
+		},
+		Raw: *response,
+	}, nil
+}
+
+// Ping looks up the configured model, which needs a valid API key
+func (g *openAI) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.openai.com/v1/models/"+g.model, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return transportError("openai", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(resp.Body)
+		return newError("openai", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// send posts a chat completion request. Completions are not idempotent so are
+// only retried when OpenAI did not process the request.
+func (g *openAI) send(ctx context.Context, requestBytes []byte) (*chatCompletionResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(requestBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if retry.ShouldRetryError(err, false) {
+			return nil, retry.Retryable(transportError("openai", err), 0)
+		}
+		return nil, transportError("openai", err)
+	}
+	defer resp.Body.Close()
+	respBodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, transportError("openai", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		openAIErr := newError("openai", resp.StatusCode, string(respBodyBytes))
+		errResp := errorResponse{}
+		if json.Unmarshal(respBodyBytes, &errResp) == nil && errResp.Error.Code == "content_policy_violation" {
+			openAIErr.Err = ErrContentBlocked
+		}
+		if retry.ShouldRetryStatus(resp.StatusCode, false) && errResp.Error.Code != "insufficient_quota" {
+			return nil, retry.Retryable(openAIErr, retry.ParseRetryAfter(resp.Header.Get("Retry-After")))
+		}
+		return nil, openAIErr
+	}
+
+	response := chatCompletionResponse{}
+	if err = json.Unmarshal(respBodyBytes, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,74 @@
+package gpt
+
+import (
+	_ "embed"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+//go:embed prices.json
+var defaultPrices []byte
+
+// Price is the USD cost per million tokens
+type Price struct {
+	Prompt       float64 `json:"prompt"`
+	CachedPrompt float64 `json:"cachedPrompt"`
+	Completion   float64 `json:"completion"`
+}
+
+// PriceTable maps a model name to its price
+type PriceTable map[string]Price
+
+// LoadPriceTable returns the default price table with any models in the JSON
+// file at path added or replaced
+func LoadPriceTable(path string) (PriceTable, error) {
+	prices := PriceTable{}
+	if err := json.Unmarshal(defaultPrices, &prices); err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		return prices, nil
+	}
+
+	overrideBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	overrides := PriceTable{}
+	if err := json.Unmarshal(overrideBytes, &overrides); err != nil {
+		return nil, err
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+
+	return prices, nil
+}
+
+// Cost returns the USD cost of the usage. Versioned model names such as
+// gpt-4o-2024-08-06 fall back to the longest matching model prefix. Unknown
+// models cost nothing.
+func (p PriceTable) Cost(model string, usage Usage) float64 {
+	price, ok := p[model]
+	if !ok {
+		matched := ""
+		for name, candidate := range p {
+			if strings.HasPrefix(model, name) && len(name) > len(matched) {
+				matched = name
+				price = candidate
+			}
+		}
+	}
+
+	cachedPrice := price.CachedPrompt
+	if cachedPrice == 0 {
+		cachedPrice = price.Prompt
+	}
+
+	uncached := usage.PromptTokens - usage.CachedTokens
+	return (float64(uncached)*price.Prompt +
+		float64(usage.CachedTokens)*cachedPrice +
+		float64(usage.CompletionTokens)*price.Completion) / 1e6
+}
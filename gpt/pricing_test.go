@@ -0,0 +1,116 @@
+package gpt
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestPriceTableCost(t *testing.T) {
+	prices := PriceTable{
+		"gpt-4o":      {Prompt: 2.50, CachedPrompt: 1.25, Completion: 10},
+		"gpt-4o-mini": {Prompt: 0.15, Completion: 0.60},
+	}
+
+	tests := []struct {
+		name  string
+		model string
+		usage Usage
+		want  float64
+	}{
+		{"exact model", "gpt-4o", Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000}, 2.50 + 1},
+		{"versioned model", "gpt-4o-2024-08-06", Usage{PromptTokens: 1_000_000}, 2.50},
+		{"longest prefix", "gpt-4o-mini-2024-07-18", Usage{PromptTokens: 1_000_000}, 0.15},
+		{"cached tokens", "gpt-4o", Usage{PromptTokens: 1_000_000, CachedTokens: 400_000}, 0.6*2.50 + 0.4*1.25},
+		{"cached price falls back to prompt price", "gpt-4o-mini", Usage{PromptTokens: 1_000_000, CachedTokens: 1_000_000}, 0.15},
+		{"unknown model", "claude", Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}, 0},
+		{"no usage", "gpt-4o", Usage{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prices.Cost(tt.model, tt.usage); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPriceTable(t *testing.T) {
+	prices, err := LoadPriceTable("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prices["gpt-4o"].Prompt == 0 || prices["gemini-2.5-flash"].Completion == 0 {
+		t.Fatalf("default prices missing models: %v", prices)
+	}
+
+	path := filepath.Join(t.TempDir(), "prices.json")
+	overrides := `{"gpt-4o": {"prompt": 5, "completion": 15}, "custom-model": {"prompt": 1, "completion": 2}}`
+	if err := os.WriteFile(path, []byte(overrides), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prices, err = LoadPriceTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prices["gpt-4o"] != (Price{Prompt: 5, Completion: 15}) {
+		t.Errorf("gpt-4o = %+v, want the override", prices["gpt-4o"])
+	}
+	if prices["custom-model"].Prompt != 1 {
+		t.Errorf("custom-model = %+v, want it added", prices["custom-model"])
+	}
+	if prices["gpt-4o-mini"].Prompt == 0 {
+		t.Error("defaults missing after override")
+	}
+
+	if _, err := LoadPriceTable(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing price file")
+	}
+}
+
+func TestGeminiUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata *genai.UsageMetadata
+		want     Usage
+	}{
+		{"no metadata", nil, Usage{}},
+		{
+			"no thinking",
+			&genai.UsageMetadata{PromptTokenCount: 1000, CandidatesTokenCount: 50, TotalTokenCount: 1050},
+			Usage{PromptTokens: 1000, CompletionTokens: 50, TotalTokens: 1050},
+		},
+		{
+			"thinking tokens from the total",
+			&genai.UsageMetadata{PromptTokenCount: 1000, CandidatesTokenCount: 50, TotalTokenCount: 1350},
+			Usage{PromptTokens: 1000, CompletionTokens: 350, ReasoningTokens: 300, TotalTokens: 1350},
+		},
+		{
+			"cached prompt tokens",
+			&genai.UsageMetadata{PromptTokenCount: 1000, CachedContentTokenCount: 800, CandidatesTokenCount: 50, TotalTokenCount: 1050},
+			Usage{PromptTokens: 1000, CachedTokens: 800, CompletionTokens: 50, TotalTokens: 1050},
+		},
+		{
+			"total below the parts",
+			&genai.UsageMetadata{PromptTokenCount: 1000, CandidatesTokenCount: 50, TotalTokenCount: 900},
+			Usage{PromptTokens: 1000, CompletionTokens: 50, TotalTokens: 900},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := geminiUsage(tt.metadata); got != tt.want {
+				t.Errorf("geminiUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// reasoning tokens are billed at the completion price
+	prices := PriceTable{"gemini-2.5-flash": {Prompt: 0.30, Completion: 2.50}}
+	usage := geminiUsage(&genai.UsageMetadata{PromptTokenCount: 1_000_000, CandidatesTokenCount: 100_000, TotalTokenCount: 1_400_000})
+	if got, want := prices.Cost("gemini-2.5-flash", usage), 0.30+0.4*2.50; math.Abs(got-want) > 1e-9 {
+		t.Errorf("cost = %v, want %v", got, want)
+	}
+}
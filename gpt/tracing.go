@@ -0,0 +1,54 @@
+package gpt
+
+import (
+	"context"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/reporting"
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type traced struct {
+	gpt GPT
+}
+
+// NewTraced wraps a GPT to trace its prompts and record them as breadcrumbs of
+// error reports. Wrap the cache so cached results are traced too.
+func NewTraced(gpt GPT) GPT {
+	return &traced{gpt: gpt}
+}
+
+func (t *traced) Prompt(ctx context.Context, prompt string, image []byte) (*Result, error) {
+	ctx, span := tracing.Start(ctx, "gpt.GPT.Prompt")
+	result, err := t.gpt.Prompt(ctx, prompt, image)
+	if result != nil {
+		span.SetAttributes(
+			attribute.String("llm.provider", result.Provider),
+			attribute.String("llm.model", result.Model),
+			attribute.Bool("llm.cached", result.Cached),
+			attribute.Int("llm.tokens.prompt", result.Usage.PromptTokens),
+			attribute.Int("llm.tokens.cached", result.Usage.CachedTokens),
+			attribute.Int("llm.tokens.completion", result.Usage.CompletionTokens),
+			attribute.Int("llm.tokens.reasoning", result.Usage.ReasoningTokens),
+		)
+	}
+	tracing.End(span, err)
+
+	breadcrumb := map[string]any{}
+	if result != nil {
+		breadcrumb = map[string]any{
+			"provider":   result.Provider,
+			"model":      result.Model,
+			"cached":     result.Cached,
+			"latency_ms": result.Latency.Milliseconds(),
+		}
+		reporting.SetTag(ctx, "llm.provider", result.Provider)
+	}
+	reporting.Breadcrumb(ctx, "llm", "prompt", breadcrumb, err)
+
+	return result, err
+}
+
+func (t *traced) Ping(ctx context.Context) error {
+	return t.gpt.Ping(ctx)
+}
@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// Check returns an error when a dependency cannot be used
+type Check func(ctx context.Context) error
+
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+} // @name HealthResponse
+
+type result struct {
+	err       error
+	checkedAt time.Time
+}
+
+// Checker serves liveness and readiness probes. Readiness runs the checks
+// concurrently and caches each result for the TTL so probes do not load
+// the dependencies.
+type Checker struct {
+	ttl     time.Duration
+	timeout time.Duration
+	checks  map[string]Check
+	group   singleflight.Group
+
+	mu       sync.Mutex
+	results  map[string]result
+	draining atomic.Bool
+}
+
+// NewChecker returns a checker caching results for ttl and failing checks
+// which take longer than timeout
+func NewChecker(ttl time.Duration, timeout time.Duration) *Checker {
+	return &Checker{
+		ttl:     ttl,
+		timeout: timeout,
+		checks:  map[string]Check{},
+		results: map[string]result{},
+	}
+}
+
+// Add registers a readiness check. Checks must be added before serving.
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// Drain fails readiness from now on so the instance stops receiving traffic
+// while it shuts down
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Live reports the process is serving requests
+func (c *Checker) Live() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, Response{Status: StatusUp})
+	}
+}
+
+// Ready reports whether every dependency is usable. Failures are logged
+// rather than returned as the probe is not authenticated.
+func (c *Checker) Ready() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if c.draining.Load() {
+			ctx.JSON(http.StatusServiceUnavailable, Response{Status: StatusDraining})
+			return
+		}
+
+		response := Response{Status: StatusUp, Checks: map[string]string{}}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name := range c.checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				status := StatusUp
+				if err := c.run(ctx, name); err != nil {
+					status = StatusDown
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				response.Checks[name] = status
+				if status == StatusDown {
+					response.Status = StatusDown
+				}
+			}()
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		if response.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.JSON(status, response)
+	}
+}
+
+// run returns the cached result of a check, running it when stale
+func (c *Checker) run(ctx context.Context, name string) error {
+	c.mu.Lock()
+	cached, ok := c.results[name]
+	c.mu.Unlock()
+	if ok && time.Since(cached.checkedAt) < c.ttl {
+		return cached.err
+	}
+
+	value, _, _ := c.group.Do(name, func() (any, error) {
+		// the check outlives a probe which gives up so its result is cached
+		checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		defer cancel()
+
+		err := c.checks[name](checkCtx)
+		if err != nil {
+			slog.WarnContext(ctx, "readiness check failed", "check", name, "error", err)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.results[name] = result{err: err, checkedAt: time.Now()}
+		return err, nil
+	})
+
+	err, _ := value.(error)
+	return err
+}
@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(checker *Checker) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/healthz", checker.Live())
+	router.GET("/readyz", checker.Ready())
+	return router
+}
+
+func probe(t *testing.T, router *gin.Engine, path string) (int, Response) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	response := Response{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Code, response
+}
+
+func TestReady(t *testing.T) {
+	mongoErr := atomic.Pointer[error]{}
+	checker := NewChecker(0, time.Second)
+	checker.Add("mongo", func(context.Context) error {
+		if err := mongoErr.Load(); err != nil {
+			return *err
+		}
+		return nil
+	})
+	checker.Add("ups", func(context.Context) error { return nil })
+	router := newTestRouter(checker)
+
+	code, response := probe(t, router, "/readyz")
+	if code != http.StatusOK || response.Status != StatusUp || response.Checks["mongo"] != StatusUp || response.Checks["ups"] != StatusUp {
+		t.Errorf("ready = %d %+v, want every check up", code, response)
+	}
+
+	down := errors.New("connection refused")
+	mongoErr.Store(&down)
+	code, response = probe(t, router, "/readyz")
+	if code != http.StatusServiceUnavailable || response.Status != StatusDown || response.Checks["mongo"] != StatusDown || response.Checks["ups"] != StatusUp {
+		t.Errorf("ready with Mongo down = %d %+v, want mongo down", code, response)
+	}
+	// liveness does not depend on Mongo, so the pod is not restarted for it
+	if code, response := probe(t, router, "/healthz"); code != http.StatusOK || response.Status != StatusUp {
+		t.Errorf("live with Mongo down = %d %+v, want up", code, response)
+	}
+
+	mongoErr.Store(nil)
+	checker.Drain()
+	code, response = probe(t, router, "/readyz")
+	if code != http.StatusServiceUnavailable || response.Status != StatusDraining {
+		t.Errorf("ready after drain = %d %+v, want draining", code, response)
+	}
+	if code, response := probe(t, router, "/healthz"); code != http.StatusOK || response.Status != StatusUp {
+		t.Errorf("live after drain = %d %+v, want up", code, response)
+	}
+}
+
+func TestReadyCachesResults(t *testing.T) {
+	calls := atomic.Int32{}
+	checker := NewChecker(time.Hour, time.Second)
+	checker.Add("mongo", func(context.Context) error {
+		calls.Add(1)
+		return errors.New("connection refused")
+	})
+	router := newTestRouter(checker)
+
+	for range 3 {
+		if code, _ := probe(t, router, "/readyz"); code != http.StatusServiceUnavailable {
+			t.Errorf("ready = %d, want 503", code)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("check ran %d times, want once within the TTL", got)
+	}
+}
+
+func TestReadyTimesOutChecks(t *testing.T) {
+	checker := NewChecker(0, 10*time.Millisecond)
+	checker.Add("mongo", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, response := probe(t, newTestRouter(checker), "/readyz")
+	if code != http.StatusServiceUnavailable || response.Checks["mongo"] != StatusDown {
+		t.Errorf("ready with a hanging check = %d %+v, want mongo down", code, response)
+	}
+}
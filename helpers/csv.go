@@ -0,0 +1,16 @@
+package helpers
+
+import "strings"
+
+// formulaPrefixes start cells which spreadsheets evaluate as formulas
+const formulaPrefixes = "=+-@\t\r"
+
+// CSVCell prefixes a value a spreadsheet would evaluate as a formula with a
+// single quote, so exported cells read as text
+func CSVCell(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,24 @@
+package helpers
+
+import "testing"
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1Z999AA10123456784", "1Z999AA10123456784"},
+		{"", ""},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1", "'+1"},
+		{"-1+2", "'-1+2"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := CSVCell(tt.value); got != tt.want {
+			t.Errorf("CSVCell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package helpers
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,6 +11,7 @@ import (
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code"`
 } // @name ErrorResponse
 
 type StatusError struct {
@@ -30,11 +34,104 @@ func (e *StatusError) Code() int {
 	return e.code
 }
 
+func (e *StatusError) Unwrap() error {
+	return e.err
+}
+
+// ClientError is an error whose message can be returned to clients with its
+// HTTP status and stable code. Typed upstream and input errors implement it so
+// their response bodies are never returned.
+type ClientError interface {
+	error
+	HTTPStatus() int
+	Code() string
+}
+
+type clientError struct {
+	message string
+	status  int
+	code    string
+}
+
+// NewClientError returns a client error with the message, HTTP status and
+// stable code
+func NewClientError(message string, status int, code string) ClientError {
+	return &clientError{
+		message: message,
+		status:  status,
+		code:    code,
+	}
+}
+
+func (e *clientError) Error() string {
+	return e.message
+}
+
+func (e *clientError) HTTPStatus() int {
+	return e.status
+}
+
+func (e *clientError) Code() string {
+	return e.code
+}
+
 func HandleError(c *gin.Context, err error) {
-	statusErr, ok := err.(*StatusError)
-	if ok {
-		c.JSON(statusErr.code, ErrorResponse{Error: statusErr.Error()})
-	} else {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		slog.ErrorContext(c, "request failed", "method", c.Request.Method, "route", c.FullPath(), "error", err)
 	}
+
+	status, response := ResolveError(err)
+	// server errors are kept on the context for error reporting
+	if status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+	c.JSON(status, response)
+}
+
+// ResolveError returns the status and response returned to clients for err.
+// Only the messages of status errors and client errors are returned.
+func ResolveError(err error) (int, ErrorResponse) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code, ErrorResponse{Error: statusErr.Error(), Code: statusCode(statusErr.code)}
+	}
+
+	var clientErr ClientError
+	if errors.As(err, &clientErr) {
+		return clientErr.HTTPStatus(), ErrorResponse{Error: clientErr.Error(), Code: clientErr.Code()}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, ErrorResponse{Error: context.DeadlineExceeded.Error(), Code: "timeout"}
+	}
+
+	return http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: "internal_error"}
+}
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusRequestEntityTooLarge:
+		return "payload_too_large"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	}
+
+	if status >= http.StatusInternalServerError {
+		return "internal_error"
+	}
+
+	return "error"
 }
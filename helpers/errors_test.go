@@ -0,0 +1,105 @@
+package helpers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/prompts"
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+	"github.com/gin-gonic/gin"
+)
+
+const secretBody = `{"error": "upstream secret sk-live-123"}`
+
+func TestResolveError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"status error", helpers.NewStatusError(http.StatusConflict, errors.New("already overridden")), http.StatusConflict, "conflict", "already overridden"},
+		{"wrapped status error", fmt.Errorf("override: %w", helpers.NewStatusError(http.StatusForbidden, errors.New("supervisor role required"))), http.StatusForbidden, "forbidden", "supervisor role required"},
+		{"ups not found", &ups.Error{Err: ups.ErrNotFound, StatusCode: 404, Body: secretBody}, http.StatusNotFound, "tracking_number_not_found", "tracking number not found"},
+		{"ups invalid tracking number", &ups.Error{Err: ups.ErrInvalidTrackingNumber, StatusCode: 400, Body: secretBody}, http.StatusUnprocessableEntity, "invalid_tracking_number", "invalid tracking number"},
+		{"ups rate limited", &ups.Error{Err: ups.ErrRateLimited, StatusCode: 429, Body: secretBody}, http.StatusTooManyRequests, "carrier_rate_limited", "ups rate limit exceeded"},
+		{"ups unauthorized", &ups.Error{Err: ups.ErrUnauthorized, StatusCode: 401, Body: secretBody}, http.StatusBadGateway, "carrier_unauthorized", "ups rejected the credentials"},
+		{"ups unavailable", &ups.Error{Err: ups.ErrUnavailable, StatusCode: 503, Body: secretBody}, http.StatusBadGateway, "carrier_unavailable", "ups is unavailable"},
+		{"ups timeout before deadline", fmt.Errorf("%w: %w", ups.ErrTimeout, context.DeadlineExceeded), http.StatusGatewayTimeout, "carrier_timeout", "ups request timed out"},
+		{"llm quota", &gpt.Error{Err: gpt.ErrQuotaExceeded, Provider: "openai", StatusCode: 429, Body: secretBody}, http.StatusTooManyRequests, "llm_quota_exceeded", "llm quota exceeded"},
+		{"llm blocked", fmt.Errorf("gemini: %w", gpt.ErrContentBlocked), http.StatusUnprocessableEntity, "llm_content_blocked", "llm blocked the label image"},
+		{"llm unauthorized", &gpt.Error{Err: gpt.ErrUnauthorized, Provider: "openai", StatusCode: 401, Body: secretBody}, http.StatusBadGateway, "llm_unauthorized", "llm provider rejected the credentials"},
+		{"llm unavailable", &gpt.Error{Err: gpt.ErrUnavailable, Provider: "gemini", StatusCode: 500, Body: secretBody}, http.StatusBadGateway, "llm_unavailable", "llm provider is unavailable"},
+		{"llm timeout", fmt.Errorf("openai: %w: %w", gpt.ErrTimeout, context.DeadlineExceeded), http.StatusGatewayTimeout, "llm_timeout", "llm request timed out"},
+		{"llm invalid content", fmt.Errorf("%w: %w", gpt.ErrInvalidContent, errors.New(secretBody)), http.StatusBadGateway, "llm_invalid_response", "llm returned an invalid response"},
+		{"unknown carrier", fmt.Errorf("%w %q", prompts.ErrUnknownCarrier, "fedex"), http.StatusBadRequest, "unknown_carrier", "unknown carrier"},
+		{"deadline", fmt.Errorf("validate: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout", context.DeadlineExceeded.Error()},
+		{"unknown error", errors.New(secretBody), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := helpers.ResolveError(tt.err)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if response.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", response.Code, tt.wantCode)
+			}
+			if response.Error != tt.wantMessage {
+				t.Errorf("message = %q, want %q", response.Error, tt.wantMessage)
+			}
+			if strings.Contains(response.Error, "secret") {
+				t.Errorf("message %q leaks the upstream body", response.Error)
+			}
+		})
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantErrors int
+	}{
+		{"client error", helpers.NewStatusError(http.StatusBadRequest, errors.New("bad")), http.StatusBadRequest, 0},
+		{"upstream client error", &ups.Error{Err: ups.ErrNotFound, StatusCode: 404, Body: secretBody}, http.StatusNotFound, 0},
+		{"upstream server error", &gpt.Error{Err: gpt.ErrUnavailable, StatusCode: 500, Body: secretBody}, http.StatusBadGateway, 1},
+		{"internal error", errors.New(secretBody), http.StatusInternalServerError, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			helpers.HandleError(c, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			// server errors are kept on the context for error reporting
+			if len(c.Errors) != tt.wantErrors {
+				t.Errorf("context errors = %d, want %d", len(c.Errors), tt.wantErrors)
+			}
+			response := helpers.ErrorResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(response.Error, "secret") {
+				t.Errorf("response %q leaks the upstream body", response.Error)
+			}
+		})
+	}
+}
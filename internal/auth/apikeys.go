@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/time/rate"
+)
+
+const (
+	apiKeyPrefix      = "slv_"
+	defaultRateLimit  = 1
+	defaultBurst      = 5
+	defaultDailyQuota = 5000
+)
+
+var errInvalidAPIKey = helpers.NewStatusError(http.StatusUnauthorized, errors.New("invalid api key"))
+
+func (m *manager) CreateAPIKey(ctx context.Context, newAPIKey models.NewAPIKey) (*models.APIKey, string, error) {
+	if newAPIKey.Name == "" {
+		return nil, "", helpers.NewStatusError(http.StatusBadRequest, errors.New("name is required"))
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := &models.APIKey{
+		Name:       newAPIKey.Name,
+		Prefix:     key[:len(apiKeyPrefix)+6],
+		KeyHash:    hashAPIKey(key),
+		StationID:  newAPIKey.StationID,
+		Facility:   newAPIKey.Facility,
+		Roles:      newAPIKey.Roles,
+		RateLimit:  newAPIKey.RateLimit,
+		Burst:      newAPIKey.Burst,
+		DailyQuota: newAPIKey.DailyQuota,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if len(apiKey.Roles) == 0 {
+		apiKey.Roles = []string{models.RoleOperator}
+	}
+	if err := validateRoles(apiKey.Roles); err != nil {
+		return nil, "", err
+	}
+	if err := validateFacility(apiKey.Roles, apiKey.Facility); err != nil {
+		return nil, "", err
+	}
+	if apiKey.RateLimit <= 0 {
+		apiKey.RateLimit = defaultRateLimit
+	}
+	if apiKey.Burst <= 0 {
+		apiKey.Burst = defaultBurst
+	}
+	if apiKey.DailyQuota <= 0 {
+		apiKey.DailyQuota = defaultDailyQuota
+	}
+
+	if err := m.repository.CreateAPIKey(ctx, apiKey); err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+func (m *manager) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return m.repository.ListAPIKeys(ctx)
+}
+
+func (m *manager) RevokeAPIKey(ctx context.Context, id string) error {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return helpers.NewStatusError(http.StatusNotFound, ErrAPIKeyNotFound)
+	}
+
+	err = m.repository.RevokeAPIKey(ctx, objectID, time.Now().UTC())
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return helpers.NewStatusError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	m.limitersMu.Lock()
+	delete(m.limiters, objectID)
+	m.limitersMu.Unlock()
+
+	return nil
+}
+
+func (m *manager) VerifyAPIKey(ctx context.Context, key string) (*models.Claims, *models.APIKey, error) {
+	apiKey, err := m.repository.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, nil, errInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, nil, errInvalidAPIKey
+	}
+
+	claims := &models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:  issuer,
+			Subject: "apikey:" + apiKey.Name,
+		},
+		StationID: apiKey.StationID,
+		Facility:  apiKey.Facility,
+		Roles:     apiKey.Roles,
+	}
+
+	return claims, apiKey, nil
+}
+
+func (m *manager) Allow(ctx context.Context, apiKey *models.APIKey, n int) (*models.Allowance, error) {
+	now := time.Now().UTC()
+	allowance := &models.Allowance{
+		Limit:     apiKey.DailyQuota,
+		Remaining: -1,
+		Reset:     time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+
+	reservation := m.limiter(apiKey).ReserveN(now, n)
+	if !reservation.OK() {
+		return nil, helpers.NewStatusError(http.StatusTooManyRequests, fmt.Errorf("%d validations at once exceed the API key burst of %d", n, apiKey.Burst))
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		allowance.RetryAfter = delay
+		return allowance, nil
+	}
+
+	day := now.Format(time.DateOnly)
+	count, err := m.repository.IncrementAPIKeyUsage(ctx, apiKey.ID, day, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if count > apiKey.DailyQuota {
+		// rejected validations do not use up the quota
+		if count, err = m.repository.IncrementAPIKeyUsage(ctx, apiKey.ID, day, -n); err != nil {
+			return nil, err
+		}
+		allowance.Remaining = max(apiKey.DailyQuota-count, 0)
+		allowance.RetryAfter = allowance.Reset.Sub(now)
+		return allowance, nil
+	}
+
+	allowance.Remaining = apiKey.DailyQuota - count
+
+	allowance.Allowed = true
+	return allowance, nil
+}
+
+func (m *manager) limiter(apiKey *models.APIKey) *rate.Limiter {
+	m.limitersMu.Lock()
+	defer m.limitersMu.Unlock()
+
+	limiter, ok := m.limiters[apiKey.ID]
+	if !ok || limiter.Limit() != rate.Limit(apiKey.RateLimit) || limiter.Burst() != apiKey.Burst {
+		limiter = rate.NewLimiter(rate.Limit(apiKey.RateLimit), apiKey.Burst)
+		m.limiters[apiKey.ID] = limiter
+	}
+
+	return limiter
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/gin-gonic/gin"
+)
+
+type handler struct {
+	manager models.Manager
+}
+
+func NewHandler(manager models.Manager) *handler {
+	return &handler{manager: manager}
+}
+
+func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/token", h.token)
+}
+
+func (h *handler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	admin := router.Group("", RequireRole(models.RoleAdmin))
+	admin.POST("/api-keys", h.createAPIKey)
+	admin.GET("/api-keys", h.listAPIKeys)
+	admin.DELETE("/api-keys/:id", h.revokeAPIKey)
+}
+
+type TokenRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+} //	@name	TokenRequest
+
+type TokenResponse struct {
+	Token models.Token `json:"token"`
+} //	@name	TokenResponse
+
+// token godoc
+//
+//	@Summary		Request an API token
+//	@Description	exchange a user or station device's credentials for a bearer token
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			requestBody		body		TokenRequest	true	"Token Request"
+//	@Success		200				{object}	TokenResponse
+//	@Failure		400,401,429,500	{object}	helpers.ErrorResponse
+//	@Router			/auth/token [post]
+func (h *handler) token(c *gin.Context) {
+	request := TokenRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	token, err := h.manager.Login(c, request.Username, request.Password, c.ClientIP())
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenResponse{Token: *token})
+}
+
+type CreateAPIKeyRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	StationID  string   `json:"stationId"`
+	Facility   string   `json:"facility"`
+	Roles      []string `json:"roles"`
+	RateLimit  float64  `json:"rateLimit"`
+	Burst      int      `json:"burst"`
+	DailyQuota int      `json:"dailyQuota"`
+} //	@name	CreateAPIKeyRequest
+
+type CreateAPIKeyResponse struct {
+	APIKey models.APIKey `json:"apiKey"`
+	// Key is only returned when the key is created
+	Key string `json:"key"`
+} //	@name	CreateAPIKeyResponse
+
+type ListAPIKeysResponse struct {
+	APIKeys []models.APIKey `json:"apiKeys"`
+} //	@name	ListAPIKeysResponse
+
+// createAPIKey godoc
+//
+//	@Summary		Create an API key
+//	@Description	create an API key for a station device. The key is only returned once.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			requestBody	body		CreateAPIKeyRequest	true	"API Key"
+//	@Success		201			{object}	CreateAPIKeyResponse
+//	@Failure		400,401,403	{object}	helpers.ErrorResponse
+//	@Failure		500			{object}	helpers.ErrorResponse
+//	@Router			/admin/api-keys [post]
+func (h *handler) createAPIKey(c *gin.Context) {
+	request := CreateAPIKeyRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	apiKey, key, err := h.manager.CreateAPIKey(c, models.NewAPIKey{
+		Name:       request.Name,
+		StationID:  request.StationID,
+		Facility:   request.Facility,
+		Roles:      request.Roles,
+		RateLimit:  request.RateLimit,
+		Burst:      request.Burst,
+		DailyQuota: request.DailyQuota,
+	})
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKey: *apiKey, Key: key})
+}
+
+// listAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	list API keys including revoked keys
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200			{object}	ListAPIKeysResponse
+//	@Failure		401,403,500	{object}	helpers.ErrorResponse
+//	@Router			/admin/api-keys [get]
+func (h *handler) listAPIKeys(c *gin.Context) {
+	apiKeys, err := h.manager.ListAPIKeys(c)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ListAPIKeysResponse{APIKeys: apiKeys})
+}
+
+// revokeAPIKey godoc
+//
+//	@Summary		Revoke an API key
+//	@Description	revoke an API key so it can no longer authenticate
+//	@Tags			admin
+//	@Security		Bearer
+//	@Param			id	path	string	true	"API key ID"
+//	@Success		204
+//	@Failure		401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		500			{object}	helpers.ErrorResponse
+//	@Router			/admin/api-keys/{id} [delete]
+func (h *handler) revokeAPIKey(c *gin.Context) {
+	if err := h.manager.RevokeAPIKey(c, c.Param("id")); err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
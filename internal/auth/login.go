@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// loginAttemptInterval and loginAttemptBurst limit login attempts per
+	// username and per client IP
+	loginAttemptInterval = 12 * time.Second
+	loginAttemptBurst    = 5
+	// maxLoginLimiters bounds the limiters kept before idle ones are dropped
+	maxLoginLimiters = 10000
+)
+
+var errTooManyLoginAttempts = helpers.NewStatusError(http.StatusTooManyRequests, errors.New("too many login attempts, try again later"))
+
+// dummyPasswordHash is compared against when an account is missing or disabled
+// so the response time does not reveal which usernames exist
+const dummyPasswordHash = "$2a$10$IVjhzwF3RuOow3BRVyLtpOxYS.isJyRNZ3zHEgZAMhksaoPY2UJgi"
+
+// loginLimiter limits login attempts by key, such as a username or client IP
+type loginLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{
+		limiters: map[string]*rate.Limiter{},
+	}
+}
+
+// allow reports whether every key may attempt a login, using an attempt of
+// each only when all are allowed
+func (l *loginLimiter) allow(keys ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.limiters) > maxLoginLimiters {
+		l.prune(now)
+	}
+
+	reservations := make([]*rate.Reservation, 0, len(keys))
+	for _, key := range keys {
+		limiter, ok := l.limiters[key]
+		if !ok {
+			limiter = rate.NewLimiter(rate.Every(loginAttemptInterval), loginAttemptBurst)
+			l.limiters[key] = limiter
+		}
+
+		reservation := limiter.ReserveN(now, 1)
+		if reservation.DelayFrom(now) > 0 {
+			reservation.CancelAt(now)
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+			return false
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	return true
+}
+
+// prune drops the limiters which have refilled, as they limit nothing
+func (l *loginLimiter) prune(now time.Time) {
+	for key, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= loginAttemptBurst {
+			delete(l.limiters, key)
+		}
+	}
+}
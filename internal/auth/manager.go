@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/time/rate"
+)
+
+const issuer = "shipping-label-validator"
+
+var errInvalidCredentials = helpers.NewStatusError(http.StatusUnauthorized, errors.New("invalid username or password"))
+
+// Keys sign and verify tokens. Tokens are signed with RS256 when a private key
+// is set and HS256 otherwise. Tokens signed with either algorithm are accepted
+// when the matching key is set.
+type Keys struct {
+	HMACKey    []byte
+	PublicKey  *rsa.PublicKey
+	PrivateKey *rsa.PrivateKey
+}
+
+// LoadKeys loads the HMAC signing key and the PEM encoded RSA keys. The RSA
+// keys may be given as PEM or as a path to a PEM file and are optional.
+func LoadKeys(signingKey string, publicKey string, privateKey string) (*Keys, error) {
+	keys := &Keys{}
+	if signingKey != "" {
+		keys.HMACKey = []byte(signingKey)
+	}
+
+	if privateKey != "" {
+		pem, err := readPEM(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		if keys.PrivateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("private key: %w", err)
+		}
+		keys.PublicKey = &keys.PrivateKey.PublicKey
+	}
+
+	if publicKey != "" {
+		pem, err := readPEM(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		if keys.PublicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("public key: %w", err)
+		}
+	}
+
+	if keys.HMACKey == nil && keys.PublicKey == nil {
+		return nil, errors.New("jwt signing key is not set")
+	}
+
+	return keys, nil
+}
+
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	return os.ReadFile(value)
+}
+
+type manager struct {
+	repository models.Repository
+	keys       *Keys
+	ttl        time.Duration
+	limitersMu sync.Mutex
+	limiters   map[bson.ObjectID]*rate.Limiter
+	logins     *loginLimiter
+}
+
+func NewManager(
+	repository models.Repository,
+	keys *Keys,
+	ttl time.Duration,
+) models.Manager {
+	return &manager{
+		repository: repository,
+		keys:       keys,
+		ttl:        ttl,
+		limiters:   map[bson.ObjectID]*rate.Limiter{},
+		logins:     newLoginLimiter(),
+	}
+}
+
+func (m *manager) Login(ctx context.Context, username string, password string, clientIP string) (*models.Token, error) {
+	if !m.logins.allow("username:"+username, "ip:"+clientIP) {
+		return nil, errTooManyLoginAttempts
+	}
+
+	account, err := m.repository.GetAccount(ctx, username)
+	if err != nil && !errors.Is(err, ErrAccountNotFound) {
+		return nil, err
+	}
+	// missing and disabled accounts still pay the cost of a password check
+	passwordHash := dummyPasswordHash
+	if err == nil && !account.Disabled {
+		passwordHash = account.PasswordHash
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil || passwordHash == dummyPasswordHash {
+		return nil, errInvalidCredentials
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+	claims := models.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   account.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		StationID: account.StationID,
+		Facility:  account.Facility,
+		Roles:     account.Roles,
+	}
+
+	accessToken, err := m.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Token{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (m *manager) sign(claims models.Claims) (string, error) {
+	if m.keys.PrivateKey != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(m.keys.PrivateKey)
+	}
+	if m.keys.HMACKey != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.keys.HMACKey)
+	}
+
+	return "", errors.New("no key available to sign tokens")
+}
+
+func (m *manager) Verify(token string) (*models.Claims, error) {
+	claims := models.Claims{}
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (any, error) {
+		switch token.Method {
+		case jwt.SigningMethodHS256:
+			if m.keys.HMACKey != nil {
+				return m.keys.HMACKey, nil
+			}
+		case jwt.SigningMethodRS256:
+			if m.keys.PublicKey != nil {
+				return m.keys.PublicKey, nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func (m *manager) CreateAccount(ctx context.Context, newAccount models.NewAccount) (*models.Account, error) {
+	if newAccount.Username == "" || newAccount.Password == "" {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("username and password are required"))
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(newAccount.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.Account{
+		Username:     newAccount.Username,
+		PasswordHash: string(passwordHash),
+		StationID:    newAccount.StationID,
+		Facility:     newAccount.Facility,
+		Roles:        newAccount.Roles,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if len(account.Roles) == 0 {
+		account.Roles = []string{models.RoleOperator}
+	}
+	if err := validateRoles(account.Roles); err != nil {
+		return nil, err
+	}
+	if err := validateFacility(account.Roles, account.Facility); err != nil {
+		return nil, err
+	}
+	if err := m.repository.CreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func validateRoles(roles []string) error {
+	for _, role := range roles {
+		switch role {
+		case models.RoleOperator, models.RoleSupervisor, models.RoleAdmin:
+		default:
+			return helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("unknown role %q", role))
+		}
+	}
+
+	return nil
+}
+
+// validateFacility requires a facility unless the roles include admin, as
+// records are only scoped to a facility for non-admins
+func validateFacility(roles []string, facility string) error {
+	if facility != "" || slices.Contains(roles, models.RoleAdmin) {
+		return nil
+	}
+
+	return helpers.NewStatusError(http.StatusBadRequest, errors.New("facility is required unless the roles include admin"))
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeRepository struct {
+	mu       sync.Mutex
+	accounts map[string]*models.Account
+	apiKeys  map[string]*models.APIKey
+	usage    map[string]int
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		accounts: map[string]*models.Account{},
+		apiKeys:  map[string]*models.APIKey{},
+		usage:    map[string]int{},
+	}
+}
+
+func (r *fakeRepository) GetAccount(_ context.Context, username string) (*models.Account, error) {
+	account, ok := r.accounts[username]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+func (r *fakeRepository) CreateAccount(_ context.Context, account *models.Account) error {
+	r.accounts[account.Username] = account
+	return nil
+}
+
+func (r *fakeRepository) CreateAPIKey(_ context.Context, apiKey *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apiKeys[apiKey.KeyHash] = apiKey
+	return nil
+}
+
+func (r *fakeRepository) GetAPIKeyByHash(_ context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	apiKey, ok := r.apiKeys[keyHash]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return apiKey, nil
+}
+
+func (r *fakeRepository) ListAPIKeys(context.Context) ([]models.APIKey, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) RevokeAPIKey(context.Context, bson.ObjectID, time.Time) error {
+	return nil
+}
+
+func (r *fakeRepository) IncrementAPIKeyUsage(_ context.Context, id bson.ObjectID, day string, n int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage[id.Hex()+day] += n
+	return r.usage[id.Hex()+day], nil
+}
+
+func newTestManager(t *testing.T) (*manager, *fakeRepository) {
+	t.Helper()
+	repository := newFakeRepository()
+	m := NewManager(repository, &Keys{HMACKey: []byte("test")}, time.Hour).(*manager)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repository.accounts["operator"] = &models.Account{Username: "operator", PasswordHash: string(hash), Roles: []string{models.RoleOperator}}
+	repository.accounts["disabled"] = &models.Account{Username: "disabled", PasswordHash: string(hash), Disabled: true}
+
+	return m, repository
+}
+
+func statusOf(err error) int {
+	var statusErr *helpers.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code()
+	}
+	return 0
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		wantStatus int
+	}{
+		{"valid", "operator", "secret", 0},
+		{"wrong password", "operator", "wrong", http.StatusUnauthorized},
+		{"unknown user", "nobody", "secret", http.StatusUnauthorized},
+		{"disabled", "disabled", "secret", http.StatusUnauthorized},
+		{"dummy hash password", "nobody", "not-a-password", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t)
+			token, err := m.Login(context.Background(), tt.username, tt.password, "192.0.2.1")
+			if got := statusOf(err); got != tt.wantStatus {
+				t.Fatalf("status = %d (%v), want %d", got, err, tt.wantStatus)
+			}
+			if tt.wantStatus != 0 {
+				return
+			}
+
+			claims, err := m.Verify(token.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != tt.username {
+				t.Errorf("subject = %s, want %s", claims.Subject, tt.username)
+			}
+		})
+	}
+}
+
+func TestLoginComparesPasswordForUnknownUsers(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	// the dummy hash has the default cost so an unknown user takes as long
+	// as a known one with a default cost hash
+	start := time.Now()
+	_, _ = m.Login(context.Background(), "nobody", "secret", "192.0.2.1")
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("unknown user rejected in %s, want a bcrypt comparison", elapsed)
+	}
+}
+
+func TestLoginLimitsAttempts(t *testing.T) {
+	tests := []struct {
+		name     string
+		username func(i int) string
+		ip       func(i int) string
+	}{
+		{"by username", func(int) string { return "operator" }, func(i int) string { return "192.0.2." + string(rune('0'+i)) }},
+		{"by client ip", func(i int) string { return "user" + string(rune('0'+i)) }, func(int) string { return "192.0.2.1" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t)
+			for i := range loginAttemptBurst {
+				_, err := m.Login(context.Background(), tt.username(i), "wrong", tt.ip(i))
+				if statusOf(err) != http.StatusUnauthorized {
+					t.Fatalf("attempt %d: err = %v, want 401", i+1, err)
+				}
+			}
+
+			_, err := m.Login(context.Background(), tt.username(loginAttemptBurst), "secret", tt.ip(loginAttemptBurst))
+			if statusOf(err) != http.StatusTooManyRequests {
+				t.Errorf("err = %v, want 429", err)
+			}
+		})
+	}
+}
+
+func TestLoginLimiterOnlyUsesAttemptsWhenAllowed(t *testing.T) {
+	l := newLoginLimiter()
+	for range loginAttemptBurst {
+		if !l.allow("ip:a") {
+			t.Fatal("attempt rejected within the burst")
+		}
+	}
+
+	// the rejected ip must not use up an attempt of the username
+	if l.allow("username:b", "ip:a") {
+		t.Fatal("attempt allowed past the burst")
+	}
+	for range loginAttemptBurst {
+		if !l.allow("username:b") {
+			t.Fatal("username attempts used by a rejected login")
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		name          string
+		used          int
+		n             int
+		wantAllowed   bool
+		wantRemaining int
+		wantUsed      int
+		wantStatus    int
+	}{
+		{"single", 0, 1, true, 9, 1, 0},
+		{"batch", 2, 5, true, 3, 7, 0},
+		{"batch uses the rest of the quota", 5, 5, true, 0, 10, 0},
+		{"batch over the remaining quota", 7, 5, false, 3, 7, 0},
+		{"quota used up", 10, 1, false, 0, 10, 0},
+		{"batch over the burst", 0, 21, false, 0, 0, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, repository := newTestManager(t)
+			apiKey := &models.APIKey{ID: bson.NewObjectID(), RateLimit: 1, Burst: 20, DailyQuota: 10}
+			day := time.Now().UTC().Format(time.DateOnly)
+			repository.usage[apiKey.ID.Hex()+day] = tt.used
+
+			allowance, err := m.Allow(context.Background(), apiKey, tt.n)
+			if got := statusOf(err); got != tt.wantStatus {
+				t.Fatalf("status = %d (%v), want %d", got, err, tt.wantStatus)
+			}
+			if err == nil {
+				if allowance.Allowed != tt.wantAllowed || allowance.Remaining != tt.wantRemaining {
+					t.Errorf("allowed = %v, remaining = %d, want %v, %d", allowance.Allowed, allowance.Remaining, tt.wantAllowed, tt.wantRemaining)
+				}
+				if !allowance.Allowed && allowance.RetryAfter <= 0 {
+					t.Error("rejected without a retry after")
+				}
+			}
+			if got := repository.usage[apiKey.ID.Hex()+day]; got != tt.wantUsed {
+				t.Errorf("usage = %d, want %d", got, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestAllowRateLimitsBatches(t *testing.T) {
+	m, _ := newTestManager(t)
+	apiKey := &models.APIKey{ID: bson.NewObjectID(), RateLimit: 1, Burst: 5, DailyQuota: 100}
+
+	allowance, err := m.Allow(context.Background(), apiKey, 5)
+	if err != nil || !allowance.Allowed {
+		t.Fatalf("allowance = %+v, err = %v, want allowed", allowance, err)
+	}
+
+	// the batch used every token of the burst
+	allowance, err = m.Allow(context.Background(), apiKey, 1)
+	if err != nil || allowance.Allowed {
+		t.Fatalf("allowance = %+v, err = %v, want rate limited", allowance, err)
+	}
+	if allowance.Remaining != -1 {
+		t.Errorf("remaining = %d, want -1 when rate limited", allowance.Remaining)
+	}
+}
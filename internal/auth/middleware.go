@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/gin-gonic/gin"
+)
+
+type claimsKey struct{}
+
+// ErrLimitExceeded is returned when an API key is over its rate limit or daily quota
+var ErrLimitExceeded = errors.New("api key rate limit or daily quota exceeded")
+
+// errNoFacility rejects non-admin credentials without a facility, which would
+// otherwise be unscoped
+var errNoFacility = helpers.NewStatusError(http.StatusForbidden, errors.New("credentials are not assigned to a facility"))
+
+// noFacility is the scope of requests without a facility. No record has it so
+// they match nothing.
+const noFacility = "\x00"
+
+const (
+	// claimsGinKey is the gin context key so claims are found when a *gin.Context is used as the context
+	claimsGinKey = "auth.claims"
+	apiKeyGinKey = "auth.apiKey"
+)
+
+// Middleware rejects requests without a valid bearer token or API key and adds
+// the claims to the request context. API keys are sent in the X-API-Key header
+// or as an ApiKey authorization.
+func Middleware(manager models.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if key := c.GetHeader("X-API-Key"); key != "" || strings.EqualFold(scheme, "ApiKey") {
+			if key == "" {
+				key = token
+			}
+
+			claims, apiKey, err := manager.VerifyAPIKey(c, key)
+			if err != nil {
+				helpers.HandleError(c, err)
+				c.Abort()
+				return
+			}
+
+			if !hasFacility(claims) {
+				helpers.HandleError(c, errNoFacility)
+				c.Abort()
+				return
+			}
+
+			c.Set(apiKeyGinKey, apiKey)
+			SetClaims(c, claims)
+			c.Next()
+			return
+		}
+
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusUnauthorized, errors.New("missing bearer token")))
+			c.Abort()
+			return
+		}
+
+		claims, err := manager.Verify(token)
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusUnauthorized, errors.New("invalid bearer token")))
+			c.Abort()
+			return
+		}
+		if !hasFacility(claims) {
+			helpers.HandleError(c, errNoFacility)
+			c.Abort()
+			return
+		}
+
+		SetClaims(c, claims)
+		c.Next()
+	}
+}
+
+// QueryToken accepts the bearer token as the access_token query parameter for
+// clients which cannot set headers, such as a browser's EventSource. It must
+// run before Middleware and should only be used on streaming routes so tokens
+// are not otherwise written to access logs.
+func QueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// SetClaims adds claims to the gin and request contexts
+func SetClaims(c *gin.Context, claims *models.Claims) {
+	c.Set(claimsGinKey, claims)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), claimsKey{}, claims))
+}
+
+// ClaimsFromContext returns the claims of the authenticated request
+func ClaimsFromContext(ctx context.Context) (*models.Claims, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		claims, ok := c.Get(claimsGinKey)
+		if !ok {
+			return nil, false
+		}
+		return claims.(*models.Claims), true
+	}
+
+	claims, ok := ctx.Value(claimsKey{}).(*models.Claims)
+	return claims, ok
+}
+
+// APIKeyFromContext returns the API key the request authenticated with
+func APIKeyFromContext(c *gin.Context) (*models.APIKey, bool) {
+	value, ok := c.Get(apiKeyGinKey)
+	if !ok {
+		return nil, false
+	}
+	return value.(*models.APIKey), true
+}
+
+// Limit applies the rate limit and daily quota of the request's API key to
+// one validation. Requests authenticated with a bearer token are not limited.
+func Limit(manager models.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !AllowN(c, manager, 1) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AllowN applies the rate limit and daily quota of the request's API key to n
+// validations. When they are not allowed it responds with the error, aborts the
+// request and returns false. Requests authenticated with a bearer token are not
+// limited.
+func AllowN(c *gin.Context, manager models.Manager, n int) bool {
+	apiKey, ok := APIKeyFromContext(c)
+	if !ok {
+		return true
+	}
+
+	allowance, err := manager.Allow(c, apiKey, n)
+	if err != nil {
+		helpers.HandleError(c, err)
+		c.Abort()
+		return false
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(allowance.Limit))
+	if allowance.Remaining >= 0 {
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(allowance.Remaining))
+	}
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(allowance.Reset.Unix(), 10))
+	if !allowance.Allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(allowance.RetryAfter.Seconds()))))
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusTooManyRequests, ErrLimitExceeded))
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+// RequireRole rejects requests whose claims do not include role or a role above it
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok || !claims.HasRole(role) {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusForbidden, fmt.Errorf("%s role required", role)))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// FacilityScope returns the facility the request is limited to. Only admins
+// are not limited to a facility and get an empty string. Requests without
+// claims or a facility get a scope which matches no records.
+func FacilityScope(ctx context.Context) string {
+	claims, ok := ClaimsFromContext(ctx)
+	if ok && claims.HasRole(models.RoleAdmin) {
+		return ""
+	}
+	if !ok || claims.Facility == "" {
+		return noFacility
+	}
+
+	return claims.Facility
+}
+
+// hasFacility reports whether the claims are scoped to a facility or are an
+// admin's, which need none
+func hasFacility(claims *models.Claims) bool {
+	return claims.Facility != "" || claims.HasRole(models.RoleAdmin)
+}
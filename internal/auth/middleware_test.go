@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newRBACRouter serves a route per role which responds with the facility scope
+func newRBACRouter(m models.Manager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	scope := func(c *gin.Context) {
+		c.String(http.StatusOK, FacilityScope(c))
+	}
+
+	authenticated := router.Group("", Middleware(m))
+	authenticated.GET("/operator", RequireRole(models.RoleOperator), scope)
+	authenticated.GET("/supervisor", RequireRole(models.RoleSupervisor), scope)
+	authenticated.GET("/admin", RequireRole(models.RoleAdmin), scope)
+
+	return router
+}
+
+func TestRoleAccess(t *testing.T) {
+	m, _ := newTestManager(t)
+	router := newRBACRouter(m)
+
+	token := func(facility string, roles ...string) string {
+		t.Helper()
+		signed, err := m.sign(models.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{Issuer: issuer, Subject: "user", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			Facility:         facility,
+			Roles:            roles,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + signed
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		path          string
+		wantStatus    int
+		wantScope     string
+	}{
+		{"no token", "", "/operator", http.StatusUnauthorized, ""},
+		{"invalid token", "Bearer invalid", "/operator", http.StatusUnauthorized, ""},
+		{"operator", token("ATL1", models.RoleOperator), "/operator", http.StatusOK, "ATL1"},
+		{"operator on supervisor route", token("ATL1", models.RoleOperator), "/supervisor", http.StatusForbidden, ""},
+		{"operator on admin route", token("ATL1", models.RoleOperator), "/admin", http.StatusForbidden, ""},
+		{"supervisor on operator route", token("ATL1", models.RoleSupervisor), "/operator", http.StatusOK, "ATL1"},
+		{"supervisor", token("ATL1", models.RoleSupervisor), "/supervisor", http.StatusOK, "ATL1"},
+		{"supervisor on admin route", token("ATL1", models.RoleSupervisor), "/admin", http.StatusForbidden, ""},
+		{"admin on operator route", token("", models.RoleAdmin), "/operator", http.StatusOK, ""},
+		{"admin with facility is unscoped", token("ATL1", models.RoleAdmin), "/supervisor", http.StatusOK, ""},
+		{"admin", token("", models.RoleAdmin), "/admin", http.StatusOK, ""},
+		{"unknown role", token("ATL1", "superuser"), "/operator", http.StatusForbidden, ""},
+		{"no roles", token("ATL1"), "/operator", http.StatusForbidden, ""},
+		{"operator without facility", token("", models.RoleOperator), "/operator", http.StatusForbidden, ""},
+		{"supervisor without facility", token("", models.RoleSupervisor), "/supervisor", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.wantScope {
+				t.Errorf("scope = %q, want %q", w.Body.String(), tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRoleAccess(t *testing.T) {
+	m, repository := newTestManager(t)
+	router := newRBACRouter(m)
+
+	_, operatorKey, err := m.CreateAPIKey(context.Background(), models.NewAPIKey{Name: "dock-1", Facility: "ATL1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, adminKey, err := m.CreateAPIKey(context.Background(), models.NewAPIKey{Name: "audit", Roles: []string{models.RoleAdmin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// keys created before facilities were required
+	legacyKey := apiKeyPrefix + "legacy"
+	repository.apiKeys[hashAPIKey(legacyKey)] = &models.APIKey{Name: "legacy", Roles: []string{models.RoleSupervisor}}
+
+	tests := []struct {
+		name       string
+		key        string
+		path       string
+		wantStatus int
+		wantScope  string
+	}{
+		{"operator key", operatorKey, "/operator", http.StatusOK, "ATL1"},
+		{"operator key on supervisor route", operatorKey, "/supervisor", http.StatusForbidden, ""},
+		{"admin key", adminKey, "/admin", http.StatusOK, ""},
+		{"key without facility", legacyKey, "/operator", http.StatusForbidden, ""},
+		{"unknown key", apiKeyPrefix + "unknown", "/operator", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-API-Key", tt.key)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.wantScope {
+				t.Errorf("scope = %q, want %q", w.Body.String(), tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestFacilityScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *models.Claims
+		want   string
+	}{
+		{"no claims", nil, noFacility},
+		{"admin", &models.Claims{Roles: []string{models.RoleAdmin}}, ""},
+		{"admin with facility", &models.Claims{Facility: "ATL1", Roles: []string{models.RoleOperator, models.RoleAdmin}}, ""},
+		{"supervisor", &models.Claims{Facility: "ATL1", Roles: []string{models.RoleSupervisor}}, "ATL1"},
+		{"operator", &models.Claims{Facility: "SLC1", Roles: []string{models.RoleOperator}}, "SLC1"},
+		{"operator without facility", &models.Claims{Roles: []string{models.RoleOperator}}, noFacility},
+		{"no roles without facility", &models.Claims{}, noFacility},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = context.WithValue(ctx, claimsKey{}, tt.claims)
+			}
+			if got := FacilityScope(ctx); got != tt.want {
+				t.Errorf("FacilityScope() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateRequiresFacility(t *testing.T) {
+	tests := []struct {
+		name       string
+		facility   string
+		roles      []string
+		wantStatus int
+	}{
+		{"operator", "ATL1", nil, 0},
+		{"default role without facility", "", nil, http.StatusBadRequest},
+		{"supervisor without facility", "", []string{models.RoleSupervisor}, http.StatusBadRequest},
+		{"admin without facility", "", []string{models.RoleAdmin}, 0},
+		{"unknown role", "ATL1", []string{"superuser"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t)
+
+			_, err := m.CreateAccount(context.Background(), models.NewAccount{
+				Username: "user",
+				Password: "secret",
+				Facility: tt.facility,
+				Roles:    tt.roles,
+			})
+			if got := statusOf(err); got != tt.wantStatus {
+				t.Errorf("account status = %d (%v), want %d", got, err, tt.wantStatus)
+			}
+
+			_, _, err = m.CreateAPIKey(context.Background(), models.NewAPIKey{
+				Name:     "key",
+				Facility: tt.facility,
+				Roles:    tt.roles,
+			})
+			if got := statusOf(err); got != tt.wantStatus {
+				t.Errorf("api key status = %d (%v), want %d", got, err, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Roles are hierarchical, each role is allowed everything the roles below it are
+const (
+	RoleOperator   = "operator"
+	RoleSupervisor = "supervisor"
+	RoleAdmin      = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleOperator:   1,
+	RoleSupervisor: 2,
+	RoleAdmin:      3,
+}
+
+type Manager interface {
+	// Login limits attempts per username and client IP
+	Login(ctx context.Context, username string, password string, clientIP string) (*Token, error)
+	Verify(token string) (*Claims, error)
+	CreateAccount(ctx context.Context, account NewAccount) (*Account, error)
+	CreateAPIKey(ctx context.Context, apiKey NewAPIKey) (*APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	VerifyAPIKey(ctx context.Context, key string) (*Claims, *APIKey, error)
+	// Allow applies the API key's rate limit and daily validation quota to n validations
+	Allow(ctx context.Context, apiKey *APIKey, n int) (*Allowance, error)
+}
+
+type Repository interface {
+	GetAccount(ctx context.Context, username string) (*Account, error)
+	CreateAccount(ctx context.Context, account *Account) error
+	CreateAPIKey(ctx context.Context, apiKey *APIKey) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id bson.ObjectID, revokedAt time.Time) error
+	// IncrementAPIKeyUsage adds n to the API key's count for the day and returns the new count
+	IncrementAPIKeyUsage(ctx context.Context, id bson.ObjectID, day string, n int) (int, error)
+}
+
+// Account is a user or station device allowed to request tokens
+type Account struct {
+	ID           bson.ObjectID `bson:"_id,omitempty"`
+	Username     string        `bson:"username"`
+	PasswordHash string        `bson:"passwordHash"`
+	StationID    string        `bson:"stationId"`
+	Facility     string        `bson:"facility"`
+	Roles        []string      `bson:"roles"`
+	Disabled     bool          `bson:"disabled"`
+	CreatedAt    time.Time     `bson:"createdAt"`
+}
+
+type NewAccount struct {
+	Username  string
+	Password  string
+	StationID string
+	Facility  string
+	Roles     []string
+}
+
+// Claims are the claims of an API token. The subject is the account username.
+type Claims struct {
+	jwt.RegisteredClaims
+	StationID string   `json:"station,omitempty"`
+	Facility  string   `json:"facility,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// HasRole reports whether the claims include role or a role above it
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if roleRanks[r] >= roleRanks[role] && roleRanks[r] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Token struct {
+	AccessToken string    `json:"accessToken"`
+	TokenType   string    `json:"tokenType"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+} // @name Token
+
+// APIKey authenticates a headless station device. Only the SHA-256 hash of the
+// key is stored.
+type APIKey struct {
+	ID         bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name       string        `json:"name" bson:"name"`
+	Prefix     string        `json:"prefix" bson:"prefix"`
+	KeyHash    string        `json:"-" bson:"keyHash"`
+	StationID  string        `json:"stationId" bson:"stationId"`
+	Facility   string        `json:"facility" bson:"facility"`
+	Roles      []string      `json:"roles" bson:"roles"`
+	RateLimit  float64       `json:"rateLimit" bson:"rateLimit"`
+	Burst      int           `json:"burst" bson:"burst"`
+	DailyQuota int           `json:"dailyQuota" bson:"dailyQuota"`
+	CreatedAt  time.Time     `json:"createdAt" bson:"createdAt"`
+	RevokedAt  *time.Time    `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+} // @name APIKey
+
+type NewAPIKey struct {
+	Name       string
+	StationID  string
+	Facility   string
+	Roles      []string
+	RateLimit  float64
+	Burst      int
+	DailyQuota int
+}
+
+// Allowance is the result of applying an API key's limits to a request.
+// Remaining is -1 when the request was rate limited before the quota was checked.
+type Allowance struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+}
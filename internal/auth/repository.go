@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	accountsCollectionName    = "accounts"
+	apiKeysCollectionName     = "api_keys"
+	apiKeyUsageCollectionName = "api_key_usage"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+)
+
+type repository struct {
+	accounts    *mongo.Collection
+	apiKeys     *mongo.Collection
+	apiKeyUsage *mongo.Collection
+}
+
+func NewRepository(ctx context.Context, db *mongo.Database) (models.Repository, error) {
+	r := &repository{
+		accounts:    db.Collection(accountsCollectionName),
+		apiKeys:     db.Collection(apiKeysCollectionName),
+		apiKeyUsage: db.Collection(apiKeyUsageCollectionName),
+	}
+
+	_, err := r.accounts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.apiKeys.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "keyHash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.apiKeyUsage.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "keyId", Value: 1}, {Key: "day", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *repository) GetAccount(ctx context.Context, username string) (*models.Account, error) {
+	account := models.Account{}
+	err := r.accounts.FindOne(ctx, bson.M{"username": username}).Decode(&account)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (r *repository) CreateAccount(ctx context.Context, account *models.Account) error {
+	result, err := r.accounts.InsertOne(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		account.ID = id
+	}
+
+	return nil
+}
+
+func (r *repository) CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
+	result, err := r.apiKeys.InsertOne(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		apiKey.ID = id
+	}
+
+	return nil
+}
+
+func (r *repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	apiKey := models.APIKey{}
+	err := r.apiKeys.FindOne(ctx, bson.M{"keyHash": keyHash}).Decode(&apiKey)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *repository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	cursor, err := r.apiKeys.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := []models.APIKey{}
+	if err := cursor.All(ctx, &apiKeys); err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (r *repository) RevokeAPIKey(ctx context.Context, id bson.ObjectID, revokedAt time.Time) error {
+	result, err := r.apiKeys.UpdateOne(ctx,
+		bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": revokedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) IncrementAPIKeyUsage(ctx context.Context, id bson.ObjectID, day string, n int) (int, error) {
+	usage := struct {
+		Count int `bson:"count"`
+	}{}
+	err := r.apiKeyUsage.FindOneAndUpdate(ctx,
+		bson.M{"keyId": id, "day": day},
+		bson.M{"$inc": bson.M{"count": n}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&usage)
+	if err != nil {
+		return 0, err
+	}
+
+	return usage.Count, nil
+}
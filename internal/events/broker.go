@@ -0,0 +1,82 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/events/models"
+)
+
+const subscriberBufferSize = 64
+
+type subscriber struct {
+	filter models.Filter
+	events chan models.Event
+}
+
+// Broker fans published events out to the subscribers whose filter matches.
+// Events are dropped for subscribers that are not keeping up rather than
+// blocking the publisher.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: map[*subscriber]struct{}{},
+	}
+}
+
+func (b *Broker) Publish(event models.Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for s := range b.subscribers {
+		if !s.filter.Matches(event) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			slog.Warn("dropped event for a slow subscriber", "type", event.Type, "station_id", s.filter.StationID)
+		}
+	}
+}
+
+// Subscribe returns the events matching filter until unsubscribe is called.
+// The events channel is closed when the broker closes.
+func (b *Broker) Subscribe(filter models.Filter) (events <-chan models.Event, unsubscribe func()) {
+	s := &subscriber{
+		filter: filter,
+		events: make(chan models.Event, subscriberBufferSize),
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		close(s.events)
+	} else {
+		b.subscribers[s] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return s.events, func() {
+		b.mu.Lock()
+		delete(b.subscribers, s)
+		b.mu.Unlock()
+	}
+}
+
+// Close ends every subscription so long-lived streams finish on shutdown
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscribers {
+		close(s.events)
+		delete(b.subscribers, s)
+	}
+}
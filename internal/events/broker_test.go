@@ -0,0 +1,91 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/events/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	b := NewBroker()
+	station, unsubscribeStation := b.Subscribe(models.Filter{StationID: "station-1"})
+	defer unsubscribeStation()
+	other, unsubscribeOther := b.Subscribe(models.Filter{StationID: "station-2"})
+	defer unsubscribeOther()
+
+	b.Publish(models.Event{Type: models.EventTypeVerdict, StationID: "station-1"})
+
+	select {
+	case event := <-station:
+		if event.StationID != "station-1" {
+			t.Errorf("station = %s, want station-1", event.StationID)
+		}
+	default:
+		t.Error("subscriber did not receive its station's event")
+	}
+	select {
+	case event := <-other:
+		t.Errorf("subscriber received another station's event %+v", event)
+	default:
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+	b := NewBroker()
+	events, unsubscribe := b.Subscribe(models.Filter{})
+
+	b.Close()
+	if _, ok := <-events; ok {
+		t.Error("subscription is open after close")
+	}
+
+	// late subscribers, publishers and unsubscribers must not block or panic
+	late, unsubscribeLate := b.Subscribe(models.Filter{})
+	if _, ok := <-late; ok {
+		t.Error("subscription after close is open")
+	}
+	b.Publish(models.Event{Type: models.EventTypeVerdict})
+	unsubscribe()
+	unsubscribeLate()
+}
+
+func TestSubscribeEndsWhenBrokerCloses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	b := NewBroker()
+	router := gin.New()
+	// the route is served without its role check
+	router.GET("/events", NewHandler(b).subscribe)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		res, err := http.Get(server.URL + "/events?stationId=station-1")
+		if err == nil {
+			_, err = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		done <- err
+	}()
+
+	for subscribed := false; !subscribed; time.Sleep(time.Millisecond) {
+		b.mu.RLock()
+		subscribed = len(b.subscribers) == 1
+		b.mu.RUnlock()
+	}
+	b.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream is open after the broker closed")
+	}
+}
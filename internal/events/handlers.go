@@ -0,0 +1,81 @@
+package events
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/events/models"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval keeps idle streams open through proxies
+const heartbeatInterval = 15 * time.Second
+
+type handler struct {
+	broker *Broker
+}
+
+func NewHandler(broker *Broker) *handler {
+	return &handler{broker: broker}
+}
+
+func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
+	operator := router.Group("", auth.RequireRole(authmodels.RoleOperator))
+	operator.GET("", h.subscribe)
+}
+
+// subscribe godoc
+//
+//	@Summary		Subscribe to validation events
+//	@Description	stream validation lifecycle events (received, extracting, looking_up, verdict, failed) as server-sent events. Operators subscribe to a station; supervisors may omit stationId to receive every station of their facility. Browsers may pass the bearer token as the access_token query parameter.
+//	@Tags			events
+//	@Produce		text/event-stream
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			stationId		query		string	false	"Station ID"
+//	@Param			access_token	query		string	false	"Bearer token, for clients which cannot set headers"
+//	@Success		200				{object}	models.Event
+//	@Failure		400,401,403		{object}	helpers.ErrorResponse
+//	@Router			/events [get]
+func (h *handler) subscribe(c *gin.Context) {
+	filter := models.Filter{
+		StationID: c.Query("stationId"),
+		Facility:  auth.FacilityScope(c),
+	}
+	claims, ok := auth.ClaimsFromContext(c)
+	if ok && claims.StationID != "" {
+		filter.StationID = claims.StationID
+	}
+	if filter.StationID == "" && !(ok && claims.HasRole(authmodels.RoleSupervisor)) {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("stationId is required")))
+		return
+	}
+
+	events, unsubscribe := h.broker.Subscribe(filter)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+		case <-heartbeat.C:
+			io.WriteString(w, ": heartbeat\n\n")
+		}
+		return true
+	})
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+)
+
+type EventType string // @name EventType
+
+// Validation lifecycle events in the order they are published
+const (
+	EventTypeReceived   EventType = "received"
+	EventTypeExtracting EventType = "extracting"
+	EventTypeLookingUp  EventType = "looking_up"
+	EventTypeVerdict    EventType = "verdict"
+	EventTypeFailed     EventType = "failed"
+)
+
+type Event struct {
+	Type           EventType                        `json:"type"`
+	ValidationID   string                           `json:"validationId"`
+	StationID      string                           `json:"stationId"`
+	Facility       string                           `json:"facility"`
+	TrackingNumber string                           `json:"trackingNumber,omitempty"`
+	Time           time.Time                        `json:"time"`
+	Result         *shippingmodels.ValidationResult `json:"result,omitempty"`
+	Error          *helpers.ErrorResponse           `json:"error,omitempty"`
+} // @name Event
+
+// Filter selects the events a subscriber receives. Empty fields match every
+// event.
+type Filter struct {
+	StationID string
+	Facility  string
+}
+
+func (f Filter) Matches(event Event) bool {
+	return (f.StationID == "" || f.StationID == event.StationID) &&
+		(f.Facility == "" || f.Facility == event.Facility)
+}
+
+type Publisher interface {
+	Publish(event Event)
+}
@@ -0,0 +1,255 @@
+package manifests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/manifests/models"
+	"github.com/gin-gonic/gin"
+)
+
+const maxManifestBytes = 10 << 20
+
+type handler struct {
+	manager models.Manager
+}
+
+func NewHandler(manager models.Manager) *handler {
+	return &handler{manager: manager}
+}
+
+func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
+	operator := router.Group("", auth.RequireRole(authmodels.RoleOperator))
+	operator.GET("", h.listManifests)
+	operator.GET("/:id", h.getManifest)
+	operator.GET("/:id/reconciliation", h.reconcile)
+
+	supervisor := router.Group("", auth.RequireRole(authmodels.RoleSupervisor))
+	supervisor.POST("", h.createManifest)
+	supervisor.POST("/:id/close", h.closeManifest)
+}
+
+type ManifestUploadRequest struct {
+	TrailerID string `form:"trailerId"`
+	StationID string `form:"stationId"`
+}
+
+type ManifestsRequest struct {
+	StationID string `form:"stationId"`
+	TrailerID string `form:"trailerId"`
+	Status    string `form:"status"`
+	Limit     int    `form:"limit"`
+}
+
+type ManifestsResponse struct {
+	Manifests []models.Manifest `json:"manifests"`
+} // @name ManifestsResponse
+
+// createManifest godoc
+//
+//	@Summary		Upload a manifest
+//	@Description	upload the CSV of tracking numbers expected on a trailer, as a multipart file or a text/csv body. The CSV has a tracking number column found by its header, or a single column without a header. The manifest becomes the open manifest of the station and scans at the station are reconciled against it.
+//	@Tags			manifests
+//	@Accept			mpfd
+//	@Accept			text/csv
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			trailerId		query		string	true	"Trailer ID"
+//	@Param			stationId		query		string	true	"Station loading the trailer"
+//	@Param			file			formData	file	false	"Manifest CSV"
+//	@Success		201				{object}	models.Manifest
+//	@Failure		400,401,403,413	{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/manifests [post]
+func (h *handler) createManifest(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxManifestBytes)
+
+	request := ManifestUploadRequest{}
+	if err := c.ShouldBind(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	data, err := readManifest(c)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	input := models.NewManifest{
+		TrailerID: request.TrailerID,
+		StationID: request.StationID,
+		Facility:  auth.FacilityScope(c),
+		CSV:       data,
+	}
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		input.Facility = claims.Facility
+		input.Username = claims.Subject
+		if claims.StationID != "" {
+			input.StationID = claims.StationID
+		}
+	}
+
+	manifest, err := h.manager.CreateManifest(c, input)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, manifest)
+}
+
+func readManifest(c *gin.Context) ([]byte, error) {
+	if c.ContentType() != gin.MIMEMultipartPOSTForm {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, helpers.NewStatusError(http.StatusRequestEntityTooLarge, fmt.Errorf("manifest is larger than %d MB", maxManifestBytes>>20))
+		}
+		return data, nil
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("file is required"))
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// listManifests godoc
+//
+//	@Summary		List manifests
+//	@Description	list manifests, newest first, without their tracking numbers
+//	@Tags			manifests
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			stationId	query		string	false	"Station ID"
+//	@Param			trailerId	query		string	false	"Trailer ID"
+//	@Param			status		query		string	false	"Manifest status"	Enums(OPEN, CLOSED)
+//	@Param			limit		query		int		false	"Maximum manifests to return (default 100, max 1000)"
+//	@Success		200			{object}	ManifestsResponse
+//	@Failure		400,401,403	{object}	helpers.ErrorResponse
+//	@Failure		500			{object}	helpers.ErrorResponse
+//	@Router			/manifests [get]
+func (h *handler) listManifests(c *gin.Context) {
+	request := ManifestsRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	manifests, err := h.manager.ListManifests(c, models.Filter{
+		Facility:  auth.FacilityScope(c),
+		StationID: request.StationID,
+		TrailerID: request.TrailerID,
+		Status:    models.Status(strings.ToUpper(request.Status)),
+		Limit:     request.Limit,
+	})
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ManifestsResponse{Manifests: manifests})
+}
+
+// getManifest godoc
+//
+//	@Summary		Get a manifest
+//	@Description	get a manifest with its tracking numbers
+//	@Tags			manifests
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			id				path		string	true	"Manifest ID"
+//	@Success		200				{object}	models.Manifest
+//	@Failure		400,401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/manifests/{id} [get]
+func (h *handler) getManifest(c *gin.Context) {
+	manifest, err := h.manager.GetManifest(c, c.Param("id"), auth.FacilityScope(c))
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// closeManifest godoc
+//
+//	@Summary		Close a manifest
+//	@Description	close a manifest once its trailer is loaded so further scans at the station are not associated with it
+//	@Tags			manifests
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id					path		string	true	"Manifest ID"
+//	@Success		200					{object}	models.Manifest
+//	@Failure		400,401,403,404,409	{object}	helpers.ErrorResponse
+//	@Failure		500					{object}	helpers.ErrorResponse
+//	@Router			/manifests/{id}/close [post]
+func (h *handler) closeManifest(c *gin.Context) {
+	manifest, err := h.manager.CloseManifest(c, c.Param("id"), auth.FacilityScope(c))
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// reconcile godoc
+//
+//	@Summary		Reconcile a manifest
+//	@Description	compare the packages scanned against a manifest with the packages expected on it, reporting valid, invalid, missing, extra and duplicate-scanned packages
+//	@Tags			manifests
+//	@Produce		json
+//	@Produce		text/csv
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			id				path		string	true	"Manifest ID"
+//	@Param			format			query		string	false	"Report format"	Enums(json, csv)	default(json)
+//	@Success		200				{object}	models.Reconciliation
+//	@Failure		400,401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/manifests/{id}/reconciliation [get]
+func (h *handler) reconcile(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("format must be json or csv")))
+		return
+	}
+
+	reconciliation, err := h.manager.Reconcile(c, c.Param("id"), auth.FacilityScope(c))
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, reconciliation)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	// trailer IDs are entered by users, so the filename is quoted as needed
+	filename := fmt.Sprintf("manifest-%s-%s.csv", reconciliation.Manifest.TrailerID, reconciliation.Manifest.ID)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Status(http.StatusOK)
+	if err := WriteReconciliationCSV(c.Writer, reconciliation); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,302 @@
+package manifests
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/manifests/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const maxManifestPackages = 20000
+
+var errManifestNotFound = helpers.NewStatusError(http.StatusNotFound, ErrManifestNotFound)
+
+type manager struct {
+	repository  models.Repository
+	validations shippingmodels.Repository
+}
+
+func NewManager(repository models.Repository, validations shippingmodels.Repository) models.Manager {
+	return &manager{
+		repository:  repository,
+		validations: validations,
+	}
+}
+
+// CreateManifest stores an uploaded manifest as the open manifest of its
+// station, closing the manifest the station was loading before
+func (m *manager) CreateManifest(ctx context.Context, input models.NewManifest) (*models.Manifest, error) {
+	if input.TrailerID == "" || input.StationID == "" {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("trailerId and stationId are required"))
+	}
+
+	trackingNumbers, err := parseManifest(input.CSV)
+	if err != nil {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, err)
+	}
+
+	now := time.Now().UTC()
+	if err := m.repository.CloseManifests(ctx, input.Facility, input.StationID, now); err != nil {
+		return nil, err
+	}
+
+	manifest := &models.Manifest{
+		TrailerID:       input.TrailerID,
+		StationID:       input.StationID,
+		Facility:        input.Facility,
+		CreatedBy:       input.Username,
+		CreatedAt:       now,
+		Status:          models.StatusOpen,
+		TrackingNumbers: trackingNumbers,
+	}
+	if err := m.repository.CreateManifest(ctx, manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// parseManifest reads the tracking numbers of a manifest CSV, dropping blanks
+// and repeats
+func parseManifest(data []byte) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	column := 0
+	seen := map[string]bool{}
+	trackingNumbers := []string{}
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("manifest: %w", err)
+		}
+
+		if row == 0 {
+			if header := trackingColumn(record); header >= 0 {
+				column = header
+				continue
+			}
+			if len(record) > 1 {
+				return nil, errors.New("manifest: a tracking number column header is required when there are several columns")
+			}
+		}
+		if column >= len(record) {
+			continue
+		}
+
+		trackingNumber := ups.NormalizeTrackingNumber(record[column])
+		if trackingNumber == "" || seen[trackingNumber] {
+			continue
+		}
+		seen[trackingNumber] = true
+		trackingNumbers = append(trackingNumbers, trackingNumber)
+	}
+
+	if len(trackingNumbers) == 0 {
+		return nil, errors.New("manifest has no tracking numbers")
+	}
+	if len(trackingNumbers) > maxManifestPackages {
+		return nil, fmt.Errorf("manifest has %d packages, the limit is %d", len(trackingNumbers), maxManifestPackages)
+	}
+
+	return trackingNumbers, nil
+}
+
+// trackingColumn returns the index of the tracking number column of a header
+// row, or -1 if the row is not a header
+func trackingColumn(record []string) int {
+	for i, cell := range record {
+		name := strings.NewReplacer(" ", "", "_", "", "-", "", "#", "").Replace(strings.ToLower(cell))
+		switch name {
+		case "tracking", "trackingnumber", "trackingno", "trackingid":
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *manager) GetManifest(ctx context.Context, id string, facility string) (*models.Manifest, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("invalid manifest id"))
+	}
+
+	manifest, err := m.repository.GetManifest(ctx, objectID)
+	if errors.Is(err, ErrManifestNotFound) {
+		return nil, errManifestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if facility != "" && manifest.Facility != facility {
+		return nil, errManifestNotFound
+	}
+
+	return manifest, nil
+}
+
+func (m *manager) ListManifests(ctx context.Context, filter models.Filter) ([]models.Manifest, error) {
+	return m.repository.ListManifests(ctx, filter)
+}
+
+func (m *manager) CloseManifest(ctx context.Context, id string, facility string) (*models.Manifest, error) {
+	manifest, err := m.GetManifest(ctx, id, facility)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Status == models.StatusClosed {
+		return nil, helpers.NewStatusError(http.StatusConflict, errors.New("manifest is already closed"))
+	}
+
+	manifest, err = m.repository.CloseManifest(ctx, manifest.ID, time.Now().UTC())
+	if errors.Is(err, ErrManifestNotFound) {
+		return nil, helpers.NewStatusError(http.StatusConflict, errors.New("manifest is already closed"))
+	}
+
+	return manifest, err
+}
+
+func (m *manager) ActiveManifestID(ctx context.Context, facility string, stationID string) (string, error) {
+	if stationID == "" {
+		return "", nil
+	}
+
+	manifest, err := m.repository.ActiveManifest(ctx, facility, stationID)
+	if err != nil || manifest == nil {
+		return "", err
+	}
+
+	return manifest.ID.Hex(), nil
+}
+
+// Reconcile compares the packages scanned against a manifest with the
+// packages expected on it. A package's status follows its latest scan.
+func (m *manager) Reconcile(ctx context.Context, id string, facility string) (*models.Reconciliation, error) {
+	manifest, err := m.GetManifest(ctx, id, facility)
+	if err != nil {
+		return nil, err
+	}
+
+	validations, err := m.validations.ManifestValidations(ctx, manifest.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	packages := map[string]*models.ReconciledPackage{}
+	order := []string{}
+	for _, trackingNumber := range manifest.TrackingNumbers {
+		packages[trackingNumber] = &models.ReconciledPackage{
+			TrackingNumber: trackingNumber,
+			Expected:       true,
+			Status:         models.PackageStatusMissing,
+		}
+		order = append(order, trackingNumber)
+	}
+
+	// validations are oldest first so the last one seen is the latest scan
+	for _, validation := range validations {
+		trackingNumber := ups.NormalizeTrackingNumber(validation.TrackingNumber)
+		pkg, ok := packages[trackingNumber]
+		if !ok {
+			pkg = &models.ReconciledPackage{TrackingNumber: trackingNumber}
+			packages[trackingNumber] = pkg
+			order = append(order, trackingNumber)
+		}
+
+		scannedAt := validation.CreatedAt
+		pkg.Scans++
+		pkg.Duplicate = pkg.Scans > 1
+		pkg.ValidationID = validation.ID.Hex()
+		pkg.LastScannedAt = &scannedAt
+		pkg.Overridden = validation.Override != nil
+		switch {
+		case !pkg.Expected:
+			pkg.Status = models.PackageStatusExtra
+		case validation.Valid:
+			pkg.Status = models.PackageStatusValid
+		default:
+			pkg.Status = models.PackageStatusInvalid
+		}
+	}
+
+	reconciliation := &models.Reconciliation{
+		Manifest: models.ManifestHeader{
+			ID:        manifest.ID.Hex(),
+			TrailerID: manifest.TrailerID,
+			StationID: manifest.StationID,
+			Status:    manifest.Status,
+			CreatedAt: manifest.CreatedAt,
+			ClosedAt:  manifest.ClosedAt,
+		},
+		Summary: models.ReconciliationSummary{
+			Expected: len(manifest.TrackingNumbers),
+		},
+		Packages: make([]models.ReconciledPackage, 0, len(order)),
+	}
+	for _, trackingNumber := range order {
+		pkg := packages[trackingNumber]
+		reconciliation.Packages = append(reconciliation.Packages, *pkg)
+
+		if pkg.Scans > 0 {
+			reconciliation.Summary.Scanned++
+		}
+		if pkg.Duplicate {
+			reconciliation.Summary.Duplicate++
+		}
+		switch pkg.Status {
+		case models.PackageStatusValid:
+			reconciliation.Summary.Valid++
+		case models.PackageStatusInvalid:
+			reconciliation.Summary.Invalid++
+		case models.PackageStatusMissing:
+			reconciliation.Summary.Missing++
+		case models.PackageStatusExtra:
+			reconciliation.Summary.Extra++
+		}
+	}
+
+	return reconciliation, nil
+}
+
+// WriteReconciliationCSV writes a row per package of a reconciliation.
+// Tracking numbers read from labels are escaped so they are not evaluated as
+// formulas.
+func WriteReconciliationCSV(w io.Writer, reconciliation *models.Reconciliation) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"tracking_number", "expected", "status", "scans", "duplicate", "overridden", "last_scanned_at", "validation_id"})
+	for _, pkg := range reconciliation.Packages {
+		lastScannedAt := ""
+		if pkg.LastScannedAt != nil {
+			lastScannedAt = pkg.LastScannedAt.Format(time.RFC3339)
+		}
+		writer.Write([]string{
+			helpers.CSVCell(pkg.TrackingNumber),
+			fmt.Sprint(pkg.Expected),
+			string(pkg.Status),
+			fmt.Sprint(pkg.Scans),
+			fmt.Sprint(pkg.Duplicate),
+			fmt.Sprint(pkg.Overridden),
+			lastScannedAt,
+			pkg.ValidationID,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,274 @@
+package manifests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/manifests/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []string
+		wantErr bool
+	}{
+		{"single column", "1Z999AA10123456784\n1Z999AA10123456785\n", []string{"1Z999AA10123456784", "1Z999AA10123456785"}, false},
+		{"header", "Tracking Number\n1Z999AA10123456784\n", []string{"1Z999AA10123456784"}, false},
+		{"header column", "order,tracking_no,weight\nA1,1Z999AA10123456784,2\nA2,1Z999AA10123456785,3\n", []string{"1Z999AA10123456784", "1Z999AA10123456785"}, false},
+		{"normalizes", " 1z 999 aa1 01 2345 6784\n", []string{"1Z999AA10123456784"}, false},
+		{"drops blanks and repeats", "tracking\n1Z999AA10123456784\n\n,\n1z999aa10123456784\n", []string{"1Z999AA10123456784"}, false},
+		{"short rows", "order,tracking,weight\nA1\nA2,1Z999AA10123456784\n", []string{"1Z999AA10123456784"}, false},
+		{"several columns without a header", "A1,1Z999AA10123456784\n", nil, true},
+		{"no tracking numbers", "tracking\n", nil, true},
+		{"empty", "", nil, true},
+		{"invalid csv", "tracking\n\"1Z999AA10123456784\n", nil, true},
+		{"too many packages", manifestOf(maxManifestPackages + 1), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseManifest([]byte(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tracking numbers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// manifestOf returns a single column manifest of n distinct tracking numbers
+func manifestOf(n int) string {
+	b := strings.Builder{}
+	for i := range n {
+		fmt.Fprintf(&b, "1Z%016d\n", i)
+	}
+	return b.String()
+}
+
+type fakeRepository struct {
+	models.Repository
+	manifests map[bson.ObjectID]*models.Manifest
+}
+
+func (r *fakeRepository) GetManifest(_ context.Context, id bson.ObjectID) (*models.Manifest, error) {
+	manifest, ok := r.manifests[id]
+	if !ok {
+		return nil, ErrManifestNotFound
+	}
+	return manifest, nil
+}
+
+func (r *fakeRepository) CreateManifest(_ context.Context, manifest *models.Manifest) error {
+	manifest.ID = bson.NewObjectID()
+	r.manifests[manifest.ID] = manifest
+	return nil
+}
+
+func (r *fakeRepository) ActiveManifest(_ context.Context, facility string, stationID string) (*models.Manifest, error) {
+	var active *models.Manifest
+	for _, manifest := range r.manifests {
+		if manifest.Facility == facility && manifest.StationID == stationID && manifest.Status == models.StatusOpen &&
+			(active == nil || manifest.CreatedAt.After(active.CreatedAt)) {
+			active = manifest
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeRepository) CloseManifests(_ context.Context, facility string, stationID string, closedAt time.Time) error {
+	for _, manifest := range r.manifests {
+		if manifest.Facility == facility && manifest.StationID == stationID && manifest.Status == models.StatusOpen {
+			manifest.Status = models.StatusClosed
+			manifest.ClosedAt = &closedAt
+		}
+	}
+	return nil
+}
+
+type fakeValidations struct {
+	shippingmodels.Repository
+	validations []shippingmodels.Validation
+}
+
+func (r *fakeValidations) ManifestValidations(context.Context, string) ([]shippingmodels.Validation, error) {
+	return r.validations, nil
+}
+
+func TestReconcile(t *testing.T) {
+	manifest := &models.Manifest{
+		ID:              bson.NewObjectID(),
+		Facility:        "ATL1",
+		Status:          models.StatusOpen,
+		TrackingNumbers: []string{"1ZVALID", "1ZINVALID", "1ZMISSING", "1ZRESCANNED"},
+	}
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	scan := func(minute int, trackingNumber string, valid bool) shippingmodels.Validation {
+		return shippingmodels.Validation{ID: bson.NewObjectID(), CreatedAt: start.Add(time.Duration(minute) * time.Minute), TrackingNumber: trackingNumber, Valid: valid}
+	}
+	overridden := scan(4, "1zrescanned", true)
+	overridden.Override = &shippingmodels.Override{}
+	validations := []shippingmodels.Validation{
+		scan(0, "1ZVALID", true),
+		scan(1, "1ZINVALID", false),
+		scan(2, "1ZRESCANNED", false),
+		scan(3, "1ZEXTRA", true),
+		overridden,
+	}
+
+	m := NewManager(
+		&fakeRepository{manifests: map[bson.ObjectID]*models.Manifest{manifest.ID: manifest}},
+		&fakeValidations{validations: validations},
+	)
+	reconciliation, err := m.Reconcile(context.Background(), manifest.ID.Hex(), "ATL1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSummary := models.ReconciliationSummary{Expected: 4, Scanned: 4, Valid: 2, Invalid: 1, Missing: 1, Extra: 1, Duplicate: 1}
+	if reconciliation.Summary != wantSummary {
+		t.Errorf("summary = %+v, want %+v", reconciliation.Summary, wantSummary)
+	}
+
+	tests := []struct {
+		trackingNumber string
+		expected       bool
+		status         models.PackageStatus
+		scans          int
+		overridden     bool
+	}{
+		{"1ZVALID", true, models.PackageStatusValid, 1, false},
+		{"1ZINVALID", true, models.PackageStatusInvalid, 1, false},
+		{"1ZMISSING", true, models.PackageStatusMissing, 0, false},
+		{"1ZRESCANNED", true, models.PackageStatusValid, 2, true},
+		{"1ZEXTRA", false, models.PackageStatusExtra, 1, false},
+	}
+	if len(reconciliation.Packages) != len(tests) {
+		t.Fatalf("packages = %d, want %d", len(reconciliation.Packages), len(tests))
+	}
+	for i, tt := range tests {
+		pkg := reconciliation.Packages[i]
+		if pkg.TrackingNumber != tt.trackingNumber || pkg.Expected != tt.expected || pkg.Status != tt.status || pkg.Scans != tt.scans || pkg.Overridden != tt.overridden {
+			t.Errorf("packages[%d] = %+v, want %+v", i, pkg, tt)
+		}
+		if pkg.Duplicate != (tt.scans > 1) {
+			t.Errorf("packages[%d] duplicate = %v, want %v", i, pkg.Duplicate, tt.scans > 1)
+		}
+	}
+	if rescanned := reconciliation.Packages[3]; rescanned.ValidationID != overridden.ID.Hex() || !rescanned.LastScannedAt.Equal(overridden.CreatedAt) {
+		t.Errorf("rescanned package follows validation %s, want the latest scan %s", rescanned.ValidationID, overridden.ID.Hex())
+	}
+}
+
+func TestReconcileChecksFacility(t *testing.T) {
+	manifest := &models.Manifest{ID: bson.NewObjectID(), Facility: "ATL1", TrackingNumbers: []string{"1ZVALID"}}
+	m := NewManager(
+		&fakeRepository{manifests: map[bson.ObjectID]*models.Manifest{manifest.ID: manifest}},
+		&fakeValidations{},
+	)
+
+	tests := []struct {
+		name       string
+		id         string
+		facility   string
+		wantStatus int
+	}{
+		{"same facility", manifest.ID.Hex(), "ATL1", 0},
+		{"all facilities", manifest.ID.Hex(), "", 0},
+		{"other facility", manifest.ID.Hex(), "DFW2", http.StatusNotFound},
+		{"unknown manifest", bson.NewObjectID().Hex(), "", http.StatusNotFound},
+		{"invalid id", "manifest", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Reconcile(context.Background(), tt.id, tt.facility)
+			status := 0
+			if err != nil {
+				status, _ = helpers.ResolveError(err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d (%v), want %d", status, err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestActiveManifestIsScopedToFacility(t *testing.T) {
+	ctx := context.Background()
+	repository := &fakeRepository{manifests: map[bson.ObjectID]*models.Manifest{}}
+	m := NewManager(repository, &fakeValidations{})
+
+	// both facilities have a dock-1
+	create := func(facility string, trailerID string) *models.Manifest {
+		t.Helper()
+		manifest, err := m.CreateManifest(ctx, models.NewManifest{TrailerID: trailerID, StationID: "dock-1", Facility: facility, CSV: []byte("1Z999AA10123456784\n")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return manifest
+	}
+	atlanta := create("ATL1", "trailer-1")
+	saltLake := create("SLC1", "trailer-2")
+
+	if atlanta.Status != models.StatusOpen {
+		t.Errorf("uploading another facility's manifest closed %s", atlanta.TrailerID)
+	}
+	for facility, want := range map[string]*models.Manifest{"ATL1": atlanta, "SLC1": saltLake} {
+		id, err := m.ActiveManifestID(ctx, facility, "dock-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != want.ID.Hex() {
+			t.Errorf("%s active manifest = %s, want %s", facility, id, want.ID.Hex())
+		}
+	}
+
+	// a new upload replaces only its own facility's manifest
+	replacement := create("ATL1", "trailer-3")
+	if atlanta.Status != models.StatusClosed || saltLake.Status != models.StatusOpen {
+		t.Errorf("statuses = %s and %s, want only the Atlanta manifest closed", atlanta.Status, saltLake.Status)
+	}
+	if id, _ := m.ActiveManifestID(ctx, "ATL1", "dock-1"); id != replacement.ID.Hex() {
+		t.Errorf("ATL1 active manifest = %s, want the replacement %s", id, replacement.ID.Hex())
+	}
+}
+
+func TestOpenManifestsQuery(t *testing.T) {
+	query := openManifestsQuery("ATL1", "dock-1")
+	if query["facility"] != "ATL1" || query["stationId"] != "dock-1" || query["status"] != models.StatusOpen {
+		t.Errorf("query = %v, want the open manifests of ATL1 dock-1", query)
+	}
+}
+
+func TestWriteReconciliationCSVEscapesFormulas(t *testing.T) {
+	b := new(bytes.Buffer)
+	err := WriteReconciliationCSV(b, &models.Reconciliation{Packages: []models.ReconciledPackage{
+		{TrackingNumber: "=HYPERLINK(\"http://example.com\")", Status: models.PackageStatusExtra},
+		{TrackingNumber: "1Z999AA10123456784", Status: models.PackageStatusMissing, Expected: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("lines = %q, want a header and two packages", lines)
+	}
+	if !strings.HasPrefix(lines[1], `"'=HYPERLINK(""http://example.com"")",`) {
+		t.Errorf("formula row = %q, want the tracking number escaped", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "1Z999AA10123456784,true,MISSING,") {
+		t.Errorf("package row = %q", lines[2])
+	}
+}
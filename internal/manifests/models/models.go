@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Manager interface {
+	CreateManifest(ctx context.Context, input NewManifest) (*Manifest, error)
+	GetManifest(ctx context.Context, id string, facility string) (*Manifest, error)
+	ListManifests(ctx context.Context, filter Filter) ([]Manifest, error)
+	CloseManifest(ctx context.Context, id string, facility string) (*Manifest, error)
+	Reconcile(ctx context.Context, id string, facility string) (*Reconciliation, error)
+	ActiveManifestID(ctx context.Context, facility string, stationID string) (string, error)
+}
+
+type Repository interface {
+	CreateManifest(ctx context.Context, manifest *Manifest) error
+	GetManifest(ctx context.Context, id bson.ObjectID) (*Manifest, error)
+	ListManifests(ctx context.Context, filter Filter) ([]Manifest, error)
+	// ActiveManifest returns the open manifest of a facility's station, or nil
+	// if there is none
+	ActiveManifest(ctx context.Context, facility string, stationID string) (*Manifest, error)
+	// CloseManifests closes the open manifests of a facility's station
+	CloseManifests(ctx context.Context, facility string, stationID string, closedAt time.Time) error
+	CloseManifest(ctx context.Context, id bson.ObjectID, closedAt time.Time) (*Manifest, error)
+}
+
+type Status string // @name ManifestStatus
+
+const (
+	StatusOpen   Status = "OPEN"
+	StatusClosed Status = "CLOSED"
+)
+
+// Manifest is the list of packages expected on an outbound trailer. Scans at
+// the station are associated with its open manifest.
+type Manifest struct {
+	ID              bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	TrailerID       string        `json:"trailerId" bson:"trailerId"`
+	StationID       string        `json:"stationId" bson:"stationId"`
+	Facility        string        `json:"facility" bson:"facility"`
+	CreatedBy       string        `json:"createdBy" bson:"createdBy"`
+	CreatedAt       time.Time     `json:"createdAt" bson:"createdAt"`
+	ClosedAt        *time.Time    `json:"closedAt,omitempty" bson:"closedAt,omitempty"`
+	Status          Status        `json:"status" bson:"status"`
+	TrackingNumbers []string      `json:"trackingNumbers" bson:"trackingNumbers"`
+} // @name Manifest
+
+// NewManifest is an uploaded manifest. CSV holds a tracking number column,
+// found by its header, or a single column of tracking numbers.
+type NewManifest struct {
+	TrailerID string
+	StationID string
+	Facility  string
+	Username  string
+	CSV       []byte
+}
+
+type Filter struct {
+	Facility  string
+	StationID string
+	TrailerID string
+	Status    Status
+	Limit     int
+}
+
+type PackageStatus string // @name ManifestPackageStatus
+
+const (
+	// PackageStatusValid packages were scanned and their latest scan is valid
+	PackageStatusValid PackageStatus = "VALID"
+	// PackageStatusInvalid packages were scanned and their latest scan is invalid
+	PackageStatusInvalid PackageStatus = "INVALID"
+	// PackageStatusMissing packages are on the manifest but were never scanned
+	PackageStatusMissing PackageStatus = "MISSING"
+	// PackageStatusExtra packages were scanned but are not on the manifest
+	PackageStatusExtra PackageStatus = "EXTRA"
+)
+
+type ReconciledPackage struct {
+	TrackingNumber string        `json:"trackingNumber"`
+	Expected       bool          `json:"expected"`
+	Status         PackageStatus `json:"status"`
+	Scans          int           `json:"scans"`
+	Duplicate      bool          `json:"duplicate"`
+	Overridden     bool          `json:"overridden"`
+	ValidationID   string        `json:"validationId,omitempty"`
+	LastScannedAt  *time.Time    `json:"lastScannedAt,omitempty"`
+} // @name ReconciledPackage
+
+type ReconciliationSummary struct {
+	Expected  int `json:"expected"`
+	Scanned   int `json:"scanned"`
+	Valid     int `json:"valid"`
+	Invalid   int `json:"invalid"`
+	Missing   int `json:"missing"`
+	Extra     int `json:"extra"`
+	Duplicate int `json:"duplicate"`
+} // @name ReconciliationSummary
+
+type Reconciliation struct {
+	Manifest ManifestHeader        `json:"manifest"`
+	Summary  ReconciliationSummary `json:"summary"`
+	Packages []ReconciledPackage   `json:"packages"`
+} // @name Reconciliation
+
+// ManifestHeader is a manifest without its tracking numbers
+type ManifestHeader struct {
+	ID        string     `json:"id"`
+	TrailerID string     `json:"trailerId"`
+	StationID string     `json:"stationId"`
+	Status    Status     `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+} // @name ManifestHeader
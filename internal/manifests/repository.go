@@ -0,0 +1,153 @@
+package manifests
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/manifests/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	collectionName   = "manifests"
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var ErrManifestNotFound = errors.New("manifest not found")
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository(ctx context.Context, db *mongo.Database) (models.Repository, error) {
+	collection := db.Collection(collectionName)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "facility", Value: 1}, {Key: "stationId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "facility", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: collection,
+	}, nil
+}
+
+func (r *repository) CreateManifest(ctx context.Context, manifest *models.Manifest) error {
+	result, err := r.collection.InsertOne(ctx, manifest)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		manifest.ID = id
+	}
+
+	return nil
+}
+
+func (r *repository) GetManifest(ctx context.Context, id bson.ObjectID) (*models.Manifest, error) {
+	manifest := models.Manifest{}
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&manifest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrManifestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+func (r *repository) ListManifests(ctx context.Context, filter models.Filter) ([]models.Manifest, error) {
+	query := bson.M{}
+	if filter.Facility != "" {
+		query["facility"] = filter.Facility
+	}
+	if filter.StationID != "" {
+		query["stationId"] = filter.StationID
+	}
+	if filter.TrailerID != "" {
+		query["trailerId"] = filter.TrailerID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	// listings leave out the tracking numbers, which can run to thousands
+	cursor, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"trackingNumbers": 0}))
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []models.Manifest{}
+	if err := cursor.All(ctx, &manifests); err != nil {
+		return nil, err
+	}
+
+	return manifests, nil
+}
+
+// openManifestsQuery selects the open manifests of a station. Station IDs are
+// only unique within a facility.
+func openManifestsQuery(facility string, stationID string) bson.M {
+	return bson.M{"facility": facility, "stationId": stationID, "status": models.StatusOpen}
+}
+
+func (r *repository) ActiveManifest(ctx context.Context, facility string, stationID string) (*models.Manifest, error) {
+	manifest := models.Manifest{}
+	err := r.collection.FindOne(ctx,
+		openManifestsQuery(facility, stationID),
+		options.FindOne().
+			SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+			SetProjection(bson.M{"trackingNumbers": 0}),
+	).Decode(&manifest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+func (r *repository) CloseManifests(ctx context.Context, facility string, stationID string, closedAt time.Time) error {
+	_, err := r.collection.UpdateMany(ctx,
+		openManifestsQuery(facility, stationID),
+		bson.M{"$set": bson.M{"status": models.StatusClosed, "closedAt": closedAt}},
+	)
+
+	return err
+}
+
+func (r *repository) CloseManifest(ctx context.Context, id bson.ObjectID, closedAt time.Time) (*models.Manifest, error) {
+	manifest := models.Manifest{}
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": models.StatusOpen},
+		bson.M{"$set": bson.M{"status": models.StatusClosed, "closedAt": closedAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&manifest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrManifestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
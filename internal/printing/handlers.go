@@ -0,0 +1,151 @@
+package printing
+
+import (
+	"net/http"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/printing/models"
+	"github.com/gin-gonic/gin"
+)
+
+type handler struct {
+	manager models.Manager
+}
+
+func NewHandler(manager models.Manager) *handler {
+	return &handler{manager: manager}
+}
+
+func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
+	operator := router.Group("", auth.RequireRole(authmodels.RoleOperator))
+	operator.POST("", h.createJob)
+	operator.GET("", h.listJobs)
+	operator.GET("/:id", h.getJob)
+}
+
+type PrintJobRequest struct {
+	ValidationID string `json:"validationId" binding:"required"`
+	StationID    string `json:"stationId"`
+	Printer      string `json:"printer"`
+} // @name PrintJobRequest
+
+type PrintJobsRequest struct {
+	StationID string `form:"stationId"`
+	Status    string `form:"status"`
+	Limit     int    `form:"limit"`
+}
+
+type PrintJobsResponse struct {
+	Jobs []models.Job `json:"jobs"`
+} // @name PrintJobsResponse
+
+// createJob godoc
+//
+//	@Summary		Print a label
+//	@Description	print the corrected label of a validation on the station's printer. The job is sent in the background; poll the job for its status.
+//	@Tags			printing
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			requestBody		body		PrintJobRequest	true	"Print Job Request"
+//	@Success		202				{object}	models.Job
+//	@Failure		400,401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		422,429			{object}	helpers.ErrorResponse
+//	@Failure		500,502,503,504	{object}	helpers.ErrorResponse
+//	@Router			/print-jobs [post]
+func (h *handler) createJob(c *gin.Context) {
+	request := PrintJobRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	input := models.NewJob{
+		ValidationID: request.ValidationID,
+		StationID:    request.StationID,
+		Printer:      request.Printer,
+		Facility:     auth.FacilityScope(c),
+	}
+	// the station of a station device's token takes precedence over the request
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		input.Username = claims.Subject
+		if claims.StationID != "" {
+			input.StationID = claims.StationID
+		}
+	}
+
+	job, err := h.manager.CreateJob(c, input)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// listJobs godoc
+//
+//	@Summary		List print jobs
+//	@Description	list print jobs, newest first
+//	@Tags			printing
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			stationId	query		string	false	"Station ID"
+//	@Param			status		query		string	false	"Job status"	Enums(QUEUED, PRINTING, COMPLETED, FAILED)
+//	@Param			limit		query		int		false	"Maximum jobs to return (default 100, max 1000)"
+//	@Success		200			{object}	PrintJobsResponse
+//	@Failure		400,401,403	{object}	helpers.ErrorResponse
+//	@Failure		500			{object}	helpers.ErrorResponse
+//	@Router			/print-jobs [get]
+func (h *handler) listJobs(c *gin.Context) {
+	request := PrintJobsRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	filter := models.JobFilter{
+		Facility:  auth.FacilityScope(c),
+		StationID: request.StationID,
+		Status:    models.JobStatus(request.Status),
+		Limit:     request.Limit,
+	}
+	if claims, ok := auth.ClaimsFromContext(c); ok && claims.StationID != "" {
+		filter.StationID = claims.StationID
+	}
+
+	jobs, err := h.manager.ListJobs(c, filter)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PrintJobsResponse{Jobs: jobs})
+}
+
+// getJob godoc
+//
+//	@Summary		Get a print job
+//	@Description	get the status of a print job
+//	@Tags			printing
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			id				path		string	true	"Print Job ID"
+//	@Success		200				{object}	models.Job
+//	@Failure		400,401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/print-jobs/{id} [get]
+func (h *handler) getJob(c *gin.Context) {
+	job, err := h.manager.GetJob(c, c.Param("id"), auth.FacilityScope(c))
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
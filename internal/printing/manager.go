@@ -0,0 +1,227 @@
+package printing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/printing/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/label"
+	"github.com/JoshuaPackardHR/shipping-label-validator/printer"
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// dispatchTimeout bounds a print job including its retries
+const dispatchTimeout = 2 * time.Minute
+
+var (
+	errJobNotFound = helpers.NewStatusError(http.StatusNotFound, ErrJobNotFound)
+	errShutdown    = helpers.NewStatusError(http.StatusServiceUnavailable, errors.New("server is shutting down, try again shortly"))
+)
+
+type manager struct {
+	repository models.Repository
+	shipping   shippingmodels.Manager
+	config     *printer.Config
+	printers   map[string]printer.Printer
+	retry      retry.Policy
+	// mu guards starting dispatches against shutdown
+	mu     sync.RWMutex
+	closed bool
+	// dispatching counts the print jobs being sent
+	dispatching sync.WaitGroup
+}
+
+// NewManager returns a manager sending print jobs in the background. Jobs left
+// unfinished by a previous run are failed as their documents are lost.
+func NewManager(
+	ctx context.Context,
+	repository models.Repository,
+	shipping shippingmodels.Manager,
+	config *printer.Config,
+) (models.Manager, error) {
+	interrupted, err := repository.FailUnfinishedJobs(ctx, "print job was interrupted by a restart, the label may not have printed")
+	if err != nil {
+		return nil, err
+	}
+	if interrupted > 0 {
+		slog.Warn("failed print jobs interrupted by a restart", "jobs", interrupted)
+	}
+
+	printers := map[string]printer.Printer{}
+	for _, printerConfig := range config.Printers {
+		p, err := printer.New(printerConfig)
+		if err != nil {
+			return nil, fmt.Errorf("printer %s: %w", printerConfig.Name, err)
+		}
+		printers[printerConfig.Name] = p
+	}
+
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = 5
+	policy.BaseDelay = time.Second
+	policy.MaxDelay = 15 * time.Second
+
+	return &manager{
+		repository: repository,
+		shipping:   shipping,
+		config:     config,
+		printers:   printers,
+		retry:      policy,
+	}, nil
+}
+
+// CreateJob renders the reprint label for a validation and queues it for the
+// printer of the station. The label is sent in the background.
+func (m *manager) CreateJob(ctx context.Context, input models.NewJob) (*models.Job, error) {
+	printerName := input.Printer
+	if printerName == "" {
+		printerName = m.config.Stations[input.StationID]
+	}
+	if printerName == "" {
+		return nil, helpers.NewStatusError(http.StatusUnprocessableEntity, fmt.Errorf("no printer is configured for station %q", input.StationID))
+	}
+	// printers of other facilities are hidden rather than forbidden
+	printerConfig, ok := m.config.Printer(printerName)
+	if !ok || (input.Facility != "" && printerConfig.Facility != input.Facility) {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("unknown printer %q", printerName))
+	}
+
+	reprint, err := m.shipping.ReprintLabel(ctx, input.ValidationID, input.Facility)
+	if err != nil {
+		return nil, err
+	}
+
+	document := printer.Document{Name: reprint.TrackingNumber}
+	switch printerConfig.Format {
+	case printer.FormatPDF:
+		document.ContentType = "application/pdf"
+		document.Data, err = label.PDF(*reprint)
+	default:
+		document.ContentType = "application/octet-stream"
+		document.Data, err = label.ZPL(*reprint)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	job := &models.Job{
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		StationID:      input.StationID,
+		Facility:       input.Facility,
+		Username:       input.Username,
+		ValidationID:   input.ValidationID,
+		TrackingNumber: reprint.TrackingNumber,
+		Printer:        printerConfig.Name,
+		Format:         printerConfig.Format,
+		Status:         models.JobStatusQueued,
+	}
+	if err := m.repository.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if !m.startDispatch() {
+		if err := m.repository.UpdateJobStatus(ctx, job.ID, models.JobStatusFailed, 0, errShutdown.Error()); err != nil {
+			slog.ErrorContext(ctx, "failed to update print job", "print_job_id", job.ID.Hex(), "error", err)
+		}
+		return nil, errShutdown
+	}
+	go func() {
+		defer m.dispatching.Done()
+		m.dispatch(job.ID, m.printers[printerConfig.Name], document)
+	}()
+
+	return job, nil
+}
+
+// startDispatch counts a dispatch about to start unless shutting down
+func (m *manager) startDispatch() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return false
+	}
+	m.dispatching.Add(1)
+	return true
+}
+
+func (m *manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.dispatching.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("print jobs did not finish: %w", ctx.Err())
+	}
+}
+
+// dispatch sends a document to the printer, retrying while the printer cannot
+// be reached, and records the outcome on the job
+func (m *manager) dispatch(id bson.ObjectID, p printer.Printer, document printer.Document) {
+	ctx, cancel := context.WithTimeout(context.Background(), dispatchTimeout)
+	defer cancel()
+
+	if err := m.repository.UpdateJobStatus(ctx, id, models.JobStatusPrinting, 0, ""); err != nil {
+		slog.Error("failed to update print job", "print_job_id", id.Hex(), "error", err)
+	}
+
+	attempts, err := m.retry.Do(ctx, "print job", func(ctx context.Context) error {
+		return p.Print(ctx, document)
+	})
+
+	status, jobErr := models.JobStatusCompleted, ""
+	if err != nil {
+		slog.Warn("print job failed", "print_job_id", id.Hex(), "attempts", attempts, "error", err)
+		status, jobErr = models.JobStatusFailed, err.Error()
+	}
+
+	// the dispatch context may have expired while retrying
+	updateCtx, updateCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer updateCancel()
+	if err := m.repository.UpdateJobStatus(updateCtx, id, status, attempts, jobErr); err != nil {
+		slog.Error("failed to update print job", "print_job_id", id.Hex(), "error", err)
+	}
+}
+
+func (m *manager) GetJob(ctx context.Context, id string, facility string) (*models.Job, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("invalid print job id"))
+	}
+
+	job, err := m.repository.GetJob(ctx, objectID)
+	if errors.Is(err, ErrJobNotFound) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if facility != "" && job.Facility != facility {
+		return nil, errJobNotFound
+	}
+
+	return job, nil
+}
+
+func (m *manager) ListJobs(ctx context.Context, filter models.JobFilter) ([]models.Job, error) {
+	return m.repository.ListJobs(ctx, filter)
+}
@@ -0,0 +1,319 @@
+package printing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/printing/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/label"
+	"github.com/JoshuaPackardHR/shipping-label-validator/printer"
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type memRepository struct {
+	mu   sync.Mutex
+	jobs map[bson.ObjectID]models.Job
+}
+
+func newMemRepository(jobs ...models.Job) *memRepository {
+	r := &memRepository{jobs: map[bson.ObjectID]models.Job{}}
+	for _, job := range jobs {
+		r.jobs[job.ID] = job
+	}
+	return r
+}
+
+func (r *memRepository) CreateJob(_ context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = bson.NewObjectID()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memRepository) GetJob(_ context.Context, id bson.ObjectID) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return &job, nil
+}
+
+func (r *memRepository) ListJobs(context.Context, models.JobFilter) ([]models.Job, error) {
+	return nil, nil
+}
+
+func (r *memRepository) UpdateJobStatus(_ context.Context, id bson.ObjectID, status models.JobStatus, attempts int, jobErr string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.jobs[id]
+	job.Status = status
+	job.Attempts = attempts
+	job.Error = jobErr
+	r.jobs[id] = job
+	return nil
+}
+
+func (r *memRepository) FailUnfinishedJobs(_ context.Context, message string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failed int64
+	for id, job := range r.jobs {
+		if job.Status == models.JobStatusQueued || job.Status == models.JobStatusPrinting {
+			job.Status = models.JobStatusFailed
+			job.Error = message
+			r.jobs[id] = job
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// stubShipping returns the same reprint for validations of its facility
+type stubShipping struct {
+	shippingmodels.Manager
+	facility string
+}
+
+func (s *stubShipping) ReprintLabel(_ context.Context, _ string, facility string) (*label.Label, error) {
+	if facility != "" && facility != s.facility {
+		return nil, helpers.NewStatusError(http.StatusNotFound, ErrJobNotFound)
+	}
+	return &label.Label{
+		TrackingNumber: "1Z999AA10123456784",
+		ShipTo:         ups.PackageAddress{Name: "Receiving", Address: ups.Address{AddressLine1: "123 Main St", City: "Atlanta", StateProvince: "GA", PostalCode: "30301"}},
+	}, nil
+}
+
+// listen returns the address of a raw printer which sends each document it
+// receives to the channel
+func listen(t *testing.T) (string, chan []byte) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []byte, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			data, _ := io.ReadAll(conn)
+			conn.Close()
+			received <- data
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func newTestManager(t *testing.T, repository models.Repository, address string) models.Manager {
+	t.Helper()
+	config := &printer.Config{
+		Printers: []printer.PrinterConfig{
+			{Name: "atl-zebra", Protocol: printer.ProtocolRaw, Address: address, Format: printer.FormatZPL, Facility: "ATL1"},
+			{Name: "slc-zebra", Protocol: printer.ProtocolRaw, Address: address, Format: printer.FormatZPL, Facility: "SLC1"},
+		},
+		Stations: map[string]string{"dock-1": "atl-zebra", "misconfigured": "slc-zebra"},
+	}
+
+	m, err := NewManager(context.Background(), repository, &stubShipping{facility: "ATL1"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.(*manager).retry.BaseDelay = time.Millisecond
+	m.(*manager).retry.MaxDelay = time.Millisecond
+
+	return m
+}
+
+// waitForStatus polls the job until it has the status
+func waitForStatus(t *testing.T, m models.Manager, id string, status models.JobStatus) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := m.GetJob(context.Background(), id, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job status = %s, want %s", job.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCreateJobPrintsOnStationPrinter(t *testing.T) {
+	address, received := listen(t)
+	m := newTestManager(t, newMemRepository(), address)
+
+	job, err := m.CreateJob(context.Background(), models.NewJob{ValidationID: "validation-1", StationID: "dock-1", Facility: "ATL1", Username: "operator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Printer != "atl-zebra" || job.Status != models.JobStatusQueued || job.TrackingNumber != "1Z999AA10123456784" {
+		t.Errorf("job = %+v", job)
+	}
+
+	select {
+	case data := <-received:
+		if !bytes.HasPrefix(data, []byte("^XA")) || !bytes.Contains(data, []byte("1Z999AA10123456784")) {
+			t.Errorf("printed %q, want the ZPL label", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("label was not printed")
+	}
+	done := waitForStatus(t, m, job.ID.Hex(), models.JobStatusCompleted)
+	if done.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", done.Attempts)
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateJobChecksPrinterFacility(t *testing.T) {
+	address, _ := listen(t)
+	m := newTestManager(t, newMemRepository(), address)
+	defer m.Shutdown(context.Background())
+
+	tests := []struct {
+		name       string
+		input      models.NewJob
+		wantStatus int
+	}{
+		{"own facility printer", models.NewJob{StationID: "other", Printer: "atl-zebra", Facility: "ATL1"}, 0},
+		{"other facility printer", models.NewJob{StationID: "dock-1", Printer: "slc-zebra", Facility: "ATL1"}, http.StatusBadRequest},
+		{"station printer in another facility", models.NewJob{StationID: "misconfigured", Facility: "ATL1"}, http.StatusBadRequest},
+		{"admin on any printer", models.NewJob{StationID: "dock-1", Printer: "slc-zebra"}, 0},
+		{"unknown printer", models.NewJob{StationID: "dock-1", Printer: "missing", Facility: "ATL1"}, http.StatusBadRequest},
+		{"station without printer", models.NewJob{StationID: "unknown", Facility: "ATL1"}, http.StatusUnprocessableEntity},
+		{"validation of another facility", models.NewJob{StationID: "other", Printer: "slc-zebra", Facility: "SLC1"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.CreateJob(context.Background(), tt.input)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if status, _ := helpers.ResolveError(err); status != tt.wantStatus {
+				t.Errorf("status = %d (%v), want %d", status, err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCreateJobFailsWhenPrinterIsDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nothing listens on the printer's address
+	address := listener.Addr().String()
+	listener.Close()
+
+	m := newTestManager(t, newMemRepository(), address)
+	job, err := m.CreateJob(context.Background(), models.NewJob{StationID: "dock-1", Facility: "ATL1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := waitForStatus(t, m, job.ID.Hex(), models.JobStatusFailed)
+	if failed.Attempts != 5 || failed.Error == "" {
+		t.Errorf("job = %+v, want five attempts and an error", failed)
+	}
+}
+
+func TestShutdownRejectsNewJobs(t *testing.T) {
+	address, _ := listen(t)
+	repository := newMemRepository()
+	m := newTestManager(t, repository, address)
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := m.CreateJob(context.Background(), models.NewJob{StationID: "dock-1", Facility: "ATL1"})
+	if status, _ := helpers.ResolveError(err); status != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d (%v), want 503", status, err)
+	}
+	for _, job := range repository.jobs {
+		if job.Status != models.JobStatusFailed {
+			t.Errorf("job created during shutdown is %s, want FAILED", job.Status)
+		}
+	}
+}
+
+func TestNewManagerFailsInterruptedJobs(t *testing.T) {
+	queued := models.Job{ID: bson.NewObjectID(), Status: models.JobStatusQueued}
+	printing := models.Job{ID: bson.NewObjectID(), Status: models.JobStatusPrinting}
+	completed := models.Job{ID: bson.NewObjectID(), Status: models.JobStatusCompleted}
+	repository := newMemRepository(queued, printing, completed)
+
+	newTestManager(t, repository, "127.0.0.1:9100")
+
+	want := map[bson.ObjectID]models.JobStatus{
+		queued.ID:    models.JobStatusFailed,
+		printing.ID:  models.JobStatusFailed,
+		completed.ID: models.JobStatusCompleted,
+	}
+	for id, status := range want {
+		if got := repository.jobs[id].Status; got != status {
+			t.Errorf("job %s = %s, want %s", id.Hex(), got, status)
+		}
+	}
+}
+
+func TestGetJobHidesOtherFacilities(t *testing.T) {
+	job := models.Job{ID: bson.NewObjectID(), Facility: "ATL1", Status: models.JobStatusCompleted}
+	m := newTestManager(t, newMemRepository(job), "127.0.0.1:9100")
+
+	tests := []struct {
+		name       string
+		id         string
+		facility   string
+		wantStatus int
+	}{
+		{"own facility", job.ID.Hex(), "ATL1", 0},
+		{"admin", job.ID.Hex(), "", 0},
+		{"other facility", job.ID.Hex(), "SLC1", http.StatusNotFound},
+		{"unknown job", bson.NewObjectID().Hex(), "ATL1", http.StatusNotFound},
+		{"invalid id", "not-an-id", "ATL1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.GetJob(context.Background(), tt.id, tt.facility)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if status, _ := helpers.ResolveError(err); status != tt.wantStatus {
+				t.Errorf("status = %d (%v), want %d", status, err, tt.wantStatus)
+			}
+		})
+	}
+}
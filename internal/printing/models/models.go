@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Manager interface {
+	CreateJob(ctx context.Context, input NewJob) (*Job, error)
+	GetJob(ctx context.Context, id string, facility string) (*Job, error)
+	ListJobs(ctx context.Context, filter JobFilter) ([]Job, error)
+	// Shutdown stops accepting jobs and waits for dispatched jobs to finish,
+	// or for ctx to end
+	Shutdown(ctx context.Context) error
+}
+
+type Repository interface {
+	CreateJob(ctx context.Context, job *Job) error
+	GetJob(ctx context.Context, id bson.ObjectID) (*Job, error)
+	ListJobs(ctx context.Context, filter JobFilter) ([]Job, error)
+	UpdateJobStatus(ctx context.Context, id bson.ObjectID, status JobStatus, attempts int, jobErr string) error
+	// FailUnfinishedJobs fails the jobs left queued or printing by a previous run
+	FailUnfinishedJobs(ctx context.Context, message string) (int64, error)
+}
+
+type JobStatus string // @name PrintJobStatus
+
+const (
+	JobStatusQueued    JobStatus = "QUEUED"
+	JobStatusPrinting  JobStatus = "PRINTING"
+	JobStatusCompleted JobStatus = "COMPLETED"
+	JobStatusFailed    JobStatus = "FAILED"
+)
+
+type Job struct {
+	ID             bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	CreatedAt      time.Time     `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt" bson:"updatedAt"`
+	StationID      string        `json:"stationId" bson:"stationId"`
+	Facility       string        `json:"facility" bson:"facility"`
+	Username       string        `json:"username" bson:"username"`
+	ValidationID   string        `json:"validationId" bson:"validationId"`
+	TrackingNumber string        `json:"trackingNumber" bson:"trackingNumber"`
+	Printer        string        `json:"printer" bson:"printer"`
+	Format         string        `json:"format" bson:"format"`
+	Status         JobStatus     `json:"status" bson:"status"`
+	Attempts       int           `json:"attempts" bson:"attempts"`
+	Error          string        `json:"error,omitempty" bson:"error,omitempty"`
+} // @name PrintJob
+
+type NewJob struct {
+	ValidationID string
+	StationID    string
+	// Printer overrides the printer configured for the station
+	Printer  string
+	Facility string
+	Username string
+}
+
+type JobFilter struct {
+	Facility  string
+	StationID string
+	Status    JobStatus
+	Limit     int
+}
@@ -0,0 +1,125 @@
+package printing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/printing/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	collectionName   = "print_jobs"
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var ErrJobNotFound = errors.New("print job not found")
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository(ctx context.Context, db *mongo.Database) (models.Repository, error) {
+	collection := db.Collection(collectionName)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "facility", Value: 1}, {Key: "stationId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: collection,
+	}, nil
+}
+
+func (r *repository) CreateJob(ctx context.Context, job *models.Job) error {
+	result, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		job.ID = id
+	}
+
+	return nil
+}
+
+func (r *repository) GetJob(ctx context.Context, id bson.ObjectID) (*models.Job, error) {
+	job := models.Job{}
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *repository) ListJobs(ctx context.Context, filter models.JobFilter) ([]models.Job, error) {
+	query := bson.M{}
+	if filter.Facility != "" {
+		query["facility"] = filter.Facility
+	}
+	if filter.StationID != "" {
+		query["stationId"] = filter.StationID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []models.Job{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *repository) UpdateJobStatus(ctx context.Context, id bson.ObjectID, status models.JobStatus, attempts int, jobErr string) error {
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"status":    status,
+		"attempts":  attempts,
+		"error":     jobErr,
+		"updatedAt": time.Now().UTC(),
+	}})
+
+	return err
+}
+
+func (r *repository) FailUnfinishedJobs(ctx context.Context, message string) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": []models.JobStatus{models.JobStatusQueued, models.JobStatusPrinting}}},
+		bson.M{"$set": bson.M{
+			"status":    models.JobStatusFailed,
+			"error":     message,
+			"updatedAt": time.Now().UTC(),
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
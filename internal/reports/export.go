@@ -0,0 +1,276 @@
+package reports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/reports/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	groupHeader      = []string{"total", "invalid", "invalid_rate", "overridden", "duplicates", "average_latency_ms"}
+	validationHeader = []string{
+		"id", "created_at", "facility", "station_id", "username", "tracking_number", "original_valid", "valid",
+		"mismatches", "duplicate", "override_reason_code", "override_reason", "overridden_by", "prompt_version",
+		"duration_ms", "manifest_id",
+	}
+)
+
+// TableWriter writes the rows of an export
+type TableWriter interface {
+	WriteRow(values []any) error
+	// Close flushes the export to the underlying writer
+	Close() error
+}
+
+type csvTable struct {
+	writer *csv.Writer
+}
+
+// NewCSVTable writes rows as CSV
+func NewCSVTable(w io.Writer, header []string) (TableWriter, error) {
+	table := &csvTable{writer: csv.NewWriter(w)}
+	if err := table.writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+func (t *csvTable) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatCell(value)
+	}
+
+	return t.writer.Write(record)
+}
+
+func (t *csvTable) Close() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+type xlsxTable struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+// NewXLSXTable writes rows to a single sheet workbook, streaming them so large
+// exports are not held as cells in memory
+func NewXLSXTable(w io.Writer, sheet string, header []string) (TableWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &xlsxTable{w: w, file: file, stream: stream}
+	if err := table.WriteRow(stringsToAny(header)); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+func (t *xlsxTable) WriteRow(values []any) error {
+	t.row++
+	cell, err := excelize.CoordinatesToCellName(1, t.row)
+	if err != nil {
+		return err
+	}
+
+	return t.stream.SetRow(cell, xlsxValues(values))
+}
+
+func (t *xlsxTable) Close() error {
+	defer t.file.Close()
+	if err := t.stream.Flush(); err != nil {
+		return err
+	}
+
+	return t.file.Write(t.w)
+}
+
+// WriteSummaryCSV writes the summary as one table with a section column
+func WriteSummaryCSV(w io.Writer, summary *models.Summary) error {
+	header := append([]string{"section", "key"}, groupHeader...)
+	table, err := NewCSVTable(w, header)
+	if err != nil {
+		return err
+	}
+
+	for _, section := range summarySections(summary) {
+		for _, row := range section.rows {
+			// count rows leave the remaining group columns empty
+			record := make([]any, len(header))
+			for i := range record {
+				record[i] = ""
+			}
+			record[0] = section.name
+			copy(record[1:], row)
+			if err := table.WriteRow(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return table.Close()
+}
+
+// WriteSummaryXLSX writes each section of the summary to its own sheet
+func WriteSummaryXLSX(w io.Writer, summary *models.Summary) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	for i, section := range summarySections(summary) {
+		if i == 0 {
+			if err := file.SetSheetName("Sheet1", section.name); err != nil {
+				return err
+			}
+		} else if _, err := file.NewSheet(section.name); err != nil {
+			return err
+		}
+
+		rows := append([][]any{stringsToAny(section.header)}, section.rows...)
+		for r, row := range rows {
+			cell, err := excelize.CoordinatesToCellName(1, r+1)
+			if err != nil {
+				return err
+			}
+			if err := file.SetSheetRow(section.name, cell, &row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return file.Write(w)
+}
+
+type summarySection struct {
+	name   string
+	header []string
+	rows   [][]any
+}
+
+func summarySections(summary *models.Summary) []summarySection {
+	groupSection := func(name, key string, groups []models.Group) summarySection {
+		section := summarySection{name: name, header: append([]string{key}, groupHeader...)}
+		for _, group := range groups {
+			section.rows = append(section.rows, groupRow(group))
+		}
+		return section
+	}
+	countSection := func(name, key string, counts []models.Count) summarySection {
+		section := summarySection{name: name, header: []string{key, "total"}}
+		for _, count := range counts {
+			section.rows = append(section.rows, []any{count.Key, count.Count})
+		}
+		return section
+	}
+
+	totals := summary.Totals
+	totals.Key = "all"
+
+	return []summarySection{
+		groupSection("totals", "key", []models.Group{totals}),
+		groupSection("hourly", "hour", summary.Hourly),
+		groupSection("stations", "station_id", summary.Stations),
+		groupSection("operators", "username", summary.Operators),
+		countSection("mismatch_fields", "field", summary.MismatchFields),
+		countSection("override_reasons", "reason_code", summary.OverrideReasons),
+	}
+}
+
+func groupRow(group models.Group) []any {
+	return []any{
+		group.Key,
+		group.Total,
+		group.Invalid,
+		group.InvalidRate,
+		group.Overridden,
+		group.Duplicates,
+		group.AverageLatencyMs,
+	}
+}
+
+func validationRow(validation shippingmodels.Validation) []any {
+	row := []any{
+		validation.ID.Hex(),
+		validation.CreatedAt,
+		validation.Facility,
+		validation.StationID,
+		validation.Username,
+		validation.TrackingNumber,
+		validation.Result.Valid,
+		validation.Valid,
+		strings.Join(validation.Result.Mismatches, ";"),
+		"",
+		"",
+		"",
+		"",
+		validation.Result.PromptVersion,
+		validation.DurationMs,
+		validation.ManifestID,
+	}
+	if validation.Result.Duplicate != nil {
+		row[9] = string(validation.Result.Duplicate.Kind)
+	}
+	if validation.Override != nil {
+		row[10] = string(validation.Override.ReasonCode)
+		row[11] = validation.Override.Reason
+		row[12] = validation.Override.By
+	}
+
+	return row
+}
+
+// formatCell formats a CSV cell. Text such as an override reason is escaped
+// so it is not evaluated as a formula.
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case string:
+		return helpers.CSVCell(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// xlsxValues formats times as text, which spreadsheets sort correctly without
+// a date style
+func xlsxValues(values []any) []any {
+	cells := make([]any, len(values))
+	for i, value := range values {
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339)
+		}
+		cells[i] = value
+	}
+
+	return cells
+}
+
+func stringsToAny(values []string) []any {
+	cells := make([]any, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+
+	return cells
+}
@@ -0,0 +1,230 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/reports/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	dateFormat = "2006-01-02"
+	xlsxType   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+type handler struct {
+	manager models.Manager
+}
+
+func NewHandler(manager models.Manager) *handler {
+	return &handler{manager: manager}
+}
+
+func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
+	supervisor := router.Group("", auth.RequireRole(authmodels.RoleSupervisor))
+	supervisor.GET("/summary", h.getSummary)
+	supervisor.GET("/validations", h.exportValidations)
+}
+
+type ReportRequest struct {
+	From      string `form:"from"`
+	To        string `form:"to"`
+	Timezone  string `form:"timezone"`
+	StationID string `form:"stationId"`
+	Username  string `form:"username"`
+	Format    string `form:"format"`
+}
+
+// getSummary godoc
+//
+//	@Summary		Get a validation summary
+//	@Description	aggregate stored validations into totals, validations per hour, invalid rate by station and operator, top mismatched address fields and override counts. Invalid counts the original verdicts, before overrides.
+//	@Tags			reports
+//	@Produce		json
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			from		query		string	false	"Start of the report, a day (YYYY-MM-DD) or an RFC 3339 time"
+//	@Param			to			query		string	false	"End of the report, a day included in the report (YYYY-MM-DD) or an RFC 3339 time"
+//	@Param			timezone	query		string	false	"IANA time zone of days and hourly buckets"	default(UTC)
+//	@Param			stationId	query		string	false	"Station ID"
+//	@Param			username	query		string	false	"Operator username"
+//	@Param			format		query		string	false	"Report format"	Enums(json, csv, xlsx)	default(json)
+//	@Success		200			{object}	models.Summary
+//	@Failure		400,401,403	{object}	helpers.ErrorResponse
+//	@Failure		500			{object}	helpers.ErrorResponse
+//	@Router			/reports/summary [get]
+func (h *handler) getSummary(c *gin.Context) {
+	filter, format, err := bindReport(c, "json")
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	summary, err := h.manager.Summarize(c, filter)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	switch format {
+	case "csv":
+		setAttachment(c, "text/csv; charset=utf-8", "validation-summary.csv")
+		err = WriteSummaryCSV(c.Writer, summary)
+	case "xlsx":
+		setAttachment(c, xlsxType, "validation-summary.xlsx")
+		err = WriteSummaryXLSX(c.Writer, summary)
+	default:
+		c.JSON(http.StatusOK, summary)
+	}
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+// exportValidations godoc
+//
+//	@Summary		Export validations
+//	@Description	export the stored validation records, oldest first, as CSV or XLSX. At most 100000 validations are exported at once.
+//	@Tags			reports
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			from		query	string	false	"Start of the export, a day (YYYY-MM-DD) or an RFC 3339 time"
+//	@Param			to			query	string	false	"End of the export, a day included in the export (YYYY-MM-DD) or an RFC 3339 time"
+//	@Param			timezone	query	string	false	"IANA time zone of days"	default(UTC)
+//	@Param			stationId	query	string	false	"Station ID"
+//	@Param			username	query	string	false	"Operator username"
+//	@Param			format		query	string	false	"Export format"	Enums(csv, xlsx)	default(csv)
+//	@Success		200
+//	@Failure		400,401,403	{object}	helpers.ErrorResponse
+//	@Failure		500			{object}	helpers.ErrorResponse
+//	@Router			/reports/validations [get]
+func (h *handler) exportValidations(c *gin.Context) {
+	filter, format, err := bindReport(c, "csv")
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+	if format == "json" {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("format must be csv or xlsx")))
+		return
+	}
+
+	// the table is created with the first validation so errors ahead of it,
+	// such as too many rows, are still reported as JSON
+	var table TableWriter
+	err = h.manager.ExportValidations(c, filter, func(validation shippingmodels.Validation) error {
+		if table == nil {
+			var err error
+			if table, err = newValidationTable(c, format); err != nil {
+				return err
+			}
+		}
+		return table.WriteRow(validationRow(validation))
+	})
+	if err == nil && table == nil {
+		table, err = newValidationTable(c, format)
+	}
+	if table != nil {
+		if closeErr := table.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		if c.Writer.Written() {
+			c.Error(err)
+			return
+		}
+		helpers.HandleError(c, err)
+	}
+}
+
+func newValidationTable(c *gin.Context, format string) (TableWriter, error) {
+	if format == "xlsx" {
+		setAttachment(c, xlsxType, "validations.xlsx")
+		return NewXLSXTable(c.Writer, "validations", validationHeader)
+	}
+
+	setAttachment(c, "text/csv; charset=utf-8", "validations.csv")
+	return NewCSVTable(c.Writer, validationHeader)
+}
+
+func setAttachment(c *gin.Context, contentType string, filename string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+}
+
+func bindReport(c *gin.Context, defaultFormat string) (models.Filter, string, error) {
+	request := ReportRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		return models.Filter{}, "", helpers.NewStatusError(http.StatusBadRequest, err)
+	}
+
+	format := strings.ToLower(request.Format)
+	if format == "" {
+		format = defaultFormat
+	}
+	if format != "json" && format != "csv" && format != "xlsx" {
+		return models.Filter{}, "", helpers.NewStatusError(http.StatusBadRequest, errors.New("format must be json, csv or xlsx"))
+	}
+
+	filter := models.Filter{
+		ValidationFilter: shippingmodels.ValidationFilter{
+			Facility:  auth.FacilityScope(c),
+			StationID: request.StationID,
+			Username:  request.Username,
+		},
+		Location: time.UTC,
+	}
+	if request.Timezone != "" {
+		location, err := time.LoadLocation(request.Timezone)
+		if err != nil {
+			return models.Filter{}, "", helpers.NewStatusError(http.StatusBadRequest, errors.New("unknown timezone"))
+		}
+		filter.Location = location
+	}
+
+	var err error
+	if filter.From, err = parseTime(request.From, filter.Location, false); err != nil {
+		return models.Filter{}, "", helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("from %w", err))
+	}
+	if filter.To, err = parseTime(request.To, filter.Location, true); err != nil {
+		return models.Filter{}, "", helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("to %w", err))
+	}
+
+	return filter, format, nil
+}
+
+// parseTime parses a day or an RFC 3339 time. The end of a range includes the
+// whole of its day.
+func parseTime(value string, location *time.Location, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if day, err := time.ParseInLocation(dateFormat, value, location); err == nil {
+		if end {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("must be formatted as YYYY-MM-DD or RFC 3339")
+	}
+
+	return t, nil
+}
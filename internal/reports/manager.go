@@ -0,0 +1,70 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/reports/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+)
+
+// maxExportRows keeps exports within what spreadsheets open comfortably
+const maxExportRows = 100000
+
+type manager struct {
+	repository models.Repository
+}
+
+func NewManager(repository models.Repository) models.Manager {
+	return &manager{repository: repository}
+}
+
+func (m *manager) Summarize(ctx context.Context, filter models.Filter) (*models.Summary, error) {
+	if filter.Location == nil {
+		filter.Location = time.UTC
+	}
+
+	summary, err := m.repository.Summarize(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Timezone = filter.Location.String()
+	if !filter.From.IsZero() {
+		summary.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		summary.To = &filter.To
+	}
+
+	setInvalidRate(&summary.Totals)
+	for _, groups := range [][]models.Group{summary.Hourly, summary.Stations, summary.Operators} {
+		for i := range groups {
+			setInvalidRate(&groups[i])
+		}
+	}
+
+	return summary, nil
+}
+
+func (m *manager) ExportValidations(ctx context.Context, filter models.Filter, fn func(shippingmodels.Validation) error) error {
+	count, err := m.repository.CountValidations(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if count > maxExportRows {
+		return helpers.NewStatusError(http.StatusBadRequest,
+			fmt.Errorf("%d validations match, narrow the filters to export at most %d", count, maxExportRows))
+	}
+
+	return m.repository.EachValidation(ctx, filter, fn)
+}
+
+func setInvalidRate(group *models.Group) {
+	if group.Total > 0 {
+		group.InvalidRate = float64(group.Invalid) / float64(group.Total)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+)
+
+type Manager interface {
+	Summarize(ctx context.Context, filter Filter) (*Summary, error)
+	// ExportValidations calls fn with each validation matching the filter, oldest first
+	ExportValidations(ctx context.Context, filter Filter, fn func(shippingmodels.Validation) error) error
+}
+
+type Repository interface {
+	Summarize(ctx context.Context, filter Filter) (*Summary, error)
+	CountValidations(ctx context.Context, filter Filter) (int64, error)
+	EachValidation(ctx context.Context, filter Filter, fn func(shippingmodels.Validation) error) error
+}
+
+// Filter selects the validations of a report. Its limit is not used.
+type Filter struct {
+	shippingmodels.ValidationFilter
+	// Location is the time zone of the hourly buckets
+	Location *time.Location
+}
+
+// Group aggregates the validations sharing a key. Invalid counts the original
+// verdicts, before overrides.
+type Group struct {
+	Key              string  `json:"key" bson:"_id"`
+	Total            int     `json:"total" bson:"total"`
+	Invalid          int     `json:"invalid" bson:"invalid"`
+	InvalidRate      float64 `json:"invalidRate" bson:"-"`
+	Overridden       int     `json:"overridden" bson:"overridden"`
+	Duplicates       int     `json:"duplicates" bson:"duplicates"`
+	AverageLatencyMs float64 `json:"averageLatencyMs" bson:"averageLatencyMs"`
+} // @name ReportGroup
+
+type Count struct {
+	Key   string `json:"key" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+} // @name ReportCount
+
+type Summary struct {
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+	Timezone string     `json:"timezone"`
+	Totals   Group      `json:"totals"`
+	// Hourly is keyed by the start of the hour in the report time zone
+	Hourly          []Group `json:"hourly"`
+	Stations        []Group `json:"stations"`
+	Operators       []Group `json:"operators"`
+	MismatchFields  []Count `json:"mismatchFields"`
+	OverrideReasons []Count `json:"overrideReasons"`
+} // @name ReportSummary
@@ -0,0 +1,134 @@
+package reports
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/reports/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const validationsCollectionName = "validations"
+
+type repository struct {
+	validations *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) models.Repository {
+	return &repository{
+		validations: db.Collection(validationsCollectionName),
+	}
+}
+
+func (r *repository) Summarize(ctx context.Context, filter models.Filter) (*models.Summary, error) {
+	stats := bson.M{
+		"total":            bson.M{"$sum": 1},
+		"invalid":          bson.M{"$sum": bson.M{"$cond": bson.A{"$result.valid", 0, 1}}},
+		"overridden":       bson.M{"$sum": isObject("$override")},
+		"duplicates":       bson.M{"$sum": isObject("$result.duplicate")},
+		"averageLatencyMs": bson.M{"$avg": "$durationMs"},
+	}
+	group := func(key any) bson.A {
+		g := bson.M{"_id": key}
+		for field, accumulator := range stats {
+			g[field] = accumulator
+		}
+		return bson.A{
+			bson.M{"$group": g},
+			bson.M{"$sort": bson.M{"_id": 1}},
+		}
+	}
+	count := func(key string) bson.A {
+		return bson.A{
+			bson.M{"$group": bson.M{"_id": key, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: shipping.ValidationQuery(filter.ValidationFilter)}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": group(nil),
+			"hourly": group(bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%dT%H:00",
+				"date":     "$createdAt",
+				"timezone": filter.Location.String(),
+			}}),
+			"stations":  group("$stationId"),
+			"operators": group("$username"),
+			"mismatchFields": append(bson.A{
+				bson.M{"$unwind": "$result.mismatches"},
+			}, count("$result.mismatches")...),
+			"overrideReasons": append(bson.A{
+				bson.M{"$match": bson.M{"override": bson.M{"$exists": true}}},
+			}, count("$override.reasonCode")...),
+		}}},
+	}
+
+	cursor, err := r.validations.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := []struct {
+		Totals          []models.Group `bson:"totals"`
+		Hourly          []models.Group `bson:"hourly"`
+		Stations        []models.Group `bson:"stations"`
+		Operators       []models.Group `bson:"operators"`
+		MismatchFields  []models.Count `bson:"mismatchFields"`
+		OverrideReasons []models.Count `bson:"overrideReasons"`
+	}{}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+	if len(facets) != 1 {
+		return nil, errors.New("unexpected report aggregation result")
+	}
+
+	summary := &models.Summary{
+		Hourly:          facets[0].Hourly,
+		Stations:        facets[0].Stations,
+		Operators:       facets[0].Operators,
+		MismatchFields:  facets[0].MismatchFields,
+		OverrideReasons: facets[0].OverrideReasons,
+	}
+	if len(facets[0].Totals) == 1 {
+		summary.Totals = facets[0].Totals[0]
+	}
+
+	return summary, nil
+}
+
+func (r *repository) CountValidations(ctx context.Context, filter models.Filter) (int64, error) {
+	return r.validations.CountDocuments(ctx, shipping.ValidationQuery(filter.ValidationFilter))
+}
+
+func (r *repository) EachValidation(ctx context.Context, filter models.Filter, fn func(shippingmodels.Validation) error) error {
+	cursor, err := r.validations.Find(ctx, shipping.ValidationQuery(filter.ValidationFilter),
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		validation := shippingmodels.Validation{}
+		if err := cursor.Decode(&validation); err != nil {
+			return err
+		}
+		validation.Result.ID = validation.ID.Hex()
+		if err := fn(validation); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func isObject(field string) bson.M {
+	return bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$type": field}, "object"}}, 1, 0}}
+}
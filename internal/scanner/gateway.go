@@ -0,0 +1,454 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/scanner/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// captureTimeout is how long a barcode waits for its image
+	captureTimeout    = 30 * time.Second
+	validationTimeout = 2 * time.Minute
+	writeTimeout      = 10 * time.Second
+	pongTimeout       = 60 * time.Second
+	pingInterval      = pongTimeout * 9 / 10
+	maxMessageSize    = 16 << 20
+	sendBufferSize    = 16
+	// closeTimeout bounds telling an agent the server is going away
+	closeTimeout = time.Second
+	// maxAgentValidations bounds the validations running for one agent, as
+	// each holds a decoded image and an LLM call
+	maxAgentValidations = 4
+	// scanRate and scanBurst limit the scans of token authenticated agents
+	// per subject, as API keys carry their own limits
+	scanRate  = 2
+	scanBurst = 10
+	// maxScanLimiters bounds the limiters kept before idle ones are dropped
+	maxScanLimiters = 10000
+)
+
+var (
+	errBusy              = errors.New("too many scans are validating, scan again shortly")
+	errScanLimitExceeded = errors.New("scan rate limit exceeded")
+	errShuttingDown      = errors.New("server is shutting down, scan again shortly")
+)
+
+// Gateway routes scanner agents' barcodes to image captures and their images
+// to validations, replacing the standalone scanner bridge
+type Gateway struct {
+	shipping shippingmodels.Manager
+	auth     authmodels.Manager
+
+	mu     sync.Mutex
+	agents map[*agent]struct{}
+	closed bool
+
+	limitersMu sync.Mutex
+	limiters   map[string]*rate.Limiter
+
+	// validations run on the gateway's context rather than their agent's
+	// connection, so a disconnecting agent does not cancel them
+	ctx           context.Context
+	cancel        context.CancelFunc
+	validationsMu sync.Mutex
+	validations   sync.WaitGroup
+	draining      bool
+}
+
+func NewGateway(shipping shippingmodels.Manager, auth authmodels.Manager) *Gateway {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Gateway{
+		shipping: shipping,
+		auth:     auth,
+		agents:   map[*agent]struct{}{},
+		limiters: map[string]*rate.Limiter{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+type scannerState struct {
+	status     string
+	barcode    string
+	barcodeAt  time.Time
+	lastScanAt time.Time
+}
+
+type agent struct {
+	models.Agent
+	conn        *websocket.Conn
+	send        chan models.Command
+	connectedAt time.Time
+	// validating holds a slot per running validation
+	validating chan struct{}
+
+	mu       sync.Mutex
+	scanners map[int]*scannerState
+}
+
+// Serve runs an agent connection until it closes
+func (g *Gateway) Serve(conn *websocket.Conn, identity models.Agent) {
+	a := &agent{
+		Agent:       identity,
+		conn:        conn,
+		send:        make(chan models.Command, sendBufferSize),
+		connectedAt: time.Now().UTC(),
+		validating:  make(chan struct{}, maxAgentValidations),
+		scanners:    map[int]*scannerState{},
+	}
+
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		closeConn(conn)
+		return
+	}
+	g.agents[a] = struct{}{}
+	g.mu.Unlock()
+	slog.Info("scanner agent connected", "station_id", a.StationID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.writeLoop(ctx)
+		close(done)
+	}()
+
+	g.readLoop(a)
+
+	cancel()
+	<-done
+	g.mu.Lock()
+	delete(g.agents, a)
+	g.mu.Unlock()
+	conn.Close()
+	slog.Info("scanner agent disconnected", "station_id", a.StationID)
+}
+
+// Shutdown stops starting validations and waits for running ones, whose
+// agents stay connected to receive the results. Validations still running
+// when ctx is done are cancelled.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	g.validationsMu.Lock()
+	g.draining = true
+	g.validationsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.validations.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		g.cancel()
+		return fmt.Errorf("scanner validations did not finish: %w", ctx.Err())
+	}
+}
+
+// startValidation counts a validation towards the drain, returning false once
+// the gateway is draining
+func (g *Gateway) startValidation() bool {
+	g.validationsMu.Lock()
+	defer g.validationsMu.Unlock()
+
+	if g.draining {
+		return false
+	}
+	g.validations.Add(1)
+	return true
+}
+
+// Close disconnects every agent and refuses new connections, as hijacked
+// websocket connections are not closed by the HTTP server's shutdown. Agents
+// reconnect to another instance.
+func (g *Gateway) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	for a := range g.agents {
+		closeConn(a.conn)
+	}
+}
+
+func (g *Gateway) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
+
+// closeConn tells the agent the server is going away and closes the connection
+func closeConn(conn *websocket.Conn) {
+	message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(closeTimeout))
+	conn.Close()
+}
+
+func (g *Gateway) readLoop(a *agent) {
+	a.conn.SetReadLimit(maxMessageSize)
+	a.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	a.conn.SetPongHandler(func(string) error {
+		return a.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		_, data, err := a.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !g.isClosed() {
+				slog.Warn("scanner agent connection failed", "station_id", a.StationID, "error", err)
+			}
+			return
+		}
+		a.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+
+		message := models.Message{}
+		if err := json.Unmarshal(data, &message); err != nil {
+			slog.Warn("scanner agent sent an invalid message", "station_id", a.StationID, "error", err)
+			continue
+		}
+
+		g.handle(a, message)
+	}
+}
+
+func (g *Gateway) handle(a *agent, message models.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch message.MessageType {
+	case models.MessageTypeAttached:
+		a.scanner(message.ScannerID).status = "attached"
+	case models.MessageTypeDetached:
+		delete(a.scanners, message.ScannerID)
+	case models.MessageTypeScannerList:
+		for _, status := range message.Scanners {
+			a.scanner(status.ScannerID).status = status.Status
+		}
+	case models.MessageTypeBarcode:
+		// a scanned tracking number triggers an image capture on the same scanner
+		state := a.scanner(message.ScannerID)
+		state.barcode = message.Barcode
+		state.barcodeAt = time.Now()
+		a.queue(models.Command{ScannerID: message.ScannerID, CommandType: models.CommandTypeImageCapture})
+	case models.MessageTypeImage:
+		state := a.scanner(message.ScannerID)
+		trackingNumber := ""
+		if time.Since(state.barcodeAt) < captureTimeout {
+			trackingNumber = state.barcode
+		}
+		state.barcode = ""
+		state.lastScanAt = time.Now().UTC()
+
+		// token authenticated scans are limited here, API key scans against
+		// the key's limits as they validate
+		if a.APIKey == nil {
+			if retryAfter, ok := g.allowScan(a.Username); !ok {
+				a.queueError(message.ScannerID, fmt.Sprintf("%s, retry in %ds", errScanLimitExceeded, int(math.Ceil(retryAfter.Seconds()))))
+				return
+			}
+		}
+		select {
+		case a.validating <- struct{}{}:
+		default:
+			a.queueError(message.ScannerID, errBusy.Error())
+			return
+		}
+		if !g.startValidation() {
+			<-a.validating
+			a.queueError(message.ScannerID, errShuttingDown.Error())
+			return
+		}
+		go func() {
+			defer g.validations.Done()
+			defer func() { <-a.validating }()
+			g.validate(g.ctx, a, message.ScannerID, trackingNumber, message.Image)
+		}()
+	case models.MessageTypeResponse:
+	default:
+		slog.Warn("scanner agent sent an unknown message type", "station_id", a.StationID, "message_type", message.MessageType)
+	}
+}
+
+func (g *Gateway) validate(ctx context.Context, a *agent, scannerID int, trackingNumber string, encodedImage string) {
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	data, err := base64.StdEncoding.DecodeString(encodedImage)
+	if err != nil {
+		a.queueError(scannerID, "image is not valid base64")
+		return
+	}
+	image, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		a.queueError(scannerID, "image is not a valid JPEG")
+		return
+	}
+
+	// scans count against the API key like validations over HTTP
+	if a.APIKey != nil {
+		allowance, err := g.auth.Allow(ctx, a.APIKey, 1)
+		if err != nil {
+			_, response := helpers.ResolveError(err)
+			a.queueError(scannerID, response.Error)
+			return
+		}
+		if !allowance.Allowed {
+			a.queueError(scannerID, fmt.Sprintf("%s, retry in %ds", auth.ErrLimitExceeded, int(math.Ceil(allowance.RetryAfter.Seconds()))))
+			return
+		}
+	}
+
+	result, err := g.shipping.Validate(ctx, shippingmodels.ValidationInput{
+		StationID:      a.StationID,
+		Facility:       a.Facility,
+		Username:       a.Username,
+		TrackingNumber: trackingNumber,
+		Image:          image,
+	})
+	if err != nil {
+		slog.Warn("scanner failed to validate", "scanner_id", scannerID, "station_id", a.StationID, "error", err)
+		_, response := helpers.ResolveError(err)
+		a.queueError(scannerID, response.Error)
+		return
+	}
+
+	a.queue(models.Command{ScannerID: scannerID, MessageType: models.MessageTypeValidation, Result: result})
+}
+
+// allowScan takes a scan from the subject's limiter, returning how long until
+// the next scan is allowed when it is over the limit
+func (g *Gateway) allowScan(subject string) (time.Duration, bool) {
+	g.limitersMu.Lock()
+	defer g.limitersMu.Unlock()
+
+	now := time.Now()
+	if len(g.limiters) > maxScanLimiters {
+		for key, limiter := range g.limiters {
+			if limiter.TokensAt(now) >= scanBurst {
+				delete(g.limiters, key)
+			}
+		}
+	}
+
+	limiter, ok := g.limiters[subject]
+	if !ok {
+		limiter = rate.NewLimiter(scanRate, scanBurst)
+		g.limiters[subject] = limiter
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+
+	return 0, true
+}
+
+func (a *agent) writeLoop(ctx context.Context) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+			return
+		case command := <-a.send:
+			a.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := a.conn.WriteJSON(command); err != nil {
+				a.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := a.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				a.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// scanner returns the state of a scanner, registering it on first use. The
+// agent must be locked.
+func (a *agent) scanner(scannerID int) *scannerState {
+	state, ok := a.scanners[scannerID]
+	if !ok {
+		state = &scannerState{status: "attached"}
+		a.scanners[scannerID] = state
+	}
+
+	return state
+}
+
+// queue sends a command without blocking. Commands are dropped when a slow
+// agent's buffer is full.
+func (a *agent) queue(command models.Command) {
+	select {
+	case a.send <- command:
+	default:
+		slog.Warn("scanner agent is not keeping up, dropped a command", "station_id", a.StationID, "command", command.CommandType+command.MessageType)
+	}
+}
+
+func (a *agent) queueError(scannerID int, message string) {
+	a.queue(models.Command{ScannerID: scannerID, MessageType: models.MessageTypeError, Error: message})
+}
+
+// Scanners lists the scanners of connected agents. An empty facility lists
+// the scanners of every facility.
+func (g *Gateway) Scanners(facility string) []models.Scanner {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	scanners := []models.Scanner{}
+	for a := range g.agents {
+		if facility != "" && a.Facility != facility {
+			continue
+		}
+
+		a.mu.Lock()
+		for scannerID, state := range a.scanners {
+			scanners = append(scanners, models.Scanner{
+				StationID:   a.StationID,
+				Facility:    a.Facility,
+				ScannerID:   scannerID,
+				Status:      state.status,
+				ConnectedAt: a.connectedAt,
+				LastScanAt:  state.lastScanAt,
+			})
+		}
+		a.mu.Unlock()
+	}
+
+	sort.Slice(scanners, func(i, j int) bool {
+		if scanners[i].StationID != scanners[j].StationID {
+			return scanners[i].StationID < scanners[j].StationID
+		}
+		return scanners[i].ScannerID < scanners[j].ScannerID
+	})
+
+	return scanners
+}
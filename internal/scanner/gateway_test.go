@@ -0,0 +1,315 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/scanner/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// newTestGateway serves g to websocket clients, which connect with dial as
+// the agent identity
+func newTestGateway(t *testing.T, g *Gateway, identity models.Agent) (dial func() *websocket.Conn) {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		g.Serve(conn, identity)
+	}))
+	t.Cleanup(server.Close)
+
+	return func() *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+}
+
+// waitForAgents waits until n agents are connected
+func waitForAgents(t *testing.T, g *Gateway, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		g.mu.Lock()
+		agents := len(g.agents)
+		g.mu.Unlock()
+		if agents == n {
+			return
+		}
+	}
+	t.Fatalf("agents did not reach %d", n)
+}
+
+func assertGoingAway(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("err = %v, want a going away close", err)
+	}
+}
+
+func TestGatewayClose(t *testing.T) {
+	g := NewGateway(nil, nil)
+	dial := newTestGateway(t, g, models.Agent{StationID: "station-1", Facility: "ATL1"})
+
+	conns := []*websocket.Conn{dial(), dial()}
+	waitForAgents(t, g, len(conns))
+
+	g.Close()
+	for _, conn := range conns {
+		assertGoingAway(t, conn)
+	}
+	waitForAgents(t, g, 0)
+
+	// agents reconnecting to a closed gateway are turned away
+	assertGoingAway(t, dial())
+	if scanners := g.Scanners(""); len(scanners) != 0 {
+		t.Errorf("scanners = %v, want none", scanners)
+	}
+}
+
+type fakeShipping struct {
+	shippingmodels.Manager
+	validations atomic.Int32
+}
+
+func (m *fakeShipping) Validate(context.Context, shippingmodels.ValidationInput) (*shippingmodels.ValidationResult, error) {
+	m.validations.Add(1)
+	return &shippingmodels.ValidationResult{Valid: true}, nil
+}
+
+// fakeLimits allows the first allowed scans of each key
+type fakeLimits struct {
+	authmodels.Manager
+	allowed int
+	err     error
+
+	mu    sync.Mutex
+	scans int
+}
+
+func (m *fakeLimits) Allow(_ context.Context, _ *authmodels.APIKey, n int) (*authmodels.Allowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.scans += n
+	if m.scans > m.allowed {
+		return &authmodels.Allowance{RetryAfter: 90 * time.Second}, nil
+	}
+	return &authmodels.Allowance{Allowed: true}, nil
+}
+
+func testJPEG(t *testing.T) string {
+	t.Helper()
+	b := new(bytes.Buffer)
+	if err := jpeg.Encode(b, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+func TestGatewayLimitsScans(t *testing.T) {
+	apiKey := &authmodels.APIKey{ID: bson.NewObjectID()}
+	tests := []struct {
+		name            string
+		identity        models.Agent
+		limits          *fakeLimits
+		wantValidations int32
+		wantErrors      []string
+	}{
+		{"within the quota", models.Agent{StationID: "station-1", APIKey: apiKey}, &fakeLimits{allowed: 2}, 2, nil},
+		{"over the quota", models.Agent{StationID: "station-1", APIKey: apiKey}, &fakeLimits{allowed: 1}, 1, []string{"api key rate limit or daily quota exceeded, retry in 90s"}},
+		{"limits unavailable", models.Agent{StationID: "station-1", APIKey: apiKey}, &fakeLimits{err: errors.New("mongo unavailable")}, 0, []string{"internal server error", "internal server error"}},
+		{"token authenticated", models.Agent{StationID: "station-1"}, &fakeLimits{allowed: 0}, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shipping := &fakeShipping{}
+			g := NewGateway(shipping, tt.limits)
+			conn := newTestGateway(t, g, tt.identity)()
+
+			image := testJPEG(t)
+			for scannerID := range 2 {
+				if err := conn.WriteJSON(models.Message{MessageType: models.MessageTypeImage, ScannerID: scannerID, Image: image}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			errs := []string{}
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			for range 2 {
+				command := models.Command{}
+				if err := conn.ReadJSON(&command); err != nil {
+					t.Fatal(err)
+				}
+				if command.MessageType == models.MessageTypeError {
+					errs = append(errs, command.Error)
+				}
+			}
+
+			if got := shipping.validations.Load(); got != tt.wantValidations {
+				t.Errorf("validations = %d, want %d", got, tt.wantValidations)
+			}
+			if !slices.Equal(errs, tt.wantErrors) {
+				t.Errorf("errors = %q, want %q", errs, tt.wantErrors)
+			}
+		})
+	}
+}
+
+// blockingShipping holds validations until released
+type blockingShipping struct {
+	shippingmodels.Manager
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingShipping) Validate(context.Context, shippingmodels.ValidationInput) (*shippingmodels.ValidationResult, error) {
+	m.started <- struct{}{}
+	<-m.release
+	return &shippingmodels.ValidationResult{Valid: true}, nil
+}
+
+func TestGatewayBoundsAgentValidations(t *testing.T) {
+	shipping := &blockingShipping{started: make(chan struct{}, maxAgentValidations), release: make(chan struct{})}
+	g := NewGateway(shipping, nil)
+	conn := newTestGateway(t, g, models.Agent{StationID: "station-1", Username: "operator"})()
+
+	image := testJPEG(t)
+	for scannerID := range maxAgentValidations {
+		if err := conn.WriteJSON(models.Message{MessageType: models.MessageTypeImage, ScannerID: scannerID, Image: image}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range maxAgentValidations {
+		select {
+		case <-shipping.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("validations did not start")
+		}
+	}
+
+	// a scan over the bound is answered as busy rather than queued
+	if err := conn.WriteJSON(models.Message{MessageType: models.MessageTypeImage, ScannerID: maxAgentValidations, Image: image}); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	command := models.Command{}
+	if err := conn.ReadJSON(&command); err != nil {
+		t.Fatal(err)
+	}
+	if command.ScannerID != maxAgentValidations || command.Error != errBusy.Error() {
+		t.Errorf("command = %+v, want a busy error", command)
+	}
+
+	close(shipping.release)
+	for range maxAgentValidations {
+		command := models.Command{}
+		if err := conn.ReadJSON(&command); err != nil {
+			t.Fatal(err)
+		}
+		if command.MessageType != models.MessageTypeValidation {
+			t.Errorf("command = %+v, want a validation", command)
+		}
+	}
+}
+
+func TestGatewayShutdownDrainsValidations(t *testing.T) {
+	shipping := &blockingShipping{started: make(chan struct{}, 1), release: make(chan struct{})}
+	g := NewGateway(shipping, nil)
+	conn := newTestGateway(t, g, models.Agent{StationID: "station-1", Username: "operator"})()
+
+	image := testJPEG(t)
+	if err := conn.WriteJSON(models.Message{MessageType: models.MessageTypeImage, ScannerID: 0, Image: image}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-shipping.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("validation did not start")
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- g.Shutdown(ctx)
+	}()
+
+	for draining := false; !draining; time.Sleep(time.Millisecond) {
+		g.validationsMu.Lock()
+		draining = g.draining
+		g.validationsMu.Unlock()
+	}
+
+	// scans while draining are turned away and the running one keeps going
+	if err := conn.WriteJSON(models.Message{MessageType: models.MessageTypeImage, ScannerID: 1, Image: image}); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	command := models.Command{}
+	if err := conn.ReadJSON(&command); err != nil {
+		t.Fatal(err)
+	}
+	if command.ScannerID != 1 || command.Error != errShuttingDown.Error() {
+		t.Errorf("command = %+v, want a shutting down error", command)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown returned %v before the validation finished", err)
+	default:
+	}
+
+	close(shipping.release)
+	if err := conn.ReadJSON(&command); err != nil {
+		t.Fatal(err)
+	}
+	if command.ScannerID != 0 || command.MessageType != models.MessageTypeValidation {
+		t.Errorf("command = %+v, want the drained validation", command)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("shutdown = %v", err)
+	}
+}
+
+func TestGatewayLimitsScansPerSubject(t *testing.T) {
+	g := NewGateway(nil, nil)
+
+	for range scanBurst {
+		if _, ok := g.allowScan("operator"); !ok {
+			t.Fatal("scan within the burst was limited")
+		}
+	}
+	retryAfter, ok := g.allowScan("operator")
+	if ok || retryAfter <= 0 {
+		t.Errorf("allowScan = %s, %t, want limited with a retry", retryAfter, ok)
+	}
+	if _, ok := g.allowScan("supervisor"); !ok {
+		t.Error("another subject was limited")
+	}
+}
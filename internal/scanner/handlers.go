@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/scanner/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type handler struct {
+	gateway  *Gateway
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(gateway *Gateway) *handler {
+	return &handler{
+		gateway: gateway,
+		upgrader: websocket.Upgrader{
+			// agents authenticate with a header rather than cookies, so
+			// cross-origin connections cannot borrow a browser's session
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
+	operator := router.Group("", auth.RequireRole(authmodels.RoleOperator))
+	operator.GET("/connect", h.connect)
+
+	supervisor := router.Group("", auth.RequireRole(authmodels.RoleSupervisor))
+	supervisor.GET("", h.listScanners)
+}
+
+type ScannersResponse struct {
+	Scanners []models.Scanner `json:"scanners"`
+} //	@name	ScannersResponse
+
+// connect godoc
+//
+//	@Summary		Connect a scanner agent
+//	@Description	upgrade to a websocket for a scanner agent. Agents send barcode, image, attached, detached and scanner_list messages; a barcode is answered with an image_capture command for the same scanner and an image with a validation or error message. Images are answered with a busy error while the agent has too many validations running. Browsers may pass the bearer token as the access_token query parameter.
+//	@Tags			scanners
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			stationId		query	string	false	"Station ID, required unless the credentials are bound to a station"
+//	@Param			access_token	query	string	false	"Bearer token, for clients which cannot set headers"
+//	@Success		101
+//	@Failure		400,401,403	{object}	helpers.ErrorResponse
+//	@Router			/scanners/connect [get]
+func (h *handler) connect(c *gin.Context) {
+	identity := models.Agent{
+		StationID: c.Query("stationId"),
+		Facility:  auth.FacilityScope(c),
+	}
+	if apiKey, ok := auth.APIKeyFromContext(c); ok {
+		identity.APIKey = apiKey
+	}
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		identity.Username = claims.Subject
+		identity.Facility = claims.Facility
+		if claims.StationID != "" {
+			identity.StationID = claims.StationID
+		}
+	}
+	if identity.StationID == "" {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("stationId is required")))
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has already written the error response
+		return
+	}
+
+	h.gateway.Serve(conn, identity)
+}
+
+// listScanners godoc
+//
+//	@Summary		List scanners
+//	@Description	list the scanners of connected scanner agents
+//	@Tags			scanners
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200		{object}	ScannersResponse
+//	@Failure		401,403	{object}	helpers.ErrorResponse
+//	@Router			/scanners [get]
+func (h *handler) listScanners(c *gin.Context) {
+	c.JSON(http.StatusOK, ScannersResponse{Scanners: h.gateway.Scanners(auth.FacilityScope(c))})
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+)
+
+// Message types sent by scanner agents, matching the scanner bridge protocol
+const (
+	MessageTypeBarcode     = "barcode"
+	MessageTypeImage       = "image"
+	MessageTypeAttached    = "attached"
+	MessageTypeDetached    = "detached"
+	MessageTypeScannerList = "scanner_list"
+	MessageTypeResponse    = "response"
+)
+
+// Message types sent to scanner agents
+const (
+	MessageTypeValidation = "validation"
+	MessageTypeError      = "error"
+)
+
+const CommandTypeImageCapture = "image_capture"
+
+type ScannerStatus struct {
+	ScannerID int    `json:"scannerId"`
+	Status    string `json:"status"`
+}
+
+// Message is a message from a scanner agent
+type Message struct {
+	MessageType string          `json:"messageType"`
+	ScannerID   int             `json:"scannerId"`
+	Barcode     string          `json:"barcode,omitempty"`
+	Image       string          `json:"image,omitempty"`
+	Scanners    []ScannerStatus `json:"scanners,omitempty"`
+	Status      bool            `json:"status"`
+}
+
+// Command is a message to a scanner agent. Commands carry a CommandType and
+// validation results carry a MessageType.
+type Command struct {
+	ScannerID   int                              `json:"scannerId"`
+	CommandType string                           `json:"commandType,omitempty"`
+	MessageType string                           `json:"messageType,omitempty"`
+	Result      *shippingmodels.ValidationResult `json:"result,omitempty"`
+	Error       string                           `json:"error,omitempty"`
+}
+
+// Scanner is a scanner attached to a connected agent
+type Scanner struct {
+	StationID   string    `json:"stationId"`
+	Facility    string    `json:"facility"`
+	ScannerID   int       `json:"scannerId"`
+	Status      string    `json:"status"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	LastScanAt  time.Time `json:"lastScanAt,omitzero"`
+} // @name Scanner
+
+// Agent identifies the station an agent connection scans for
+type Agent struct {
+	StationID string
+	Facility  string
+	Username  string
+	// APIKey is the key the agent connected with, whose rate limit and daily
+	// quota apply to each scan. It is nil for token authenticated agents.
+	APIKey *authmodels.APIKey
+}
@@ -0,0 +1,138 @@
+package shipping
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxImagePixels bounds the width times height of a batch image, as a small
+// file may decode to a very large image
+const maxImagePixels = 50_000_000
+
+type batchManager struct {
+	manager     models.Manager
+	parallelism int
+	maxItems    int
+}
+
+// NewBatchManager validates batches of labels, running at most parallelism
+// validations of a batch at once
+func NewBatchManager(manager models.Manager, parallelism int, maxItems int) models.BatchManager {
+	return &batchManager{
+		manager:     manager,
+		parallelism: max(parallelism, 1),
+		maxItems:    maxItems,
+	}
+}
+
+func (m *batchManager) MaxItems() int {
+	return m.maxItems
+}
+
+// ValidateBatch validates every item of the batch. A failed item is reported
+// in its result rather than failing the batch.
+func (m *batchManager) ValidateBatch(ctx context.Context, input models.BatchInput) (*models.BatchResult, error) {
+	if len(input.Items) == 0 {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("batch has no items"))
+	}
+	if m.maxItems > 0 && len(input.Items) > m.maxItems {
+		return nil, helpers.NewStatusError(http.StatusRequestEntityTooLarge, fmt.Errorf("batch has %d items, the limit is %d", len(input.Items), m.maxItems))
+	}
+
+	parallelism := m.parallelism
+	if input.Parallelism > 0 {
+		parallelism = min(input.Parallelism, parallelism)
+	}
+
+	start := time.Now()
+	results := make([]models.BatchItemResult, len(input.Items))
+	group := errgroup.Group{}
+	group.SetLimit(parallelism)
+	for i, item := range input.Items {
+		group.Go(func() error {
+			results[i] = m.validateItem(ctx, input, i, item)
+			return nil
+		})
+	}
+	group.Wait()
+
+	summary := models.BatchSummary{
+		Total:      len(results),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	for _, result := range results {
+		switch result.Status {
+		case models.BatchItemStatusValid:
+			summary.Valid++
+		case models.BatchItemStatusInvalid:
+			summary.Invalid++
+		default:
+			summary.Failed++
+		}
+	}
+
+	return &models.BatchResult{
+		Items:   results,
+		Summary: summary,
+	}, nil
+}
+
+func (m *batchManager) validateItem(ctx context.Context, input models.BatchInput, index int, item models.BatchItem) models.BatchItemResult {
+	itemResult := models.BatchItemResult{
+		Index:          index,
+		Name:           item.Name,
+		TrackingNumber: item.TrackingNumber,
+	}
+	fail := func(err error) models.BatchItemResult {
+		_, response := helpers.ResolveError(err)
+		itemResult.Status = models.BatchItemStatusError
+		itemResult.Error = &response
+		return itemResult
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(item.Image))
+	if err != nil {
+		return fail(helpers.NewStatusError(http.StatusBadRequest, errors.New("image is not a valid JPEG or PNG")))
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return fail(helpers.NewStatusError(http.StatusRequestEntityTooLarge, fmt.Errorf("image is %dx%d, the limit is %d megapixels", config.Width, config.Height, maxImagePixels/1_000_000)))
+	}
+	img, _, err := image.Decode(bytes.NewReader(item.Image))
+	if err != nil {
+		return fail(helpers.NewStatusError(http.StatusBadRequest, errors.New("image is not a valid JPEG or PNG")))
+	}
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+
+	result, err := m.manager.Validate(ctx, models.ValidationInput{
+		StationID:      input.StationID,
+		Facility:       input.Facility,
+		Username:       input.Username,
+		TrackingNumber: item.TrackingNumber,
+		Locale:         input.Locale,
+		Image:          img,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	itemResult.Result = result
+	itemResult.Status = models.BatchItemStatusInvalid
+	if result.Valid {
+		itemResult.Status = models.BatchItemStatusValid
+	}
+
+	return itemResult
+}
@@ -0,0 +1,79 @@
+package shipping
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+)
+
+// countingValidator finds every label valid and counts the validations
+type countingValidator struct {
+	models.Manager
+	validations atomic.Int32
+}
+
+func (v *countingValidator) Validate(context.Context, models.ValidationInput) (*models.ValidationResult, error) {
+	v.validations.Add(1)
+	return &models.ValidationResult{Valid: true}, nil
+}
+
+// pngHeader returns the signature and header chunk of a PNG of the size,
+// which is all decoding its config reads
+func pngHeader(width, height uint32) []byte {
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], width)
+	binary.BigEndian.PutUint32(header[4:], height)
+	header[8] = 8 // bit depth of a grayscale image
+
+	chunk := append([]byte("IHDR"), header...)
+	b := bytes.NewBufferString("\x89PNG\r\n\x1a\n")
+	binary.Write(b, binary.BigEndian, uint32(len(header)))
+	b.Write(chunk)
+	binary.Write(b, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return b.Bytes()
+}
+
+func TestValidateBatchChecksImageSize(t *testing.T) {
+	small := new(bytes.Buffer)
+	if err := png.Encode(small, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+
+	validator := &countingValidator{}
+	m := NewBatchManager(validator, 2, 10)
+	result, err := m.ValidateBatch(context.Background(), models.BatchInput{
+		StationID: "dock-1",
+		Facility:  "ATL1",
+		Items: []models.BatchItem{
+			{Name: "small.png", Image: small.Bytes()},
+			{Name: "huge.png", Image: pngHeader(100_000, 100_000)},
+			{Name: "not-an-image.png", Image: []byte("label")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := validator.validations.Load(); got != 1 {
+		t.Errorf("validations = %d, want only the small image validated", got)
+	}
+	if result.Items[0].Status != models.BatchItemStatusValid {
+		t.Errorf("small image status = %s, want valid", result.Items[0].Status)
+	}
+	if item := result.Items[1]; item.Status != models.BatchItemStatusError || item.Error == nil || item.Error.Error != "image is 100000x100000, the limit is 50 megapixels" {
+		t.Errorf("huge image result = %+v, want it rejected by size", item)
+	}
+	if item := result.Items[2]; item.Status != models.BatchItemStatusError || item.Error == nil || item.Error.Error != "image is not a valid JPEG or PNG" {
+		t.Errorf("invalid image result = %+v, want it rejected as invalid", item)
+	}
+	if result.Summary.Valid != 1 || result.Summary.Failed != 2 {
+		t.Errorf("summary = %+v, want 1 valid and 2 failed", result.Summary)
+	}
+}
@@ -0,0 +1,204 @@
+package shipping
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math/bits"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+)
+
+const (
+	defaultDuplicateWindow = 24 * time.Hour
+	maxDuplicateHistory    = 100
+	// defaultMaxHashDistance tolerates the lighting, angle and compression
+	// differences between two photos of the same label
+	defaultMaxHashDistance = 10
+)
+
+// DuplicatePolicy configures duplicate detection for a facility
+type DuplicatePolicy struct {
+	Disabled bool `json:"disabled"`
+	// Window is how far back to look for earlier validations, e.g. 72h
+	Window string `json:"window"`
+	// MaxHashDistance is the most bits of two images' 64 bit perceptual hashes
+	// which may differ for them to be the same label, 10 by default
+	MaxHashDistance *int `json:"maxHashDistance"`
+
+	window          time.Duration
+	maxHashDistance int
+}
+
+// DuplicateConfig holds the default duplicate policy and per-facility overrides
+type DuplicateConfig struct {
+	Default    DuplicatePolicy            `json:"default"`
+	Facilities map[string]DuplicatePolicy `json:"facilities"`
+}
+
+// LoadDuplicateConfig loads the duplicate policies from a JSON file. An empty
+// path returns a config looking back 24 hours in every facility.
+func LoadDuplicateConfig(path string) (*DuplicateConfig, error) {
+	config := &DuplicateConfig{Facilities: map[string]DuplicatePolicy{}}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("duplicates: %w", err)
+		}
+	}
+
+	defaults := DuplicatePolicy{window: defaultDuplicateWindow, maxHashDistance: defaultMaxHashDistance}
+	if err := config.Default.parse(defaults); err != nil {
+		return nil, fmt.Errorf("duplicates: default: %w", err)
+	}
+	for facility, policy := range config.Facilities {
+		if err := policy.parse(config.Default); err != nil {
+			return nil, fmt.Errorf("duplicates: %s: %w", facility, err)
+		}
+		config.Facilities[facility] = policy
+	}
+
+	return config, nil
+}
+
+// parse fills in the settings of the policy, taking those it does not set
+// from fallback
+func (p *DuplicatePolicy) parse(fallback DuplicatePolicy) error {
+	p.window = fallback.window
+	if p.Window != "" {
+		window, err := time.ParseDuration(p.Window)
+		if err != nil {
+			return err
+		}
+		if window <= 0 {
+			return errors.New("window must be positive")
+		}
+		p.window = window
+	}
+
+	p.maxHashDistance = fallback.maxHashDistance
+	if p.MaxHashDistance != nil {
+		if *p.MaxHashDistance < 0 || *p.MaxHashDistance > 64 {
+			return errors.New("maxHashDistance must be between 0 and 64")
+		}
+		p.maxHashDistance = *p.MaxHashDistance
+	}
+
+	return nil
+}
+
+// Policy returns the duplicate policy of a facility
+func (c *DuplicateConfig) Policy(facility string) DuplicatePolicy {
+	if policy, ok := c.Facilities[facility]; ok {
+		return policy
+	}
+
+	return c.Default
+}
+
+// findDuplicate compares a validation with the earlier validations of its
+// tracking number. Any earlier image whose perceptual hash is more than
+// maxDistance bits from this one makes it a second box, otherwise it is a
+// rescan. Validations stored without a perceptual hash are not compared.
+func findDuplicate(perceptualHash string, maxDistance int, previous []models.Validation) *models.Duplicate {
+	if len(previous) == 0 {
+		return nil
+	}
+
+	// previous is newest first
+	match := previous[0]
+	kind := models.DuplicateKindRescan
+	for _, validation := range previous {
+		distance, ok := hashDistance(perceptualHash, validation.PerceptualHash)
+		if ok && distance > maxDistance {
+			match = validation
+			kind = models.DuplicateKindSecondBox
+			break
+		}
+	}
+
+	return &models.Duplicate{
+		Kind:                 kind,
+		PreviousScans:        len(previous),
+		PreviousValidationID: match.ID.Hex(),
+		PreviousStationID:    match.StationID,
+		PreviousScannedAt:    match.CreatedAt,
+	}
+}
+
+const (
+	hashWidth  = 9
+	hashHeight = 8
+	// hashSamples bounds the pixels averaged per axis of a cell, so large
+	// images cost no more than small ones
+	hashSamples = 16
+)
+
+// perceptualHash returns the hex encoded 64 bit difference hash (dHash) of an
+// image. The image is shrunk to 9x8 grey cells and each bit records whether a
+// cell is brighter than its right neighbour, so photos of the same label hash
+// alike despite differences in exposure, scale and JPEG encoding.
+func perceptualHash(img image.Image) string {
+	bounds := img.Bounds()
+	cells := [hashHeight][hashWidth]float64{}
+	for y := range hashHeight {
+		y0 := bounds.Min.Y + y*bounds.Dy()/hashHeight
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/hashHeight, y0+1)
+		for x := range hashWidth {
+			x0 := bounds.Min.X + x*bounds.Dx()/hashWidth
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/hashWidth, x0+1)
+			cells[y][x] = averageLuminance(img, x0, y0, x1, y1)
+		}
+	}
+
+	var hash uint64
+	for y := range hashHeight {
+		for x := range hashWidth - 1 {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return fmt.Sprintf("%016x", hash)
+}
+
+// averageLuminance samples the luminance of the rectangle x0,y0 to x1,y1
+func averageLuminance(img image.Image, x0, y0, x1, y1 int) float64 {
+	stepX := max((x1-x0)/hashSamples, 1)
+	stepY := max((y1-y0)/hashSamples, 1)
+
+	sum, samples := 0.0, 0
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			samples++
+		}
+	}
+
+	return sum / float64(samples)
+}
+
+// hashDistance returns the number of bits two perceptual hashes differ by, or
+// false when either is not a perceptual hash
+func hashDistance(a, b string) (int, bool) {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil || len(a) != 16 {
+		return 0, false
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil || len(b) != 16 {
+		return 0, false
+	}
+
+	return bits.OnesCount64(x ^ y), true
+}
@@ -0,0 +1,169 @@
+package shipping
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func reencode(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+	b := new(bytes.Buffer)
+	if err := jpeg.Encode(b, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := jpeg.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestPerceptualHash(t *testing.T) {
+	label := testLabelImage(900, 600, 230, 15, 40, 70)
+	hash := perceptualHash(label)
+
+	tests := []struct {
+		name    string
+		img     image.Image
+		maxDist int
+		minDist int
+	}{
+		{"same image", label, 0, 0},
+		{"jpeg recompressed", reencode(t, label, 40), 4, 0},
+		{"darker exposure", testLabelImage(900, 600, 190, 15, 40, 70), 4, 0},
+		{"scaled down", testLabelImage(450, 300, 230, 15, 40, 70), 4, 0},
+		{"different label", testLabelImage(900, 600, 230, 25, 55, 85), 64, defaultMaxHashDistance + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance, ok := hashDistance(hash, perceptualHash(tt.img))
+			if !ok {
+				t.Fatal("hashes are not comparable")
+			}
+			if distance < tt.minDist || distance > tt.maxDist {
+				t.Errorf("distance = %d, want [%d, %d]", distance, tt.minDist, tt.maxDist)
+			}
+		})
+	}
+}
+
+func TestHashDistance(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		{"0000000000000000", "0000000000000000", 0, true},
+		{"0000000000000000", "000000000000000f", 4, true},
+		{"ffffffffffffffff", "0000000000000000", 64, true},
+		{"0000000000000000", "", 0, false},
+		// SHA-256 hashes stored before perceptual hashes are not compared
+		{"0000000000000000", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", 0, false},
+		{"000000000000000g", "0000000000000000", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := hashDistance(tt.a, tt.b)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("hashDistance(%q, %q) = %d, %v, want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFindDuplicate(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	validation := func(hash string, hoursAgo int) models.Validation {
+		return models.Validation{
+			ID:             bson.NewObjectID(),
+			CreatedAt:      now.Add(-time.Duration(hoursAgo) * time.Hour),
+			StationID:      "station-" + hash[:2],
+			PerceptualHash: hash,
+		}
+	}
+	same := validation("00000000000000ff", 1)
+	similar := validation("00000000000003ff", 2)
+	different := validation("ffffffffffffff00", 3)
+	legacy := validation("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", 4)
+
+	tests := []struct {
+		name        string
+		maxDistance int
+		previous    []models.Validation
+		wantKind    models.DuplicateKind
+		wantMatch   *models.Validation
+	}{
+		{"first scan", 10, nil, "", nil},
+		{"rescan", 10, []models.Validation{same}, models.DuplicateKindRescan, &same},
+		{"rescan of a similar image", 10, []models.Validation{similar}, models.DuplicateKindRescan, &similar},
+		{"similar image over a strict threshold", 1, []models.Validation{similar}, models.DuplicateKindSecondBox, &similar},
+		{"second box", 10, []models.Validation{different}, models.DuplicateKindSecondBox, &different},
+		{"second box behind a rescan", 10, []models.Validation{same, different}, models.DuplicateKindSecondBox, &different},
+		{"legacy validation", 10, []models.Validation{legacy}, models.DuplicateKindRescan, &legacy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duplicate := findDuplicate("00000000000000ff", tt.maxDistance, tt.previous)
+			if tt.wantMatch == nil {
+				if duplicate != nil {
+					t.Fatalf("duplicate = %+v, want nil", duplicate)
+				}
+				return
+			}
+			if duplicate == nil {
+				t.Fatal("duplicate = nil")
+			}
+			if duplicate.Kind != tt.wantKind || duplicate.PreviousScans != len(tt.previous) {
+				t.Errorf("kind = %s, previous scans = %d, want %s, %d", duplicate.Kind, duplicate.PreviousScans, tt.wantKind, len(tt.previous))
+			}
+			if duplicate.PreviousValidationID != tt.wantMatch.ID.Hex() || duplicate.PreviousStationID != tt.wantMatch.StationID || !duplicate.PreviousScannedAt.Equal(tt.wantMatch.CreatedAt) {
+				t.Errorf("duplicate = %+v, want it to point at %s", duplicate, tt.wantMatch.ID.Hex())
+			}
+		})
+	}
+}
+
+func TestLoadDuplicateConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          string
+		facility        string
+		wantWindow      time.Duration
+		wantMaxDistance int
+		wantErr         bool
+	}{
+		{"defaults", `{}`, "ATL1", defaultDuplicateWindow, defaultMaxHashDistance, false},
+		{"default policy", `{"default": {"window": "48h", "maxHashDistance": 6}}`, "ATL1", 48 * time.Hour, 6, false},
+		{"facility inherits the default", `{"default": {"window": "48h", "maxHashDistance": 6}, "facilities": {"SLC1": {}}}`, "SLC1", 48 * time.Hour, 6, false},
+		{"facility override", `{"default": {"maxHashDistance": 6}, "facilities": {"SLC1": {"window": "72h", "maxHashDistance": 0}}}`, "SLC1", 72 * time.Hour, 0, false},
+		{"invalid window", `{"default": {"window": "soon"}}`, "", 0, 0, true},
+		{"negative window", `{"facilities": {"SLC1": {"window": "-1h"}}}`, "", 0, 0, true},
+		{"distance out of range", `{"default": {"maxHashDistance": 65}}`, "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "duplicates.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadDuplicateConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			policy := config.Policy(tt.facility)
+			if policy.window != tt.wantWindow || policy.maxHashDistance != tt.wantMaxDistance {
+				t.Errorf("window = %s, max distance = %d, want %s, %d", policy.window, policy.maxHashDistance, tt.wantWindow, tt.wantMaxDistance)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package shipping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+)
+
+// Extract calls the LLM with the prompt to read the address and tracking number from a JPEG encoded label image
+func Extract(ctx context.Context, g gpt.GPT, prompt string, image []byte) (*models.Extraction, *gpt.Result, error) {
+	result, err := g.Prompt(ctx, prompt, image)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	extraction := models.Extraction{}
+	if err := json.Unmarshal([]byte(result.Content), &extraction); err != nil {
+		return nil, result, fmt.Errorf("%w: %w", gpt.ErrInvalidContent, err)
+	}
+
+	return &extraction, result, nil
+}
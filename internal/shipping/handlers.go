@@ -1,53 +1,125 @@
 package shipping
 
 import (
+	"archive/zip"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"image/jpeg"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
 	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/label"
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
 	"github.com/gin-gonic/gin"
 )
 
+const dateFormat = "2006-01-02"
+
+const (
+	maxBatchBytes = 256 << 20
+	maxImageBytes = 20 << 20
+)
+
+// upsTrackingNumber matches file names in batch uploads which are a tracking number
+var upsTrackingNumber = regexp.MustCompile(`^1Z[0-9A-Z]{16}$`)
+
 type handler struct {
 	manager models.Manager
+	jobs    models.JobManager
+	batches models.BatchManager
+	limits  authmodels.Manager
 }
 
-func NewHandler(manager models.Manager) *handler {
-	return &handler{manager: manager}
+// NewHandler returns the shipping handler. Validations count against the rate
+// limit and daily quota of the request's API key through limits.
+func NewHandler(manager models.Manager, jobs models.JobManager, batches models.BatchManager, limits authmodels.Manager) *handler {
+	return &handler{
+		manager: manager,
+		jobs:    jobs,
+		batches: batches,
+		limits:  limits,
+	}
 }
 
+// RegisterRoutes registers the label validation routes
 func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
-	router.POST("/label/validate", h.validate)
+	operator := router.Group("", auth.RequireRole(authmodels.RoleOperator))
+	operator.POST("/label/validate", auth.Limit(h.limits), h.validate)
+
+	// batches are limited once their number of items is known
+	supervisor := router.Group("", auth.RequireRole(authmodels.RoleSupervisor))
+	supervisor.POST("/labels/validate-batch", h.validateBatch)
+}
+
+// RegisterValidationRoutes registers the routes for reviewing and overriding
+// stored validations
+func (h *handler) RegisterValidationRoutes(router *gin.RouterGroup) {
+	operator := router.Group("", auth.RequireRole(authmodels.RoleOperator))
+	operator.GET("/:id/label", h.reprintLabel)
+	operator.GET("/jobs/:id", h.getJob)
+
+	supervisor := router.Group("", auth.RequireRole(authmodels.RoleSupervisor))
+	supervisor.GET("", h.listValidations)
+	supervisor.GET("/:id", h.getValidation)
+	supervisor.POST("/:id/override", h.overrideValidation)
 }
 
 type ValidationRequest struct {
+	StationID      string `json:"stationId"`
 	TrackingNumber string `json:"trackingNumber"`
 	Image          string `json:"image"`
-} // @name ValidationRequest
+	Locale         string `json:"locale"`
+	// Async queues the validation and responds with a job to poll instead of the result
+	Async bool `json:"async"`
+	// CallbackURL receives the finished job. Setting it implies async.
+	CallbackURL string `json:"callbackUrl"`
+} //	@name	ValidationRequest
 
 type ValidationResponse struct {
 	Result models.ValidationResult `json:"result"`
-} // @name ValidationResponse
+} //	@name	ValidationResponse
 
 type ValidationError struct {
 	Error string `json:"error"`
-} // @name ValidationError
+} //	@name	ValidationError
 
 // login godoc
 //
 //	@Summary		Check a shipping label
-//	@Description	check a shipping label
+//	@Description	check a shipping label. With async or a callbackUrl the label is validated in the background and a job is returned to poll at /validations/jobs/{id}; the callback receives the finished job as JSON, signed in X-Signature-256 when a webhook secret is configured.
 //	@Tags			shipping
 //	@Accept			json
 //	@Produce		json
-//	@Param			requestBody	body		ValidationRequest	true	"Validation Request"
-//	@Success		200			{object}	ValidationResponse
-//	@Failure		400,500		{object}	ValidationError
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			requestBody		body		ValidationRequest	true	"Validation Request"
+//	@Success		200				{object}	ValidationResponse
+//	@Success		202				{object}	models.Job
+//	@Header			202				{string}	Location	"URL of the job"
+//	@Failure		400				{object}	ValidationError
+//	@Failure		401,403,404		{object}	helpers.ErrorResponse
+//	@Failure		422				{object}	helpers.ErrorResponse
+//	@Failure		429				{object}	helpers.ErrorResponse
+//	@Header			200,202,429		{integer}	X-RateLimit-Limit		"Daily validation quota of the API key"
+//	@Header			200,202,429		{integer}	X-RateLimit-Remaining	"Validations remaining today for the API key"
+//	@Header			200,202,429		{integer}	X-RateLimit-Reset		"Unix time the quota resets"
+//	@Failure		500,502,503,504	{object}	helpers.ErrorResponse
 //	@Router			/shipping/label/validate [post]
 func (h *handler) validate(c *gin.Context) {
+	ctx, span := tracing.Start(c, "handler.validate")
+	defer span.End()
+
 	request := ValidationRequest{}
 	if err := c.BindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, ValidationError{Error: err.Error()})
@@ -55,14 +127,46 @@ func (h *handler) validate(c *gin.Context) {
 	}
 
 	// parse base64 image to native image
+	_, decodeSpan := tracing.Start(ctx, "image.decode")
 	reader := base64.NewDecoder(base64.StdEncoding, strings.NewReader(request.Image))
 	image, err := jpeg.Decode(reader)
+	tracing.End(decodeSpan, err)
 	if err != nil {
-		helpers.HandleError(c, err)
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
 		return
 	}
 
-	result, err := h.manager.Validate(c, request.TrackingNumber, image)
+	// the station of a station device's token takes precedence over the request
+	stationID := request.StationID
+	claims, ok := auth.ClaimsFromContext(c)
+	if ok && claims.StationID != "" {
+		stationID = claims.StationID
+	}
+
+	input := models.ValidationInput{
+		StationID:      stationID,
+		TrackingNumber: request.TrackingNumber,
+		Locale:         request.Locale,
+		Image:          image,
+	}
+	if ok {
+		input.Facility = claims.Facility
+		input.Username = claims.Subject
+	}
+
+	if request.Async || request.CallbackURL != "" {
+		job, err := h.jobs.Submit(ctx, input, request.CallbackURL)
+		if err != nil {
+			helpers.HandleError(c, err)
+			return
+		}
+
+		c.Header("Location", strings.TrimSuffix(c.FullPath(), "/shipping/label/validate")+"/validations/jobs/"+job.ID.Hex())
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	result, err := h.manager.Validate(ctx, input)
 	if err != nil {
 		helpers.HandleError(c, err)
 		return
@@ -70,3 +174,422 @@ func (h *handler) validate(c *gin.Context) {
 
 	c.JSON(http.StatusOK, ValidationResponse{Result: *result})
 }
+
+type ValidationsRequest struct {
+	From           string `form:"from"`
+	To             string `form:"to"`
+	StationID      string `form:"stationId"`
+	TrackingNumber string `form:"trackingNumber"`
+	Valid          string `form:"valid"`
+	Overridden     string `form:"overridden"`
+	ReasonCode     string `form:"reasonCode"`
+	Limit          int    `form:"limit"`
+}
+
+type ValidationsResponse struct {
+	Validations []models.Validation `json:"validations"`
+} //	@name	ValidationsResponse
+
+type OverrideRequest struct {
+	ReasonCode models.OverrideReasonCode `json:"reasonCode" binding:"required" enums:"ADDRESS_MATCHES,CARRIER_DATA_OUTDATED,LABEL_REPRINTED,IMAGE_UNREADABLE,OTHER"`
+	Reason     string                    `json:"reason"`
+} //	@name	OverrideRequest
+
+// listValidations godoc
+//
+//	@Summary		List validations
+//	@Description	list stored validations, newest first, to audit verdicts and overrides
+//	@Tags			validations
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			from			query		string	false	"First day to include (YYYY-MM-DD)"
+//	@Param			to				query		string	false	"Last day to include (YYYY-MM-DD)"
+//	@Param			stationId		query		string	false	"Station ID"
+//	@Param			trackingNumber	query		string	false	"Tracking number"
+//	@Param			valid			query		bool	false	"Effective verdict"
+//	@Param			overridden		query		bool	false	"Only overridden or not overridden validations"
+//	@Param			reasonCode		query		string	false	"Override reason code"
+//	@Param			limit			query		int		false	"Maximum validations to return (default 100, max 1000)"
+//	@Success		200				{object}	ValidationsResponse
+//	@Failure		400,401,403		{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/validations [get]
+func (h *handler) listValidations(c *gin.Context) {
+	request := ValidationsRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	filter := models.ValidationFilter{
+		Facility:       auth.FacilityScope(c),
+		StationID:      request.StationID,
+		TrackingNumber: request.TrackingNumber,
+		ReasonCode:     models.OverrideReasonCode(request.ReasonCode),
+		Limit:          request.Limit,
+	}
+	if request.From != "" {
+		from, err := time.Parse(dateFormat, request.From)
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("from must be formatted as YYYY-MM-DD")))
+			return
+		}
+		filter.From = from
+	}
+	if request.To != "" {
+		to, err := time.Parse(dateFormat, request.To)
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("to must be formatted as YYYY-MM-DD")))
+			return
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if request.Valid != "" {
+		valid, err := strconv.ParseBool(request.Valid)
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("valid must be true or false")))
+			return
+		}
+		filter.Valid = &valid
+	}
+	if request.Overridden != "" {
+		overridden, err := strconv.ParseBool(request.Overridden)
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("overridden must be true or false")))
+			return
+		}
+		filter.Overridden = &overridden
+	}
+
+	validations, err := h.manager.ListValidations(c, filter)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ValidationsResponse{Validations: validations})
+}
+
+// getValidation godoc
+//
+//	@Summary		Get a validation
+//	@Description	get a stored validation with its original and effective verdict
+//	@Tags			validations
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id				path		string	true	"Validation ID"
+//	@Success		200				{object}	models.Validation
+//	@Failure		400,401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/validations/{id} [get]
+func (h *handler) getValidation(c *gin.Context) {
+	validation, err := h.manager.GetValidation(c, c.Param("id"), auth.FacilityScope(c))
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, validation)
+}
+
+// overrideValidation godoc
+//
+//	@Summary		Override a validation
+//	@Description	override the verdict of an invalid validation, recording who overrode it, when and why
+//	@Tags			validations
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id					path		string			true	"Validation ID"
+//	@Param			requestBody			body		OverrideRequest	true	"Override Request"
+//	@Success		200					{object}	models.Validation
+//	@Failure		400,401,403,404,409	{object}	helpers.ErrorResponse
+//	@Failure		500					{object}	helpers.ErrorResponse
+//	@Router			/validations/{id}/override [post]
+func (h *handler) overrideValidation(c *gin.Context) {
+	request := OverrideRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	input := models.OverrideInput{
+		Facility:   auth.FacilityScope(c),
+		ReasonCode: request.ReasonCode,
+		Reason:     request.Reason,
+	}
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		input.Username = claims.Subject
+	}
+
+	validation, err := h.manager.Override(c, c.Param("id"), input)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, validation)
+}
+
+type BatchItemRequest struct {
+	Name           string `json:"name"`
+	TrackingNumber string `json:"trackingNumber"`
+	Image          string `json:"image"`
+} // @name BatchItemRequest
+
+type BatchRequest struct {
+	StationID   string             `json:"stationId" form:"stationId"`
+	Locale      string             `json:"locale" form:"locale"`
+	Parallelism int                `json:"parallelism" form:"parallelism"`
+	Items       []BatchItemRequest `json:"items"`
+} // @name BatchRequest
+
+// validateBatch godoc
+//
+//	@Summary		Check a batch of shipping labels
+//	@Description	check many shipping labels concurrently for audits. Send JSON with base64 JPEG or PNG images, or a multipart form with a zip of images in the file field and stationId, locale and parallelism fields; images named after their tracking number are checked against it. Items which fail are reported in their result without failing the batch. Each item counts against the rate limit and daily quota of an API key.
+//	@Tags			shipping
+//	@Accept			json
+//	@Accept			mpfd
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			requestBody		body		BatchRequest	true	"Batch Request"
+//	@Success		200				{object}	models.BatchResult
+//	@Failure		400,401,403,413	{object}	helpers.ErrorResponse
+//	@Failure		429				{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/shipping/labels/validate-batch [post]
+func (h *handler) validateBatch(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBytes)
+
+	request := BatchRequest{}
+	items := []models.BatchItem{}
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		if err := c.ShouldBind(&request); err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+			return
+		}
+		file, err := c.FormFile("file")
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("file is required")))
+			return
+		}
+		items, err = readZipItems(file, h.batches.MaxItems())
+		if err != nil {
+			helpers.HandleError(c, err)
+			return
+		}
+	} else {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+			return
+		}
+		if maxItems := h.batches.MaxItems(); maxItems > 0 && len(request.Items) > maxItems {
+			helpers.HandleError(c, errTooManyItems(maxItems))
+			return
+		}
+		for i, item := range request.Items {
+			image, err := base64.StdEncoding.DecodeString(item.Image)
+			if err != nil {
+				helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("items[%d]: image is not valid base64", i)))
+				return
+			}
+			items = append(items, models.BatchItem{
+				Name:           item.Name,
+				TrackingNumber: item.TrackingNumber,
+				Image:          image,
+			})
+		}
+	}
+
+	input := models.BatchInput{
+		StationID:   request.StationID,
+		Facility:    auth.FacilityScope(c),
+		Locale:      request.Locale,
+		Parallelism: request.Parallelism,
+		Items:       items,
+	}
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		input.Facility = claims.Facility
+		input.Username = claims.Subject
+		if claims.StationID != "" {
+			input.StationID = claims.StationID
+		}
+	}
+
+	// each item counts as a validation
+	if len(items) > 0 && !auth.AllowN(c, h.limits, len(items)) {
+		return
+	}
+
+	result, err := h.batches.ValidateBatch(c, input)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func errTooManyItems(maxItems int) error {
+	return helpers.NewStatusError(http.StatusRequestEntityTooLarge, fmt.Errorf("batch has more than %d items", maxItems))
+}
+
+// readZipItems reads the JPEG and PNG images of a zip upload, rejecting zips
+// with more than maxItems images or which decompress to more than maxBatchBytes
+func readZipItems(file *multipart.FileHeader, maxItems int) ([]models.BatchItem, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	archive, err := zip.NewReader(f, file.Size)
+	if err != nil {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("file is not a valid zip"))
+	}
+
+	items := []models.BatchItem{}
+	remaining := int64(maxBatchBytes)
+	for _, entry := range archive.File {
+		name := path.Base(entry.Name)
+		extension := strings.ToLower(path.Ext(name))
+		if entry.FileInfo().IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(entry.Name, "__MACOSX/") ||
+			(extension != ".jpg" && extension != ".jpeg" && extension != ".png") {
+			continue
+		}
+		if maxItems > 0 && len(items) == maxItems {
+			return nil, errTooManyItems(maxItems)
+		}
+		if entry.UncompressedSize64 > maxImageBytes {
+			return nil, errImageTooLarge(entry.Name)
+		}
+
+		image, err := readZipEntry(entry, min(maxImageBytes, remaining))
+		if errors.Is(err, errEntryTooLarge) {
+			if remaining < maxImageBytes {
+				return nil, helpers.NewStatusError(http.StatusRequestEntityTooLarge, fmt.Errorf("zip images are larger than %d MB in total", maxBatchBytes>>20))
+			}
+			return nil, errImageTooLarge(entry.Name)
+		}
+		if err != nil {
+			return nil, helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("%s: %w", entry.Name, err))
+		}
+		remaining -= int64(len(image))
+
+		item := models.BatchItem{Name: entry.Name, Image: image}
+		if trackingNumber := strings.ToUpper(strings.TrimSuffix(name, path.Ext(name))); upsTrackingNumber.MatchString(trackingNumber) {
+			item.TrackingNumber = trackingNumber
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func errImageTooLarge(name string) error {
+	return helpers.NewStatusError(http.StatusRequestEntityTooLarge, fmt.Errorf("%s is larger than %d MB", name, maxImageBytes>>20))
+}
+
+var errEntryTooLarge = errors.New("zip entry is too large")
+
+// readZipEntry decompresses the entry, failing with errEntryTooLarge once it
+// is longer than limit as the declared size is not trusted
+func readZipEntry(entry *zip.File, limit int64) ([]byte, error) {
+	r, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errEntryTooLarge
+	}
+
+	return data, nil
+}
+
+// getJob godoc
+//
+//	@Summary		Get a validation job
+//	@Description	get the status, progress and, once finished, the result of an asynchronous validation
+//	@Tags			validations
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			id				path		string	true	"Job ID"
+//	@Success		200				{object}	models.Job
+//	@Failure		400,401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		500				{object}	helpers.ErrorResponse
+//	@Router			/validations/jobs/{id} [get]
+func (h *handler) getJob(c *gin.Context) {
+	job, err := h.jobs.GetJob(c, c.Param("id"), auth.FacilityScope(c))
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+type LabelRequest struct {
+	Format string `form:"format"`
+}
+
+// reprintLabel godoc
+//
+//	@Summary		Reprint a label
+//	@Description	render a corrected 4x6 label for a validation from the carrier's data, as ZPL for thermal printers or PDF for laser printers
+//	@Tags			validations
+//	@Produce		application/zpl
+//	@Produce		application/pdf
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Param			id				path		string	true	"Validation ID"
+//	@Param			format			query		string	false	"Label format"	Enums(zpl, pdf)	default(zpl)
+//	@Success		200				{file}		file
+//	@Failure		400,401,403,404	{object}	helpers.ErrorResponse
+//	@Failure		422,429			{object}	helpers.ErrorResponse
+//	@Failure		500,502,504		{object}	helpers.ErrorResponse
+//	@Router			/validations/{id}/label [get]
+func (h *handler) reprintLabel(c *gin.Context) {
+	request := LabelRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	render, contentType := label.ZPL, "application/zpl"
+	switch strings.ToLower(request.Format) {
+	case "", "zpl":
+	case "pdf":
+		render, contentType = label.PDF, "application/pdf"
+	default:
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("format must be zpl or pdf")))
+		return
+	}
+
+	reprint, err := h.manager.ReprintLabel(c, c.Param("id"), auth.FacilityScope(c))
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	data, err := render(*reprint)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	extension := "zpl"
+	if contentType == "application/pdf" {
+		extension = "pdf"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, reprint.TrackingNumber, extension))
+	c.Data(http.StatusOK, contentType, data)
+}
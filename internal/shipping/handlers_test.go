@@ -0,0 +1,188 @@
+package shipping
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type zipEntry struct {
+	name string
+	size int
+}
+
+// newZipUpload returns the zip of entries as an uploaded file, each entry
+// filled with size zero bytes
+func newZipUpload(t *testing.T, entries ...zipEntry) *multipart.FileHeader {
+	t.Helper()
+	archive := new(bytes.Buffer)
+	w := zip.NewWriter(archive)
+	for _, entry := range entries {
+		f, err := w.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(make([]byte, entry.size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", "labels.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(archive.Bytes())
+	form.Close()
+
+	parsed, err := multipart.NewReader(body, form.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { parsed.RemoveAll() })
+
+	return parsed.File["file"][0]
+}
+
+func TestReadZipItems(t *testing.T) {
+	// the entries compress well so each stays small in the upload
+	images := func(n int, size int) []zipEntry {
+		entries := []zipEntry{}
+		for i := range n {
+			entries = append(entries, zipEntry{string(rune('a'+i%26)) + string(rune('a'+i/26)) + ".jpg", size})
+		}
+		return entries
+	}
+
+	tests := []struct {
+		name       string
+		entries    []zipEntry
+		maxItems   int
+		wantItems  int
+		wantStatus int
+	}{
+		{"images", []zipEntry{{"1Z999AA10123456784.jpg", 10}, {"b.png", 10}}, 10, 2, 0},
+		{"skips other files", []zipEntry{{"a.jpg", 10}, {"notes.txt", 10}, {"__MACOSX/._a.jpg", 10}, {".hidden.jpg", 10}}, 10, 1, 0},
+		{"at the item limit", images(3, 10), 3, 3, 0},
+		{"over the item limit", images(4, 10), 3, 0, http.StatusRequestEntityTooLarge},
+		{"unlimited items", images(30, 10), 0, 30, 0},
+		{"image too large", []zipEntry{{"a.jpg", maxImageBytes + 1}}, 10, 0, http.StatusRequestEntityTooLarge},
+		{"images too large in total", images(maxBatchBytes/maxImageBytes+1, maxImageBytes), 0, 0, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := readZipItems(newZipUpload(t, tt.entries...), tt.maxItems)
+			if tt.wantStatus != 0 {
+				status, _ := helpers.ResolveError(err)
+				if status != tt.wantStatus {
+					t.Fatalf("status = %d (%v), want %d", status, err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != tt.wantItems {
+				t.Errorf("items = %d, want %d", len(items), tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestReadZipItemsTrackingNumbers(t *testing.T) {
+	items, err := readZipItems(newZipUpload(t, zipEntry{"scans/1z999aa10123456784.JPG", 10}, zipEntry{"label.jpg", 10}), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].TrackingNumber != "1Z999AA10123456784" || items[1].TrackingNumber != "" {
+		t.Errorf("tracking numbers = %q, %q, want 1Z999AA10123456784 and none", items[0].TrackingNumber, items[1].TrackingNumber)
+	}
+}
+
+func TestReadZipItemsRejectsInvalidZip(t *testing.T) {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, _ := form.CreateFormFile("file", "labels.zip")
+	part.Write([]byte("not a zip"))
+	form.Close()
+	parsed, err := multipart.NewReader(body, form.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readZipItems(parsed.File["file"][0], 0)
+	if status, _ := helpers.ResolveError(err); status != http.StatusBadRequest {
+		t.Errorf("status = %d (%v), want 400", status, err)
+	}
+}
+
+func TestValidateReturnsTraceID(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	m := newTestManager(t, newMemRepository(), &stubUPS{address: testAddress}, models.Extraction{
+		Address:        testAddress,
+		TrackingNumber: "1Z999AA10123456784",
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// as in main, so spans started from the gin context join the request's
+	router.ContextWithFallback = true
+	router.Use(otelgin.Middleware("test"), tracing.TraceID())
+	authenticated := router.Group("", func(c *gin.Context) {
+		auth.SetClaims(c, &authmodels.Claims{Facility: "ATL1", Roles: []string{authmodels.RoleOperator}})
+	})
+	NewHandler(m, nil, nil, nil).RegisterRoutes(authenticated.Group("/shipping"))
+
+	image := new(bytes.Buffer)
+	if err := jpeg.Encode(image, testLabelImage(64, 64, 200, 3, 9), nil); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(ValidationRequest{StationID: "dock-1", Image: base64.StdEncoding.EncodeToString(image.Bytes())})
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/shipping/label/validate", bytes.NewReader(body)))
+
+	if response.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", response.Code, response.Body)
+	}
+	traceID := response.Header().Get(tracing.TraceIDHeader)
+	if traceID == "" {
+		t.Fatal("response has no trace ID")
+	}
+
+	// the validation's spans belong to the trace the response names
+	spans := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = true
+		}
+	}
+	for _, name := range []string{"/shipping/label/validate", "handler.validate", "image.decode", "shipping.Validate"} {
+		if !spans[name] {
+			t.Errorf("trace %s has no %s span, spans %v", traceID, name, spans)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	eventmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/events/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/logging"
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// jobTimeout bounds a validation job once a worker starts it
+const jobTimeout = 2 * time.Minute
+
+var (
+	errJobNotFound = helpers.NewStatusError(http.StatusNotFound, ErrJobNotFound)
+	errQueueFull   = helpers.NewStatusError(http.StatusServiceUnavailable, errors.New("validation queue is full, try again shortly"))
+	errShutdown    = helpers.NewStatusError(http.StatusServiceUnavailable, errors.New("server is shutting down, try again shortly"))
+)
+
+type jobRequest struct {
+	job   *models.Job
+	input models.ValidationInput
+	// submitted links the job's trace to the request which submitted it
+	submitted trace.Link
+	// requestID is the ID of the request which submitted the job
+	requestID string
+}
+
+type jobManager struct {
+	manager    models.Manager
+	repository models.Repository
+	webhook    *webhook
+	queue      chan jobRequest
+	// mu guards closing the queue against concurrent submissions
+	mu     sync.RWMutex
+	closed bool
+	// pending counts the workers and callback deliveries still running
+	pending sync.WaitGroup
+}
+
+// NewJobManager starts workers validating submitted jobs. At most queueSize
+// jobs wait for a worker; further jobs are rejected until the queue drains.
+// Jobs left unfinished by a previous run are failed as their images are lost.
+func NewJobManager(
+	ctx context.Context,
+	manager models.Manager,
+	repository models.Repository,
+	webhook *webhook,
+	workers int,
+	queueSize int,
+) (models.JobManager, error) {
+	interrupted, err := repository.FailUnfinishedJobs(ctx, "validation was interrupted by a restart")
+	if err != nil {
+		return nil, err
+	}
+	if interrupted > 0 {
+		slog.Warn("failed validation jobs interrupted by a restart", "jobs", interrupted)
+	}
+
+	m := &jobManager{
+		manager:    manager,
+		repository: repository,
+		webhook:    webhook,
+		queue:      make(chan jobRequest, queueSize),
+	}
+
+	for range max(workers, 1) {
+		m.pending.Add(1)
+		go m.work()
+	}
+
+	return m, nil
+}
+
+func (m *jobManager) Submit(ctx context.Context, input models.ValidationInput, callbackURL string) (*models.Job, error) {
+	if callbackURL != "" {
+		if err := validateCallbackURL(callbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	input.ID = bson.NewObjectID()
+	job := &models.Job{
+		ID:             input.ID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		StationID:      input.StationID,
+		Facility:       input.Facility,
+		Username:       input.Username,
+		TrackingNumber: input.TrackingNumber,
+		Status:         models.JobStatusQueued,
+		CallbackURL:    callbackURL,
+	}
+	if callbackURL != "" {
+		job.CallbackStatus = models.CallbackStatusPending
+	}
+	if err := m.repository.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if err := m.enqueue(jobRequest{job: job, input: input, submitted: trace.LinkFromContext(ctx), requestID: logging.RequestID(ctx)}); err != nil {
+		_, response := helpers.ResolveError(err)
+		if err := m.repository.UpdateJob(ctx, job.ID, models.JobUpdate{Status: models.JobStatusFailed, Error: &response}); err != nil {
+			slog.ErrorContext(ctx, "failed to update validation job", "job_id", job.ID.Hex(), "error", err)
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (m *jobManager) enqueue(request jobRequest) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return errShutdown
+	}
+	select {
+	case m.queue <- request:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+func (m *jobManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("validation jobs did not finish: %w", ctx.Err())
+	}
+}
+
+func (m *jobManager) GetJob(ctx context.Context, id string, facility string) (*models.Job, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("invalid job id"))
+	}
+
+	job, err := m.repository.GetJob(ctx, objectID)
+	if errors.Is(err, ErrJobNotFound) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if facility != "" && job.Facility != facility {
+		return nil, errJobNotFound
+	}
+
+	return job, nil
+}
+
+func (m *jobManager) work() {
+	defer m.pending.Done()
+	for request := range m.queue {
+		m.run(request)
+	}
+}
+
+func (m *jobManager) run(request jobRequest) {
+	job := request.job
+	id := job.ID.Hex()
+
+	ctx, cancel := context.WithTimeout(logging.WithRequestID(context.Background(), request.requestID), jobTimeout)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "validation job", trace.WithLinks(request.submitted))
+	defer span.End()
+
+	m.update(job.ID, models.JobUpdate{Status: models.JobStatusRunning})
+
+	// the worker records the job's stages itself rather than following the
+	// event broker, which closes before jobs drain on shutdown
+	request.input.Progress = func(stage string, trackingNumber string) {
+		if stage != string(eventmodels.EventTypeExtracting) && stage != string(eventmodels.EventTypeLookingUp) {
+			return
+		}
+		m.update(job.ID, models.JobUpdate{
+			Stage:          stage,
+			TrackingNumber: trackingNumber,
+			IfStatus:       models.JobStatusRunning,
+		})
+	}
+
+	result, err := m.manager.Validate(ctx, request.input)
+	update := models.JobUpdate{Status: models.JobStatusCompleted, Stage: string(eventmodels.EventTypeVerdict), Result: result}
+	if err != nil {
+		slog.WarnContext(ctx, "validation job failed", "job_id", id, "error", err)
+		_, response := helpers.ResolveError(err)
+		update = models.JobUpdate{Status: models.JobStatusFailed, Stage: string(eventmodels.EventTypeFailed), Error: &response}
+	}
+	m.update(job.ID, update)
+
+	if job.CallbackURL == "" {
+		return
+	}
+
+	job.Status, job.Stage, job.Result, job.Error = update.Status, update.Stage, update.Result, update.Error
+	job.UpdatedAt = time.Now().UTC()
+	// deliver in the background so retries do not hold up the worker
+	m.pending.Add(1)
+	go func() {
+		defer m.pending.Done()
+		status := models.CallbackStatusDelivered
+		if err := m.webhook.Deliver(job.CallbackURL, job); err != nil {
+			slog.Warn("failed to deliver validation job to its callback", "job_id", id, "request_id", request.requestID, "error", err)
+			status = models.CallbackStatusFailed
+		}
+		m.update(job.ID, models.JobUpdate{CallbackStatus: status})
+	}()
+}
+
+func (m *jobManager) update(id bson.ObjectID, update models.JobUpdate) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := m.repository.UpdateJob(ctx, id, update); err != nil {
+		slog.Error("failed to update validation job", "job_id", id.Hex(), "error", err)
+	}
+}
+
+func validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("callbackUrl must be an absolute http or https URL"))
+	}
+
+	// names are checked when dialed as they may resolve to any address
+	addr, err := netip.ParseAddr(u.Hostname())
+	if (err == nil && !isPublicAddr(addr)) || strings.EqualFold(u.Hostname(), "localhost") {
+		return helpers.NewStatusError(http.StatusBadRequest, fmt.Errorf("callbackUrl must not point to an internal address"))
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package shipping
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	eventmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/events/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// blockingValidator reports the progress of each validation and holds it
+// until released
+type blockingValidator struct {
+	models.Manager
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingValidator() *blockingValidator {
+	return &blockingValidator{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (v *blockingValidator) Validate(_ context.Context, input models.ValidationInput) (*models.ValidationResult, error) {
+	input.Progress(string(eventmodels.EventTypeReceived), "")
+	input.Progress(string(eventmodels.EventTypeExtracting), "")
+	input.Progress(string(eventmodels.EventTypeLookingUp), "1Z999AA10123456784")
+	v.started <- struct{}{}
+	<-v.release
+	return &models.ValidationResult{Valid: true}, nil
+}
+
+func (v *blockingValidator) waitStarted(t *testing.T) {
+	t.Helper()
+	select {
+	case <-v.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("validation did not start")
+	}
+}
+
+func newTestJobManager(t *testing.T, validator models.Manager, repository models.Repository, queueSize int) models.JobManager {
+	t.Helper()
+	m, err := NewJobManager(context.Background(), validator, repository, NewWebhook(""), 1, queueSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// pollJob gets the job until it has the status
+func pollJob(t *testing.T, m models.JobManager, id string, status models.JobStatus) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := m.GetJob(context.Background(), id, "ATL1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job status = %s, want %s", job.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobPolling(t *testing.T) {
+	ctx := context.Background()
+	validator := newBlockingValidator()
+	m := newTestJobManager(t, validator, newMemRepository(), 1)
+
+	job, err := m.Submit(ctx, models.ValidationInput{StationID: "dock-1", Facility: "ATL1"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.JobStatusQueued {
+		t.Errorf("submitted job status = %s, want QUEUED", job.Status)
+	}
+
+	// the worker records the stage the validation reached while it runs
+	validator.waitStarted(t)
+	running := pollJob(t, m, job.ID.Hex(), models.JobStatusRunning)
+	if running.Stage != string(eventmodels.EventTypeLookingUp) || running.TrackingNumber != "1Z999AA10123456784" {
+		t.Errorf("running job = %+v, want looking up the tracking number", running)
+	}
+
+	close(validator.release)
+	completed := pollJob(t, m, job.ID.Hex(), models.JobStatusCompleted)
+	if completed.Stage != string(eventmodels.EventTypeVerdict) || completed.Result == nil || !completed.Result.Valid {
+		t.Errorf("completed job = %+v, want a valid verdict", completed)
+	}
+
+	if _, err := m.GetJob(ctx, job.ID.Hex(), "SLC1"); err == nil {
+		t.Error("job was visible to another facility")
+	} else if status, _ := helpers.ResolveError(err); status != http.StatusNotFound {
+		t.Errorf("other facility status = %d, want 404", status)
+	}
+
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubmitRejectsWhenQueueIsFull(t *testing.T) {
+	ctx := context.Background()
+	validator := newBlockingValidator()
+	repository := newMemRepository()
+	m := newTestJobManager(t, validator, repository, 1)
+	input := models.ValidationInput{StationID: "dock-1", Facility: "ATL1"}
+
+	// the only worker takes the first job and the second waits in the queue
+	if _, err := m.Submit(ctx, input, ""); err != nil {
+		t.Fatal(err)
+	}
+	validator.waitStarted(t)
+	queued, err := m.Submit(ctx, input, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Submit(ctx, input, "")
+	if status, _ := helpers.ResolveError(err); status != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d (%v), want 503", status, err)
+	}
+	failed := 0
+	for _, job := range repository.jobs {
+		if job.Status == models.JobStatusFailed {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d failed jobs, want the rejected job failed", failed)
+	}
+
+	// shutting down drains the queued job rather than dropping it
+	close(validator.release)
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if job, _ := repository.GetJob(ctx, queued.ID); job.Status != models.JobStatusCompleted {
+		t.Errorf("queued job status = %s, want COMPLETED", job.Status)
+	}
+
+	_, err = m.Submit(ctx, input, "")
+	if status, _ := helpers.ResolveError(err); status != http.StatusServiceUnavailable {
+		t.Errorf("status after shutdown = %d (%v), want 503", status, err)
+	}
+}
+
+func TestNewJobManagerFailsInterruptedJobs(t *testing.T) {
+	ctx := context.Background()
+	repository := newMemRepository()
+	jobs := map[models.JobStatus]bson.ObjectID{}
+	for _, status := range []models.JobStatus{models.JobStatusQueued, models.JobStatusRunning, models.JobStatusCompleted} {
+		job := &models.Job{ID: bson.NewObjectID(), Facility: "ATL1", Status: status}
+		repository.CreateJob(ctx, job)
+		jobs[status] = job.ID
+	}
+
+	m := newTestJobManager(t, newBlockingValidator(), repository, 1)
+	defer m.Shutdown(ctx)
+
+	want := map[models.JobStatus]models.JobStatus{
+		models.JobStatusQueued:    models.JobStatusFailed,
+		models.JobStatusRunning:   models.JobStatusFailed,
+		models.JobStatusCompleted: models.JobStatusCompleted,
+	}
+	for before, after := range want {
+		job, err := m.GetJob(ctx, jobs[before].Hex(), "ATL1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != after {
+			t.Errorf("%s job is %s after a restart, want %s", before, job.Status, after)
+		}
+		if after == models.JobStatusFailed && job.Error == nil {
+			t.Errorf("%s job failed without an error", before)
+		}
+	}
+}
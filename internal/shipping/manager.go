@@ -3,84 +3,368 @@ package shipping
 import (
 	"bytes"
 	"context"
-	_ "embed"
-	"encoding/json"
 	"errors"
-	"image"
 	"image/jpeg"
+	"log/slog"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	eventmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/events/models"
 	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	usagemodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/label"
+	"github.com/JoshuaPackardHR/shipping-label-validator/metrics"
+	"github.com/JoshuaPackardHR/shipping-label-validator/prompts"
+	"github.com/JoshuaPackardHR/shipping-label-validator/reporting"
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
 	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-//go:embed prompt.txt
-var prompt string
+const carrier = "ups"
+
+var (
+	errInvalidValidationID = helpers.NewStatusError(http.StatusBadRequest, errors.New("invalid validation id"))
+	errValidationNotFound  = helpers.NewStatusError(http.StatusNotFound, ErrValidationNotFound)
+)
 
 type manager struct {
-	upsClient ups.Client
-	gpt       gpt.GPT
+	repository models.Repository
+	upsClient  ups.Client
+	gpt        gpt.GPT
+	prompts    *prompts.Registry
+	usage      usagemodels.Manager
+	events     eventmodels.Publisher
+	manifests  models.ManifestLookup
+	duplicates *DuplicateConfig
 }
 
 func NewManager(
+	repository models.Repository,
 	upsClient ups.Client,
 	gpt gpt.GPT,
+	prompts *prompts.Registry,
+	usage usagemodels.Manager,
+	events eventmodels.Publisher,
+	manifests models.ManifestLookup,
+	duplicates *DuplicateConfig,
 ) models.Manager {
 	return &manager{
-		upsClient: upsClient,
-		gpt:       gpt,
+		repository: repository,
+		upsClient:  upsClient,
+		gpt:        gpt,
+		prompts:    prompts,
+		usage:      usage,
+		events:     events,
+		manifests:  manifests,
+		duplicates: duplicates,
 	}
 }
 
-type promptResponse struct {
-	ups.Address
-	TrackingNumber string `json:"trackingNumber"`
-	Error          string `json:"error"`
+// Validate validates a label, publishing its lifecycle events to the station
+func (m *manager) Validate(ctx context.Context, input models.ValidationInput) (*models.ValidationResult, error) {
+	id := input.ID
+	if id.IsZero() {
+		id = bson.NewObjectID()
+	}
+	start := time.Now()
+	// tracking numbers are stored normalized so duplicate and manifest
+	// lookups match however the label was read
+	input.TrackingNumber = ups.NormalizeTrackingNumber(input.TrackingNumber)
+	ctx, span := tracing.Start(ctx, "shipping.Validate", trace.WithAttributes(
+		attribute.String("validation.id", id.Hex()),
+		attribute.String("station.id", input.StationID),
+		attribute.String("facility", input.Facility),
+	))
+	reporting.SetTag(ctx, "station", input.StationID)
+	m.publish(id, input, eventmodels.EventTypeReceived, nil, nil)
+
+	// validate fills in the tracking number when it is read from the label
+	result, err := m.validate(ctx, id, &input)
+	if err != nil {
+		tracing.End(span, err)
+		metrics.Validations.WithLabelValues(input.Facility, "error").Inc()
+		m.publish(id, input, eventmodels.EventTypeFailed, nil, err)
+		return nil, err
+	}
+
+	verdict := "valid"
+	if !result.Valid {
+		verdict = "invalid"
+	}
+	span.SetAttributes(attribute.String("validation.verdict", verdict))
+	metrics.Validations.WithLabelValues(input.Facility, verdict).Inc()
+	metrics.ValidationDuration.Observe(time.Since(start).Seconds())
+	if result.Duplicate != nil {
+		metrics.DuplicateScans.WithLabelValues(string(result.Duplicate.Kind)).Inc()
+	}
+
+	span.End()
+	m.publish(id, input, eventmodels.EventTypeVerdict, result, nil)
+	return result, nil
 }
 
-// TODO: Store validation results in the database
-func (m *manager) Validate(ctx context.Context, trackingNumber string, img image.Image) (*models.ValidationResult, error) {
+func (m *manager) publish(id bson.ObjectID, input models.ValidationInput, eventType eventmodels.EventType, result *models.ValidationResult, err error) {
+	event := eventmodels.Event{
+		Type:           eventType,
+		ValidationID:   id.Hex(),
+		StationID:      input.StationID,
+		Facility:       input.Facility,
+		TrackingNumber: input.TrackingNumber,
+		Time:           time.Now().UTC(),
+		Result:         result,
+	}
+	if err != nil {
+		_, response := helpers.ResolveError(err)
+		event.Error = &response
+	}
+
+	m.events.Publish(event)
+	if input.Progress != nil {
+		input.Progress(string(eventType), input.TrackingNumber)
+	}
+}
+
+func (m *manager) validate(ctx context.Context, id bson.ObjectID, input *models.ValidationInput) (*models.ValidationResult, error) {
+	started := time.Now()
+	_, encodeSpan := tracing.Start(ctx, "image.encode")
 	imageBytes := new(bytes.Buffer)
-	if err := jpeg.Encode(imageBytes, img, nil); err != nil {
+	err := jpeg.Encode(imageBytes, input.Image, nil)
+	tracing.End(encodeSpan, err)
+	if err != nil {
 		return nil, err
 	}
 
-	// Call LLM to read the address and tracking number from the image
-	result, err := m.gpt.Prompt(ctx, prompt, imageBytes.Bytes())
+	// Select the prompt, keeping retries of the same label on the same version
+	promptKey := input.TrackingNumber
+	if promptKey == "" {
+		promptKey = gpt.ImageHash(imageBytes.Bytes())
+	}
+	prompt, err := m.prompts.Select(promptKey, prompts.Vars{
+		Carrier: carrier,
+		Locale:  input.Locale,
+	})
 	if err != nil {
 		return nil, err
 	}
-	promptResp := promptResponse{}
-	if err := json.Unmarshal([]byte(result.Content), &promptResp); err != nil {
+
+	// Call LLM to read the address and tracking number from the image
+	m.publish(id, *input, eventmodels.EventTypeExtracting, nil, nil)
+	promptResp, gptResult, err := Extract(ctx, m.gpt, prompt.Text, imageBytes.Bytes())
+	if gptResult != nil {
+		if err := m.usage.Record(ctx, id.Hex(), input.StationID, prompt.Version, gptResult); err != nil {
+			slog.ErrorContext(ctx, "failed to record LLM usage", "error", err)
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	// Call UPS API to get the address for the tracking number
+	trackingNumber := input.TrackingNumber
 	if trackingNumber == "" {
-		trackingNumber = promptResp.TrackingNumber
+		trackingNumber = ups.NormalizeTrackingNumber(promptResp.TrackingNumber)
+		input.TrackingNumber = trackingNumber
 	}
-	trackingDetails, err := m.upsClient.GetTrackingDetails(trackingNumber)
+	reporting.SetTag(ctx, "tracking_number_hash", reporting.Hash(trackingNumber))
+	m.publish(id, *input, eventmodels.EventTypeLookingUp, nil, nil)
+	trackingDetails, err := m.upsClient.GetTrackingDetails(ctx, trackingNumber)
 	if err != nil {
 		return nil, err
 	}
 	expectedAddress := trackingDetails.GetPackageAddress(ups.PackageAddressTypeDestination)
 	if expectedAddress == nil {
-		return nil, errors.New("no address found for the tracking number")
+		return nil, helpers.NewStatusError(http.StatusUnprocessableEntity, errors.New("no address found for the tracking number"))
 	}
 
 	// Compare the address from the image with the address from the UPS API
-	return &models.ValidationResult{
+	mismatches := compareAddresses(promptResp.Address, expectedAddress.Address)
+	result := &models.ValidationResult{
 		ScannedAddress:         promptResp.Address,
 		ExpectedPackageAddress: *expectedAddress,
-		Valid:                  compareAddresses(promptResp.Address, expectedAddress.Address),
-	}, nil
+		Valid:                  len(mismatches) == 0,
+		Mismatches:             mismatches,
+		PromptVersion:          prompt.Version,
+	}
+
+	hash := perceptualHash(input.Image)
+	now := time.Now().UTC()
+	if policy := m.duplicates.Policy(input.Facility); !policy.Disabled {
+		previous, err := m.repository.ListValidations(ctx, models.ValidationFilter{
+			From:           now.Add(-policy.window),
+			Facility:       input.Facility,
+			TrackingNumber: trackingNumber,
+			Limit:          maxDuplicateHistory,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to check duplicates", "tracking_number", trackingNumber, "error", err)
+		} else {
+			result.Duplicate = findDuplicate(hash, policy.maxHashDistance, previous)
+		}
+	}
+
+	// A failure to store the validation should not fail the scan
+	validation := &models.Validation{
+		ID:             id,
+		CreatedAt:      now,
+		StationID:      input.StationID,
+		Facility:       input.Facility,
+		Username:       input.Username,
+		TrackingNumber: trackingNumber,
+		PerceptualHash: hash,
+		DurationMs:     now.Sub(started).Milliseconds(),
+		Result:         *result,
+		Valid:          result.Valid,
+	}
+	if manifestID, err := m.manifests.ActiveManifestID(ctx, input.Facility, input.StationID); err != nil {
+		slog.ErrorContext(ctx, "failed to find the active manifest", "station_id", input.StationID, "error", err)
+	} else {
+		validation.ManifestID = manifestID
+	}
+	if err := m.repository.CreateValidation(ctx, validation); err != nil {
+		slog.ErrorContext(ctx, "failed to store validation", "validation_id", id.Hex(), "error", err)
+	} else {
+		result.ID = validation.ID.Hex()
+	}
+
+	return result, nil
+}
+
+func (m *manager) GetValidation(ctx context.Context, id string, facility string) (*models.Validation, error) {
+	objectID, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errInvalidValidationID
+	}
+
+	validation, err := m.repository.GetValidation(ctx, objectID)
+	if errors.Is(err, ErrValidationNotFound) {
+		return nil, errValidationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// validations of other facilities are hidden rather than forbidden
+	if facility != "" && validation.Facility != facility {
+		return nil, errValidationNotFound
+	}
+
+	validation.Result.ID = validation.ID.Hex()
+	return validation, nil
 }
 
-func compareAddresses(address1, address2 ups.Address) bool {
-	return strings.EqualFold(address1.AddressLine1, address2.AddressLine1) &&
-		strings.EqualFold(address1.AddressLine2, address2.AddressLine2) &&
-		strings.EqualFold(address1.City, address2.City) &&
-		strings.EqualFold(address1.StateProvince, address2.StateProvince) &&
-		strings.EqualFold(address1.PostalCode, address2.PostalCode)
+func (m *manager) ListValidations(ctx context.Context, filter models.ValidationFilter) ([]models.Validation, error) {
+	if filter.ReasonCode != "" && !filter.ReasonCode.IsValid() {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("unknown reason code"))
+	}
+	filter.TrackingNumber = ups.NormalizeTrackingNumber(filter.TrackingNumber)
+
+	validations, err := m.repository.ListValidations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range validations {
+		validations[i].Result.ID = validations[i].ID.Hex()
+	}
+
+	return validations, nil
+}
+
+// Override flips the verdict of a flagged validation. The original verdict is
+// kept on the result and each validation can only be overridden once.
+func (m *manager) Override(ctx context.Context, id string, input models.OverrideInput) (*models.Validation, error) {
+	if !input.ReasonCode.IsValid() {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("unknown reason code"))
+	}
+	if input.ReasonCode == models.OverrideReasonOther && strings.TrimSpace(input.Reason) == "" {
+		return nil, helpers.NewStatusError(http.StatusBadRequest, errors.New("a reason is required for reason code OTHER"))
+	}
+
+	validation, err := m.GetValidation(ctx, id, input.Facility)
+	if err != nil {
+		return nil, err
+	}
+	if validation.Result.Valid {
+		return nil, helpers.NewStatusError(http.StatusConflict, errors.New("only invalid validations can be overridden"))
+	}
+
+	override := models.Override{
+		By:            input.Username,
+		At:            time.Now().UTC(),
+		ReasonCode:    input.ReasonCode,
+		Reason:        strings.TrimSpace(input.Reason),
+		OriginalValid: validation.Result.Valid,
+	}
+	validation, err = m.repository.SetOverride(ctx, validation.ID, override, !validation.Result.Valid)
+	if errors.Is(err, ErrAlreadyOverridden) {
+		return nil, helpers.NewStatusError(http.StatusConflict, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	validation.Result.ID = validation.ID.Hex()
+	return validation, nil
+}
+
+// ReprintLabel returns a corrected label for a validation built from the
+// current carrier data for its tracking number
+func (m *manager) ReprintLabel(ctx context.Context, id string, facility string) (*label.Label, error) {
+	validation, err := m.GetValidation(ctx, id, facility)
+	if err != nil {
+		return nil, err
+	}
+	if validation.TrackingNumber == "" {
+		return nil, helpers.NewStatusError(http.StatusUnprocessableEntity, errors.New("validation has no tracking number"))
+	}
+
+	trackingDetails, err := m.upsClient.GetTrackingDetails(ctx, validation.TrackingNumber)
+	if err != nil {
+		return nil, err
+	}
+	shipTo := trackingDetails.GetPackageAddress(ups.PackageAddressTypeDestination)
+	if shipTo == nil {
+		return nil, helpers.NewStatusError(http.StatusUnprocessableEntity, errors.New("no address found for the tracking number"))
+	}
+
+	reprint := &label.Label{
+		ShipTo:         *shipTo,
+		ShipFrom:       trackingDetails.GetPackageAddress(ups.PackageAddressTypeOrigin),
+		TrackingNumber: strings.ToUpper(validation.TrackingNumber),
+	}
+	if service := trackingDetails.GetService(); service != nil {
+		reprint.Service = service.Description
+	}
+
+	return reprint, nil
+}
+
+// compareAddresses returns the fields of the scanned address which differ from
+// the expected address
+func compareAddresses(scanned, expected ups.Address) []string {
+	fields := []struct {
+		name              string
+		scanned, expected string
+	}{
+		{"addressLine1", scanned.AddressLine1, expected.AddressLine1},
+		{"addressLine2", scanned.AddressLine2, expected.AddressLine2},
+		{"city", scanned.City, expected.City},
+		{"stateProvince", scanned.StateProvince, expected.StateProvince},
+		{"postalCode", scanned.PostalCode, expected.PostalCode},
+	}
+
+	mismatches := []string{}
+	for _, field := range fields {
+		if !strings.EqualFold(field.scanned, field.expected) {
+			mismatches = append(mismatches, field.name)
+		}
+	}
+
+	return mismatches
 }
@@ -0,0 +1,392 @@
+package shipping
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	eventmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/events/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	usagemodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/prompts"
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memRepository keeps validations and jobs in memory
+type memRepository struct {
+	mu          sync.Mutex
+	validations map[bson.ObjectID]models.Validation
+	jobs        map[bson.ObjectID]models.Job
+}
+
+func newMemRepository() *memRepository {
+	return &memRepository{
+		validations: map[bson.ObjectID]models.Validation{},
+		jobs:        map[bson.ObjectID]models.Job{},
+	}
+}
+
+func (r *memRepository) CreateValidation(_ context.Context, validation *models.Validation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validations[validation.ID] = *validation
+	return nil
+}
+
+func (r *memRepository) GetValidation(_ context.Context, id bson.ObjectID) (*models.Validation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	validation, ok := r.validations[id]
+	if !ok {
+		return nil, ErrValidationNotFound
+	}
+	return &validation, nil
+}
+
+func (r *memRepository) ListValidations(_ context.Context, filter models.ValidationFilter) ([]models.Validation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	validations := []models.Validation{}
+	for _, validation := range r.validations {
+		if filter.TrackingNumber != "" && validation.TrackingNumber != filter.TrackingNumber {
+			continue
+		}
+		if filter.Facility != "" && validation.Facility != filter.Facility {
+			continue
+		}
+		if validation.CreatedAt.Before(filter.From) {
+			continue
+		}
+		validations = append(validations, validation)
+	}
+	return validations, nil
+}
+
+func (r *memRepository) SetOverride(_ context.Context, id bson.ObjectID, override models.Override, valid bool) (*models.Validation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	validation, ok := r.validations[id]
+	if !ok || validation.Override != nil {
+		return nil, ErrAlreadyOverridden
+	}
+	validation.Override = &override
+	validation.Valid = valid
+	r.validations[id] = validation
+	return &validation, nil
+}
+
+func (r *memRepository) CreateJob(_ context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memRepository) GetJob(_ context.Context, id bson.ObjectID) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return &job, nil
+}
+
+func (r *memRepository) UpdateJob(_ context.Context, id bson.ObjectID, update models.JobUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || (update.IfStatus != "" && job.Status != update.IfStatus) {
+		return nil
+	}
+	if update.Status != "" {
+		job.Status = update.Status
+	}
+	if update.Stage != "" {
+		job.Stage = update.Stage
+	}
+	if update.TrackingNumber != "" {
+		job.TrackingNumber = update.TrackingNumber
+	}
+	if update.Result != nil {
+		job.Result = update.Result
+	}
+	if update.Error != nil {
+		job.Error = update.Error
+	}
+	if update.CallbackStatus != "" {
+		job.CallbackStatus = update.CallbackStatus
+	}
+	job.UpdatedAt = time.Now().UTC()
+	r.jobs[id] = job
+	return nil
+}
+
+func (r *memRepository) FailUnfinishedJobs(_ context.Context, message string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failed int64
+	for id, job := range r.jobs {
+		if job.Status == models.JobStatusQueued || job.Status == models.JobStatusRunning {
+			job.Status = models.JobStatusFailed
+			job.Error = &helpers.ErrorResponse{Error: message, Code: "job_interrupted"}
+			r.jobs[id] = job
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+func (r *memRepository) ManifestValidations(context.Context, string) ([]models.Validation, error) {
+	return nil, nil
+}
+
+// stubExtractor reads the same label from every image
+type stubExtractor struct {
+	extraction models.Extraction
+}
+
+func (s *stubExtractor) Prompt(context.Context, string, []byte) (*gpt.Result, error) {
+	content, err := json.Marshal(s.extraction)
+	if err != nil {
+		return nil, err
+	}
+	return &gpt.Result{Content: string(content), Provider: "stub"}, nil
+}
+
+func (s *stubExtractor) Ping(context.Context) error {
+	return nil
+}
+
+// stubUPS returns address as the destination of every tracking number and
+// keeps the tracking numbers it was asked for
+type stubUPS struct {
+	mu              sync.Mutex
+	address         ups.Address
+	trackingNumbers []string
+}
+
+func (s *stubUPS) GetTrackingDetails(_ context.Context, trackingNumber string) (*ups.TrackingDetails, error) {
+	s.mu.Lock()
+	s.trackingNumbers = append(s.trackingNumbers, trackingNumber)
+	s.mu.Unlock()
+
+	details := &ups.TrackingDetails{}
+	body, _ := json.Marshal(map[string]any{
+		"trackResponse": map[string]any{
+			"shipment": []any{map[string]any{
+				"package": []any{map[string]any{
+					"trackingNumber": trackingNumber,
+					"packageAddress": []any{map[string]any{"type": ups.PackageAddressTypeDestination, "address": s.address}},
+				}},
+			}},
+		},
+	})
+	if err := json.Unmarshal(body, details); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+func (s *stubUPS) CheckToken(context.Context) error {
+	return nil
+}
+
+type stubUsage struct{}
+
+func (stubUsage) Record(context.Context, string, string, string, *gpt.Result) error {
+	return nil
+}
+
+func (stubUsage) Summarize(context.Context, usagemodels.Filter) ([]usagemodels.Summary, error) {
+	return nil, nil
+}
+
+type stubPublisher struct{}
+
+func (stubPublisher) Publish(eventmodels.Event) {}
+
+type stubManifests struct{}
+
+func (stubManifests) ActiveManifestID(context.Context, string, string) (string, error) {
+	return "", nil
+}
+
+var testAddress = ups.Address{
+	AddressLine1:  "123 Main St",
+	City:          "Atlanta",
+	StateProvince: "GA",
+	PostalCode:    "30301",
+}
+
+// testLabelImage draws dark bars on a light background at the offsets, a
+// stand-in for the text and barcode of a label
+func testLabelImage(width, height int, brightness uint8, bars ...int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{brightness, brightness, brightness, 255})
+		}
+	}
+	for _, offset := range bars {
+		for y := range height {
+			for x := offset * width / 100; x < (offset+6)*width/100; x++ {
+				img.Set(x, y, color.RGBA{20, 20, 20, 255})
+			}
+		}
+	}
+	// a gradient keeps every row of cells distinct
+	for y := range height {
+		for x := range width / 10 {
+			img.Set(x, y, color.RGBA{uint8(y * 255 / height), 0, 0, 255})
+		}
+	}
+	return img
+}
+
+func newTestManager(t *testing.T, repository models.Repository, upsClient ups.Client, extraction models.Extraction) models.Manager {
+	t.Helper()
+	promptRegistry, err := prompts.NewRegistry("", "v1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicates, err := LoadDuplicateConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewManager(repository, upsClient, &stubExtractor{extraction: extraction}, promptRegistry, stubUsage{}, stubPublisher{}, stubManifests{}, duplicates)
+}
+
+func TestValidateNormalizesTrackingNumbers(t *testing.T) {
+	ctx := context.Background()
+	repository := newMemRepository()
+	upsClient := &stubUPS{address: testAddress}
+	m := newTestManager(t, repository, upsClient, models.Extraction{
+		Address:        testAddress,
+		TrackingNumber: " 1z999aa1 0123456784",
+	})
+
+	// the first scan reads the tracking number from the label and the
+	// rescan sends the barcode
+	first, err := m.Validate(ctx, models.ValidationInput{Facility: "ATL1", StationID: "dock-1", Image: testLabelImage(64, 64, 200, 3, 9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.Validate(ctx, models.ValidationInput{Facility: "ATL1", StationID: "dock-2", TrackingNumber: "1z999aa10123456784 ", Image: testLabelImage(64, 64, 200, 3, 9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, trackingNumber := range upsClient.trackingNumbers {
+		if trackingNumber != "1Z999AA10123456784" {
+			t.Errorf("UPS lookup of %q, want 1Z999AA10123456784", trackingNumber)
+		}
+	}
+	for _, validation := range repository.validations {
+		if validation.TrackingNumber != "1Z999AA10123456784" {
+			t.Errorf("stored tracking number %q, want 1Z999AA10123456784", validation.TrackingNumber)
+		}
+	}
+	if first.Duplicate != nil {
+		t.Errorf("first scan duplicate = %+v, want none", first.Duplicate)
+	}
+	if second.Duplicate == nil || second.Duplicate.Kind != models.DuplicateKindRescan || second.Duplicate.PreviousValidationID != first.ID {
+		t.Errorf("rescan duplicate = %+v, want a rescan of %s", second.Duplicate, first.ID)
+	}
+
+	found, err := m.ListValidations(ctx, models.ValidationFilter{TrackingNumber: "1z999aa10123456784"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Errorf("listed %d validations by a lower case tracking number, want 2", len(found))
+	}
+}
+
+func TestValidateChecksDuplicatesWithinFacility(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, newMemRepository(), &stubUPS{address: testAddress}, models.Extraction{
+		Address:        testAddress,
+		TrackingNumber: "1Z999AA10123456784",
+	})
+
+	scan := func(facility string) *models.ValidationResult {
+		t.Helper()
+		result, err := m.Validate(ctx, models.ValidationInput{Facility: facility, StationID: "dock-1", Image: testLabelImage(64, 64, 200, 3, 9)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	atlanta := scan("ATL1")
+	if saltLake := scan("SLC1"); saltLake.Duplicate != nil {
+		t.Errorf("SLC1 scan duplicate = %+v, want none from another facility", saltLake.Duplicate)
+	}
+	if rescan := scan("ATL1"); rescan.Duplicate == nil || rescan.Duplicate.PreviousValidationID != atlanta.ID {
+		t.Errorf("ATL1 rescan duplicate = %+v, want a duplicate of %s", rescan.Duplicate, atlanta.ID)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	ctx := context.Background()
+	repository := newMemRepository()
+	m := newTestManager(t, repository, &stubUPS{}, models.Extraction{})
+
+	invalid := models.Validation{ID: bson.NewObjectID(), Facility: "ATL1", Result: models.ValidationResult{Valid: false, Mismatches: []string{"city"}}}
+	valid := models.Validation{ID: bson.NewObjectID(), Facility: "ATL1", Result: models.ValidationResult{Valid: true}, Valid: true}
+	for _, validation := range []models.Validation{invalid, valid} {
+		repository.CreateValidation(ctx, &validation)
+	}
+
+	override := models.OverrideInput{Username: "supervisor", Facility: "ATL1", ReasonCode: models.OverrideReasonAddressMatches}
+	validation, err := m.Override(ctx, invalid.ID.Hex(), override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validation.Valid {
+		t.Error("effective verdict is still invalid")
+	}
+	if validation.Result.Valid {
+		t.Error("original verdict was changed")
+	}
+	if validation.Override == nil || validation.Override.OriginalValid || validation.Override.By != "supervisor" || validation.Override.ReasonCode != models.OverrideReasonAddressMatches {
+		t.Errorf("override = %+v", validation.Override)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		input      models.OverrideInput
+		wantStatus int
+	}{
+		{"repeat override", invalid.ID.Hex(), override, http.StatusConflict},
+		{"valid verdict", valid.ID.Hex(), override, http.StatusConflict},
+		{"other facility", invalid.ID.Hex(), models.OverrideInput{Facility: "SLC1", ReasonCode: models.OverrideReasonAddressMatches}, http.StatusNotFound},
+		{"unknown validation", bson.NewObjectID().Hex(), override, http.StatusNotFound},
+		{"invalid id", "not-an-id", override, http.StatusBadRequest},
+		{"unknown reason code", invalid.ID.Hex(), models.OverrideInput{Facility: "ATL1", ReasonCode: "BECAUSE"}, http.StatusBadRequest},
+		{"other without a reason", invalid.ID.Hex(), models.OverrideInput{Facility: "ATL1", ReasonCode: models.OverrideReasonOther, Reason: "  "}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Override(ctx, tt.id, tt.input)
+			if status, _ := helpers.ResolveError(err); status != tt.wantStatus {
+				t.Errorf("status = %d (%v), want %d", status, err, tt.wantStatus)
+			}
+		})
+	}
+
+	stored, _ := repository.GetValidation(ctx, invalid.ID)
+	if stored.Override.By != "supervisor" {
+		t.Errorf("override by %s, want the first override kept", stored.Override.By)
+	}
+}
@@ -3,16 +3,270 @@ package models
 import (
 	"context"
 	"image"
+	"time"
 
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/label"
 	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type Manager interface {
-	Validate(ctx context.Context, trackingNumber string, image image.Image) (*ValidationResult, error)
+	Validate(ctx context.Context, input ValidationInput) (*ValidationResult, error)
+	GetValidation(ctx context.Context, id string, facility string) (*Validation, error)
+	ListValidations(ctx context.Context, filter ValidationFilter) ([]Validation, error)
+	Override(ctx context.Context, id string, input OverrideInput) (*Validation, error)
+	ReprintLabel(ctx context.Context, id string, facility string) (*label.Label, error)
+}
+
+type Repository interface {
+	CreateValidation(ctx context.Context, validation *Validation) error
+	GetValidation(ctx context.Context, id bson.ObjectID) (*Validation, error)
+	ListValidations(ctx context.Context, filter ValidationFilter) ([]Validation, error)
+	// SetOverride records the override if the validation has not already been overridden
+	SetOverride(ctx context.Context, id bson.ObjectID, override Override, valid bool) (*Validation, error)
+	CreateJob(ctx context.Context, job *Job) error
+	GetJob(ctx context.Context, id bson.ObjectID) (*Job, error)
+	UpdateJob(ctx context.Context, id bson.ObjectID, update JobUpdate) error
+	// FailUnfinishedJobs fails the jobs left queued or running by a previous run
+	FailUnfinishedJobs(ctx context.Context, message string) (int64, error)
+	// ManifestValidations returns every validation of a manifest, oldest first
+	ManifestValidations(ctx context.Context, manifestID string) ([]Validation, error)
+}
+
+// ManifestLookup finds the manifest a facility's station is currently loading
+type ManifestLookup interface {
+	ActiveManifestID(ctx context.Context, facility string, stationID string) (string, error)
+}
+
+// JobManager runs validations in the background
+type JobManager interface {
+	Submit(ctx context.Context, input ValidationInput, callbackURL string) (*Job, error)
+	GetJob(ctx context.Context, id string, facility string) (*Job, error)
+	// Shutdown stops accepting jobs and waits for queued jobs and their
+	// callbacks to finish, or for ctx to end
+	Shutdown(ctx context.Context) error
+}
+
+// BatchManager validates many labels at once
+type BatchManager interface {
+	ValidateBatch(ctx context.Context, input BatchInput) (*BatchResult, error)
+	// MaxItems is the most items a batch may have, or 0 when unlimited
+	MaxItems() int
+}
+
+type ValidationInput struct {
+	// ID is the ID to store the validation with, generated when zero
+	ID             bson.ObjectID
+	StationID      string
+	Facility       string
+	Username       string
+	TrackingNumber string
+	Locale         string
+	Image          image.Image
+	// Progress is called with each lifecycle event type the validation
+	// reaches and the tracking number known at the time
+	Progress func(stage string, trackingNumber string)
 }
 
 type ValidationResult struct {
-	ScannedAddress         ups.Address        `json:"scannedAddress"`
-	ExpectedPackageAddress ups.PackageAddress `json:"expectedAddress"`
-	Valid                  bool               `json:"valid"`
+	ID                     string             `json:"id" bson:"-"`
+	ScannedAddress         ups.Address        `json:"scannedAddress" bson:"scannedAddress"`
+	ExpectedPackageAddress ups.PackageAddress `json:"expectedAddress" bson:"expectedAddress"`
+	Valid                  bool               `json:"valid" bson:"valid"`
+	// Mismatches lists the address fields which differ from the expected address
+	Mismatches    []string   `json:"mismatches,omitempty" bson:"mismatches,omitempty"`
+	PromptVersion string     `json:"promptVersion" bson:"promptVersion"`
+	Duplicate     *Duplicate `json:"duplicate,omitempty" bson:"duplicate,omitempty"`
 } // @name ValidationResult
+
+type DuplicateKind string // @name DuplicateKind
+
+const (
+	// DuplicateKindRescan is the same label image validated again
+	DuplicateKindRescan DuplicateKind = "RESCAN"
+	// DuplicateKindSecondBox is a different image of a label with the same
+	// tracking number, likely a second box carrying a copy of the label
+	DuplicateKindSecondBox DuplicateKind = "SECOND_BOX"
+)
+
+// Duplicate flags a tracking number validated before within the duplicate
+// window of the facility
+type Duplicate struct {
+	Kind DuplicateKind `json:"kind" bson:"kind"`
+	// PreviousScans counts the earlier validations within the window
+	PreviousScans int `json:"previousScans" bson:"previousScans"`
+	// Previous is the latest earlier validation of the kind
+	PreviousValidationID string    `json:"previousValidationId" bson:"previousValidationId"`
+	PreviousStationID    string    `json:"previousStationId" bson:"previousStationId"`
+	PreviousScannedAt    time.Time `json:"previousScannedAt" bson:"previousScannedAt"`
+} // @name Duplicate
+
+// Validation is a stored validation. Valid is the effective verdict, which
+// differs from the original Result.Valid once overridden.
+type Validation struct {
+	ID             bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	CreatedAt      time.Time     `json:"createdAt" bson:"createdAt"`
+	StationID      string        `json:"stationId" bson:"stationId"`
+	Facility       string        `json:"facility" bson:"facility"`
+	Username       string        `json:"username" bson:"username"`
+	TrackingNumber string        `json:"trackingNumber" bson:"trackingNumber"`
+	// PerceptualHash is the dHash of the label image, used to tell rescans
+	// of a label from copies of it on other boxes
+	PerceptualHash string `json:"perceptualHash" bson:"perceptualHash"`
+	ManifestID     string `json:"manifestId,omitempty" bson:"manifestId,omitempty"`
+	// DurationMs is the time taken to reach the verdict
+	DurationMs int64            `json:"durationMs" bson:"durationMs"`
+	Result     ValidationResult `json:"result" bson:"result"`
+	Valid      bool             `json:"valid" bson:"valid"`
+	Override   *Override        `json:"override,omitempty" bson:"override,omitempty"`
+} // @name Validation
+
+type OverrideReasonCode string // @name OverrideReasonCode
+
+const (
+	OverrideReasonAddressMatches      OverrideReasonCode = "ADDRESS_MATCHES"
+	OverrideReasonCarrierDataOutdated OverrideReasonCode = "CARRIER_DATA_OUTDATED"
+	OverrideReasonLabelReprinted      OverrideReasonCode = "LABEL_REPRINTED"
+	OverrideReasonImageUnreadable     OverrideReasonCode = "IMAGE_UNREADABLE"
+	OverrideReasonOther               OverrideReasonCode = "OTHER"
+)
+
+func (c OverrideReasonCode) IsValid() bool {
+	switch c {
+	case OverrideReasonAddressMatches, OverrideReasonCarrierDataOutdated, OverrideReasonLabelReprinted,
+		OverrideReasonImageUnreadable, OverrideReasonOther:
+		return true
+	}
+
+	return false
+}
+
+type Override struct {
+	By            string             `json:"by" bson:"by"`
+	At            time.Time          `json:"at" bson:"at"`
+	ReasonCode    OverrideReasonCode `json:"reasonCode" bson:"reasonCode"`
+	Reason        string             `json:"reason" bson:"reason"`
+	OriginalValid bool               `json:"originalValid" bson:"originalValid"`
+} // @name Override
+
+type OverrideInput struct {
+	Username   string
+	Facility   string
+	ReasonCode OverrideReasonCode
+	Reason     string
+}
+
+type ValidationFilter struct {
+	From           time.Time
+	To             time.Time
+	Facility       string
+	StationID      string
+	Username       string
+	TrackingNumber string
+	Valid          *bool
+	Overridden     *bool
+	ReasonCode     OverrideReasonCode
+	Limit          int
+}
+
+type JobStatus string // @name ValidationJobStatus
+
+const (
+	JobStatusQueued    JobStatus = "QUEUED"
+	JobStatusRunning   JobStatus = "RUNNING"
+	JobStatusCompleted JobStatus = "COMPLETED"
+	JobStatusFailed    JobStatus = "FAILED"
+)
+
+type CallbackStatus string // @name CallbackStatus
+
+const (
+	CallbackStatusPending   CallbackStatus = "PENDING"
+	CallbackStatusDelivered CallbackStatus = "DELIVERED"
+	CallbackStatusFailed    CallbackStatus = "FAILED"
+)
+
+// Job is an asynchronous validation. The job ID is also the ID of the
+// validation it stores.
+type Job struct {
+	ID             bson.ObjectID          `json:"id" bson:"_id,omitempty"`
+	CreatedAt      time.Time              `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt" bson:"updatedAt"`
+	StationID      string                 `json:"stationId" bson:"stationId"`
+	Facility       string                 `json:"facility" bson:"facility"`
+	Username       string                 `json:"username" bson:"username"`
+	TrackingNumber string                 `json:"trackingNumber,omitempty" bson:"trackingNumber,omitempty"`
+	Status         JobStatus              `json:"status" bson:"status"`
+	Stage          string                 `json:"stage,omitempty" bson:"stage,omitempty"`
+	Result         *ValidationResult      `json:"result,omitempty" bson:"result,omitempty"`
+	Error          *helpers.ErrorResponse `json:"error,omitempty" bson:"error,omitempty"`
+	CallbackURL    string                 `json:"callbackUrl,omitempty" bson:"callbackUrl,omitempty"`
+	CallbackStatus CallbackStatus         `json:"callbackStatus,omitempty" bson:"callbackStatus,omitempty"`
+} // @name ValidationJob
+
+// JobUpdate sets the non-empty fields of a job
+type JobUpdate struct {
+	Status         JobStatus
+	Stage          string
+	TrackingNumber string
+	Result         *ValidationResult
+	Error          *helpers.ErrorResponse
+	CallbackStatus CallbackStatus
+	// IfStatus only applies the update while the job has this status
+	IfStatus JobStatus
+}
+
+type BatchItem struct {
+	// Name identifies the item in the results, e.g. its file name
+	Name           string
+	TrackingNumber string
+	// Image is a JPEG or PNG image
+	Image []byte
+}
+
+type BatchInput struct {
+	StationID string
+	Facility  string
+	Username  string
+	Locale    string
+	// Parallelism limits concurrent validations below the configured limit
+	Parallelism int
+	Items       []BatchItem
+}
+
+type BatchItemStatus string // @name BatchItemStatus
+
+const (
+	BatchItemStatusValid   BatchItemStatus = "VALID"
+	BatchItemStatusInvalid BatchItemStatus = "INVALID"
+	BatchItemStatusError   BatchItemStatus = "ERROR"
+)
+
+type BatchItemResult struct {
+	Index          int                    `json:"index"`
+	Name           string                 `json:"name,omitempty"`
+	TrackingNumber string                 `json:"trackingNumber,omitempty"`
+	Status         BatchItemStatus        `json:"status"`
+	Result         *ValidationResult      `json:"result,omitempty"`
+	Error          *helpers.ErrorResponse `json:"error,omitempty"`
+} // @name BatchItemResult
+
+type BatchSummary struct {
+	Total      int   `json:"total"`
+	Valid      int   `json:"valid"`
+	Invalid    int   `json:"invalid"`
+	Failed     int   `json:"failed"`
+	DurationMs int64 `json:"durationMs"`
+} // @name BatchSummary
+
+type BatchResult struct {
+	Items   []BatchItemResult `json:"items"`
+	Summary BatchSummary      `json:"summary"`
+} // @name BatchResult
+
+type Extraction struct {
+	ups.Address
+	TrackingNumber string `json:"trackingNumber"`
+	Error          string `json:"error"`
+}
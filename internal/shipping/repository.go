@@ -0,0 +1,240 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	validationsCollectionName = "validations"
+	jobsCollectionName        = "validation_jobs"
+	defaultListLimit          = 100
+	maxListLimit              = 1000
+)
+
+var (
+	ErrValidationNotFound = errors.New("validation not found")
+	ErrAlreadyOverridden  = errors.New("validation has already been overridden")
+	ErrJobNotFound        = errors.New("validation job not found")
+)
+
+type repository struct {
+	validations *mongo.Collection
+	jobs        *mongo.Collection
+}
+
+func NewRepository(ctx context.Context, db *mongo.Database) (models.Repository, error) {
+	validations := db.Collection(validationsCollectionName)
+	_, err := validations.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "facility", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "trackingNumber", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "override.at", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "manifestId", Value: 1}, {Key: "createdAt", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := db.Collection(jobsCollectionName)
+	_, err = jobs.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}}})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		validations: validations,
+		jobs:        jobs,
+	}, nil
+}
+
+func (r *repository) CreateValidation(ctx context.Context, validation *models.Validation) error {
+	result, err := r.validations.InsertOne(ctx, validation)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		validation.ID = id
+	}
+
+	return nil
+}
+
+func (r *repository) GetValidation(ctx context.Context, id bson.ObjectID) (*models.Validation, error) {
+	validation := models.Validation{}
+	err := r.validations.FindOne(ctx, bson.M{"_id": id}).Decode(&validation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrValidationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &validation, nil
+}
+
+func (r *repository) ListValidations(ctx context.Context, filter models.ValidationFilter) ([]models.Validation, error) {
+	query := ValidationQuery(filter)
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	cursor, err := r.validations.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	validations := []models.Validation{}
+	if err := cursor.All(ctx, &validations); err != nil {
+		return nil, err
+	}
+
+	return validations, nil
+}
+
+func (r *repository) SetOverride(ctx context.Context, id bson.ObjectID, override models.Override, valid bool) (*models.Validation, error) {
+	validation := models.Validation{}
+	err := r.validations.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "override": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"override": override, "valid": valid}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&validation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAlreadyOverridden
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &validation, nil
+}
+
+func (r *repository) ManifestValidations(ctx context.Context, manifestID string) ([]models.Validation, error) {
+	cursor, err := r.validations.Find(ctx, bson.M{"manifestId": manifestID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	validations := []models.Validation{}
+	if err := cursor.All(ctx, &validations); err != nil {
+		return nil, err
+	}
+
+	return validations, nil
+}
+
+func (r *repository) CreateJob(ctx context.Context, job *models.Job) error {
+	_, err := r.jobs.InsertOne(ctx, job)
+	return err
+}
+
+func (r *repository) GetJob(ctx context.Context, id bson.ObjectID) (*models.Job, error) {
+	job := models.Job{}
+	err := r.jobs.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *repository) UpdateJob(ctx context.Context, id bson.ObjectID, update models.JobUpdate) error {
+	set := bson.M{"updatedAt": time.Now().UTC()}
+	if update.Status != "" {
+		set["status"] = update.Status
+	}
+	if update.Stage != "" {
+		set["stage"] = update.Stage
+	}
+	if update.TrackingNumber != "" {
+		set["trackingNumber"] = update.TrackingNumber
+	}
+	if update.Result != nil {
+		set["result"] = update.Result
+	}
+	if update.Error != nil {
+		set["error"] = update.Error
+	}
+	if update.CallbackStatus != "" {
+		set["callbackStatus"] = update.CallbackStatus
+	}
+
+	filter := bson.M{"_id": id}
+	if update.IfStatus != "" {
+		filter["status"] = update.IfStatus
+	}
+
+	_, err := r.jobs.UpdateOne(ctx, filter, bson.M{"$set": set})
+	return err
+}
+
+func (r *repository) FailUnfinishedJobs(ctx context.Context, message string) (int64, error) {
+	result, err := r.jobs.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": []models.JobStatus{models.JobStatusQueued, models.JobStatusRunning}}},
+		bson.M{"$set": bson.M{
+			"status":    models.JobStatusFailed,
+			"error":     helpers.ErrorResponse{Error: message, Code: "job_interrupted"},
+			"updatedAt": time.Now().UTC(),
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// ValidationQuery returns the query matching the validations of the filter,
+// without its limit
+func ValidationQuery(filter models.ValidationFilter) bson.M {
+	query := bson.M{}
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+	if filter.Facility != "" {
+		query["facility"] = filter.Facility
+	}
+	if filter.StationID != "" {
+		query["stationId"] = filter.StationID
+	}
+	if filter.Username != "" {
+		query["username"] = filter.Username
+	}
+	if filter.TrackingNumber != "" {
+		query["trackingNumber"] = filter.TrackingNumber
+	}
+	if filter.Valid != nil {
+		query["valid"] = *filter.Valid
+	}
+	if filter.Overridden != nil {
+		query["override"] = bson.M{"$exists": *filter.Overridden}
+	}
+	if filter.ReasonCode != "" {
+		query["override.reasonCode"] = filter.ReasonCode
+	}
+
+	return query
+}
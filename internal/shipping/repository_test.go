@@ -0,0 +1,38 @@
+package shipping
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestValidationQuery(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	valid := false
+	overridden := true
+
+	tests := []struct {
+		name   string
+		filter models.ValidationFilter
+		want   bson.M
+	}{
+		{"empty", models.ValidationFilter{Limit: 10}, bson.M{}},
+		{"from", models.ValidationFilter{From: from}, bson.M{"createdAt": bson.M{"$gte": from}}},
+		{"range", models.ValidationFilter{From: from, To: to}, bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}}},
+		{"scope", models.ValidationFilter{Facility: "ATL1", StationID: "station-1", Username: "operator"}, bson.M{"facility": "ATL1", "stationId": "station-1", "username": "operator"}},
+		{"tracking number", models.ValidationFilter{TrackingNumber: "1Z999AA10123456784"}, bson.M{"trackingNumber": "1Z999AA10123456784"}},
+		{"verdict", models.ValidationFilter{Valid: &valid, Overridden: &overridden}, bson.M{"valid": false, "override": bson.M{"$exists": true}}},
+		{"reason code", models.ValidationFilter{ReasonCode: models.OverrideReasonOther}, bson.M{"override.reasonCode": models.OverrideReasonOther}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidationQuery(tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidationQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
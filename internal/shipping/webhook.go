@@ -0,0 +1,153 @@
+package shipping
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+)
+
+const (
+	webhookTimeout         = 10 * time.Second
+	webhookDeliveryTimeout = 5 * time.Minute
+	signatureHeader        = "X-Signature-256"
+)
+
+// errBlockedAddress is returned when a callback resolves to an address inside
+// the network, so callbacks cannot be used to reach internal services
+var errBlockedAddress = errors.New("callback address is not public")
+
+type webhook struct {
+	secret     []byte
+	httpClient *http.Client
+	retry      retry.Policy
+}
+
+// NewWebhook returns a webhook posting finished jobs to their callback URL.
+// When secret is set each body is signed with HMAC-SHA256 in the
+// X-Signature-256 header as sha256=<hex>.
+func NewWebhook(secret string) *webhook {
+	policy := retry.DefaultPolicy()
+	policy.MaxAttempts = 5
+	policy.BaseDelay = time.Second
+	policy.MaxDelay = time.Minute
+
+	return &webhook{
+		secret:     []byte(secret),
+		httpClient: newWebhookClient(publicAddressesOnly),
+		retry:      policy,
+	}
+}
+
+// newWebhookClient returns a client which checks every dialed address with
+// control and does not follow redirects
+func newWebhookClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialed instead of the callback host
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   webhookTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}).DialContext
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddressesOnly rejects dials to addresses which are not publicly
+// routable. It runs after name resolution so it also covers hosts which
+// resolve to internal addresses.
+func publicAddressesOnly(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Deliver posts the job to its callback URL, retrying failed deliveries.
+// Receivers should use the job ID to ignore repeated deliveries.
+func (w *webhook) Deliver(callbackURL string, job *models.Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookDeliveryTimeout)
+	defer cancel()
+
+	_, err = w.retry.Do(ctx, "validation job callback", func(ctx context.Context) error {
+		return w.post(ctx, callbackURL, job.ID.Hex(), body)
+	})
+
+	return err
+}
+
+func (w *webhook) post(ctx context.Context, callbackURL string, jobID string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Job-ID", jobID)
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body)
+		req.Header.Set(signatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := w.httpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, errBlockedAddress) {
+			return err
+		}
+		// deliveries are idempotent by job ID so every failure is retried
+		if retry.ShouldRetryError(err, true) {
+			return retry.Retryable(err, 0)
+		}
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// redirects are not followed so they fail like other statuses
+		err := fmt.Errorf("callback responded with status %d", res.StatusCode)
+		if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout {
+			return retry.Retryable(err, retry.ParseRetryAfter(res.Header.Get("Retry-After")))
+		}
+		return err
+	}
+
+	return nil
+}
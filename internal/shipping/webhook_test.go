@@ -0,0 +1,134 @@
+package shipping
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCallbackURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://example.com/callback", false},
+		{"http://93.184.215.14:8080/callback", false},
+		{"ftp://example.com/callback", true},
+		{"/callback", true},
+		{"http://localhost/callback", true},
+		{"http://127.0.0.1/callback", true},
+		{"http://[::1]:8080/callback", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://10.0.0.5/callback", true},
+	}
+	for _, tt := range tests {
+		if err := validateCallbackURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("validateCallbackURL(%s) err = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func newTestWebhook(client *http.Client) *webhook {
+	return &webhook{
+		httpClient: client,
+		retry:      retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	}
+}
+
+func TestWebhookRejectsInternalAddresses(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	// the server listens on loopback, which callbacks must not reach
+	w := newTestWebhook(newWebhookClient(publicAddressesOnly))
+	err := w.Deliver(server.URL, &models.Job{ID: bson.NewObjectID()})
+	if !errors.Is(err, errBlockedAddress) {
+		t.Fatalf("err = %v, want %v", err, errBlockedAddress)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("server received %d requests, want 0", calls.Load())
+	}
+}
+
+func TestWebhookDoesNotFollowRedirects(t *testing.T) {
+	var redirected atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		redirected.Add(1)
+	}))
+	defer target.Close()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	w := newTestWebhook(newWebhookClient(nil))
+	if err := w.Deliver(server.URL, &models.Job{ID: bson.NewObjectID()}); err == nil {
+		t.Fatal("expected an error for a redirect")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("callback received %d requests, want 1", calls.Load())
+	}
+	if redirected.Load() != 0 {
+		t.Errorf("redirect target received %d requests, want 0", redirected.Load())
+	}
+}
+
+func TestWebhookSignsBody(t *testing.T) {
+	signature := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature <- r.Header.Get(signatureHeader)
+	}))
+	defer server.Close()
+
+	w := newTestWebhook(newWebhookClient(nil))
+	w.secret = []byte("secret")
+	if err := w.Deliver(server.URL, &models.Job{ID: bson.NewObjectID()}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-signature; len(got) != len("sha256=")+64 {
+		t.Errorf("signature = %q, want sha256=<hex>", got)
+	}
+}
@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+	"github.com/gin-gonic/gin"
+)
+
+const dateFormat = "2006-01-02"
+
+type handler struct {
+	manager models.Manager
+}
+
+func NewHandler(manager models.Manager) *handler {
+	return &handler{manager: manager}
+}
+
+func (h *handler) RegisterRoutes(router *gin.RouterGroup) {
+	admin := router.Group("", auth.RequireRole(authmodels.RoleAdmin))
+	admin.GET("/usage", h.getUsage)
+}
+
+type UsageRequest struct {
+	From      string `form:"from"`
+	To        string `form:"to"`
+	Provider  string `form:"provider"`
+	StationID string `form:"stationId"`
+}
+
+type UsageResponse struct {
+	Summaries []models.Summary `json:"summaries"`
+	Total     models.Summary   `json:"total"`
+} // @name UsageResponse
+
+// getUsage godoc
+//
+//	@Summary		Get LLM usage
+//	@Description	get LLM token usage and cost grouped by day, provider and station
+//	@Tags			admin
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			from		query		string	false	"First day to include (YYYY-MM-DD)"
+//	@Param			to			query		string	false	"Last day to include (YYYY-MM-DD)"
+//	@Param			provider	query		string	false	"GPT provider"
+//	@Param			stationId	query		string	false	"Station ID"
+//	@Success		200			{object}	UsageResponse
+//	@Failure		400,401,403	{object}	helpers.ErrorResponse
+//	@Failure		500			{object}	helpers.ErrorResponse
+//	@Router			/admin/usage [get]
+func (h *handler) getUsage(c *gin.Context) {
+	request := UsageRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, err))
+		return
+	}
+
+	filter := models.Filter{
+		Provider:  request.Provider,
+		StationID: request.StationID,
+	}
+	if request.From != "" {
+		from, err := time.Parse(dateFormat, request.From)
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("from must be formatted as YYYY-MM-DD")))
+			return
+		}
+		filter.From = from
+	}
+	if request.To != "" {
+		to, err := time.Parse(dateFormat, request.To)
+		if err != nil {
+			helpers.HandleError(c, helpers.NewStatusError(http.StatusBadRequest, errors.New("to must be formatted as YYYY-MM-DD")))
+			return
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	summaries, err := h.manager.Summarize(c, filter)
+	if err != nil {
+		helpers.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, UsageResponse{
+		Summaries: summaries,
+		Total:     sumSummaries(summaries),
+	})
+}
+
+// sumSummaries adds up the requests, usage and cost of every summary
+func sumSummaries(summaries []models.Summary) models.Summary {
+	total := models.Summary{}
+	for _, summary := range summaries {
+		total.Requests += summary.Requests
+		total.CachedRequests += summary.CachedRequests
+		total.Usage = addUsage(total.Usage, summary.Usage)
+		total.Cost += summary.Cost
+	}
+
+	return total
+}
+
+func addUsage(a, b gpt.Usage) gpt.Usage {
+	return gpt.Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		CachedTokens:     a.CachedTokens + b.CachedTokens,
+		ReasoningTokens:  a.ReasoningTokens + b.ReasoningTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
+}
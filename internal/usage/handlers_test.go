@@ -0,0 +1,103 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+	"github.com/gin-gonic/gin"
+)
+
+// stubManager returns summaries and keeps the last filter it was asked for
+type stubManager struct {
+	summaries []models.Summary
+	filter    models.Filter
+}
+
+func (s *stubManager) Record(context.Context, string, string, string, *gpt.Result) error {
+	return nil
+}
+
+func (s *stubManager) Summarize(_ context.Context, filter models.Filter) ([]models.Summary, error) {
+	s.filter = filter
+	return s.summaries, nil
+}
+
+func newTestRouter(manager models.Manager, roles ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		auth.SetClaims(c, &authmodels.Claims{Roles: roles})
+	})
+	NewHandler(manager).RegisterRoutes(router.Group("/admin"))
+	return router
+}
+
+func TestGetUsage(t *testing.T) {
+	manager := &stubManager{summaries: []models.Summary{
+		{Day: "2026-10-01", Provider: "openai", StationID: "station-1", Requests: 3, CachedRequests: 1, Usage: gpt.Usage{PromptTokens: 300, CompletionTokens: 30, TotalTokens: 330}, Cost: 0.25},
+		{Day: "2026-10-01", Provider: "gemini", StationID: "station-2", Requests: 2, Usage: gpt.Usage{PromptTokens: 200, CompletionTokens: 60, ReasoningTokens: 40, CachedTokens: 100, TotalTokens: 260}, Cost: 0.5},
+	}}
+	router := newTestRouter(manager, authmodels.RoleAdmin)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/usage?from=2026-10-01&to=2026-10-02&provider=openai&stationId=station-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	wantFilter := models.Filter{
+		From:      time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+		Provider:  "openai",
+		StationID: "station-1",
+	}
+	if manager.filter != wantFilter {
+		t.Errorf("filter = %+v, want %+v so the to day is included", manager.filter, wantFilter)
+	}
+
+	response := UsageResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Summaries) != 2 {
+		t.Errorf("summaries = %d, want 2", len(response.Summaries))
+	}
+	wantUsage := gpt.Usage{PromptTokens: 500, CompletionTokens: 90, ReasoningTokens: 40, CachedTokens: 100, TotalTokens: 590}
+	total := response.Total
+	if total.Requests != 5 || total.CachedRequests != 1 || total.Usage != wantUsage || math.Abs(total.Cost-0.75) > 1e-9 {
+		t.Errorf("total = %+v", total)
+	}
+}
+
+func TestGetUsageRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []string
+		query      string
+		wantStatus int
+	}{
+		{"supervisor", []string{authmodels.RoleSupervisor}, "", http.StatusForbidden},
+		{"operator", []string{authmodels.RoleOperator}, "", http.StatusForbidden},
+		{"invalid from", []string{authmodels.RoleAdmin}, "?from=10/01/2026", http.StatusBadRequest},
+		{"invalid to", []string{authmodels.RoleAdmin}, "?to=yesterday", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&stubManager{}, tt.roles...)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/usage"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
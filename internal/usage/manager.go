@@ -0,0 +1,43 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+)
+
+type manager struct {
+	repository models.Repository
+	prices     gpt.PriceTable
+}
+
+func NewManager(
+	repository models.Repository,
+	prices gpt.PriceTable,
+) models.Manager {
+	return &manager{
+		repository: repository,
+		prices:     prices,
+	}
+}
+
+func (m *manager) Record(ctx context.Context, validationID string, stationID string, promptVersion string, result *gpt.Result) error {
+	return m.repository.Create(ctx, &models.Record{
+		CreatedAt:     time.Now().UTC(),
+		ValidationID:  validationID,
+		Provider:      result.Provider,
+		Model:         result.Model,
+		StationID:     stationID,
+		PromptVersion: promptVersion,
+		LatencyMs:     result.Latency.Milliseconds(),
+		Cached:        result.Cached,
+		Usage:         result.Usage,
+		Cost:          m.prices.Cost(result.Model, result.Usage),
+	})
+}
+
+func (m *manager) Summarize(ctx context.Context, filter models.Filter) ([]models.Summary, error) {
+	return m.repository.Summarize(ctx, filter)
+}
@@ -0,0 +1,57 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+)
+
+type stubRepository struct {
+	records []*models.Record
+}
+
+func (s *stubRepository) Create(_ context.Context, record *models.Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *stubRepository) Summarize(context.Context, models.Filter) ([]models.Summary, error) {
+	return nil, nil
+}
+
+func TestRecord(t *testing.T) {
+	repository := &stubRepository{}
+	prices := gpt.PriceTable{"gpt-4o": {Prompt: 2.50, CachedPrompt: 1.25, Completion: 10}}
+	m := NewManager(repository, prices)
+
+	result := &gpt.Result{
+		Provider: "openai",
+		Model:    "gpt-4o-2024-08-06",
+		Latency:  1500 * time.Millisecond,
+		Cached:   true,
+		Usage:    gpt.Usage{PromptTokens: 2000, CachedTokens: 1000, CompletionTokens: 100, TotalTokens: 2100},
+	}
+	if err := m.Record(context.Background(), "validation-1", "station-1", "v1.ups", result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repository.records) != 1 {
+		t.Fatalf("records = %d, want 1", len(repository.records))
+	}
+	record := repository.records[0]
+	if record.ValidationID != "validation-1" || record.StationID != "station-1" || record.PromptVersion != "v1.ups" {
+		t.Errorf("record = %+v", record)
+	}
+	if record.Provider != "openai" || record.Model != "gpt-4o-2024-08-06" || !record.Cached || record.LatencyMs != 1500 {
+		t.Errorf("record = %+v", record)
+	}
+	// 1000 uncached and 1000 cached prompt tokens and 100 completion tokens
+	wantCost := (1000*2.50 + 1000*1.25 + 100*10) / 1e6
+	if math.Abs(record.Cost-wantCost) > 1e-12 {
+		t.Errorf("cost = %v, want %v", record.Cost, wantCost)
+	}
+}
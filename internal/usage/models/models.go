@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Manager interface {
+	Record(ctx context.Context, validationID string, stationID string, promptVersion string, result *gpt.Result) error
+	Summarize(ctx context.Context, filter Filter) ([]Summary, error)
+}
+
+type Repository interface {
+	Create(ctx context.Context, record *Record) error
+	Summarize(ctx context.Context, filter Filter) ([]Summary, error)
+}
+
+type Record struct {
+	ID            bson.ObjectID `bson:"_id,omitempty"`
+	CreatedAt     time.Time     `bson:"createdAt"`
+	ValidationID  string        `bson:"validationId"`
+	Provider      string        `bson:"provider"`
+	Model         string        `bson:"model"`
+	StationID     string        `bson:"stationId"`
+	PromptVersion string        `bson:"promptVersion"`
+	LatencyMs     int64         `bson:"latencyMs"`
+	Cached        bool          `bson:"cached"`
+	Usage         gpt.Usage     `bson:"usage"`
+	Cost          float64       `bson:"cost"`
+}
+
+type Filter struct {
+	From      time.Time
+	To        time.Time
+	Provider  string
+	StationID string
+}
+
+type Summary struct {
+	Day            string    `json:"day" bson:"day"`
+	Provider       string    `json:"provider" bson:"provider"`
+	StationID      string    `json:"stationId" bson:"stationId"`
+	Requests       int       `json:"requests" bson:"requests"`
+	CachedRequests int       `json:"cachedRequests" bson:"cachedRequests"`
+	Usage          gpt.Usage `json:"usage" bson:"usage"`
+	Cost           float64   `json:"cost" bson:"cost"`
+} // @name UsageSummary
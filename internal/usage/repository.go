@@ -0,0 +1,120 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const collectionName = "llm_usage"
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository(ctx context.Context, db *mongo.Database) (models.Repository, error) {
+	collection := db.Collection(collectionName)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "validationId", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		collection: collection,
+	}, nil
+}
+
+func (r *repository) Create(ctx context.Context, record *models.Record) error {
+	result, err := r.collection.InsertOne(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		record.ID = id
+	}
+
+	return nil
+}
+
+func (r *repository) Summarize(ctx context.Context, filter models.Filter) ([]models.Summary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: summaryQuery(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"day":       bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$createdAt"}},
+				"provider":  "$provider",
+				"stationId": "$stationId",
+			},
+			"requests":         bson.M{"$sum": 1},
+			"cachedRequests":   bson.M{"$sum": bson.M{"$cond": bson.A{"$cached", 1, 0}}},
+			"promptTokens":     bson.M{"$sum": "$usage.promptTokens"},
+			"completionTokens": bson.M{"$sum": "$usage.completionTokens"},
+			"cachedTokens":     bson.M{"$sum": "$usage.cachedTokens"},
+			"reasoningTokens":  bson.M{"$sum": "$usage.reasoningTokens"},
+			"totalTokens":      bson.M{"$sum": "$usage.totalTokens"},
+			"cost":             bson.M{"$sum": "$cost"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":            0,
+			"day":            "$_id.day",
+			"provider":       "$_id.provider",
+			"stationId":      "$_id.stationId",
+			"requests":       1,
+			"cachedRequests": 1,
+			"usage": bson.M{
+				"promptTokens":     "$promptTokens",
+				"completionTokens": "$completionTokens",
+				"cachedTokens":     "$cachedTokens",
+				"reasoningTokens":  "$reasoningTokens",
+				"totalTokens":      "$totalTokens",
+			},
+			"cost": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: "day", Value: 1},
+			{Key: "provider", Value: 1},
+			{Key: "stationId", Value: 1},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []models.Summary{}
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// summaryQuery returns the match stage of the usage records in filter
+func summaryQuery(filter models.Filter) bson.M {
+	match := bson.M{}
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To
+	}
+	if len(createdAt) > 0 {
+		match["createdAt"] = createdAt
+	}
+	if filter.Provider != "" {
+		match["provider"] = filter.Provider
+	}
+	if filter.StationID != "" {
+		match["stationId"] = filter.StationID
+	}
+
+	return match
+}
@@ -0,0 +1,34 @@
+package usage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/usage/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSummaryQuery(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name   string
+		filter models.Filter
+		want   bson.M
+	}{
+		{"empty", models.Filter{}, bson.M{}},
+		{"from", models.Filter{From: from}, bson.M{"createdAt": bson.M{"$gte": from}}},
+		{"to", models.Filter{To: to}, bson.M{"createdAt": bson.M{"$lt": to}}},
+		{"range", models.Filter{From: from, To: to}, bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}}},
+		{"provider and station", models.Filter{Provider: "gemini", StationID: "station-1"}, bson.M{"provider": "gemini", "stationId": "station-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summaryQuery(tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summaryQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
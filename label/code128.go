@@ -0,0 +1,101 @@
+package label
+
+import (
+	"fmt"
+)
+
+// code128Patterns are the bar and space widths of each Code 128 symbol value.
+// Values 103, 104 and 105 are the start codes for code sets A, B and C and 106
+// is the stop pattern.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// code128 encodes printable ASCII as Code 128 using code set B, switching to
+// code set C for runs of digits long enough to shorten the barcode. It returns
+// the module widths of alternating bars and spaces, starting with a bar.
+func code128(data string) ([]int, error) {
+	for _, r := range data {
+		if r < ' ' || r > '~' {
+			return nil, fmt.Errorf("code 128: unsupported character %q", r)
+		}
+	}
+	if data == "" {
+		return nil, fmt.Errorf("code 128: no data")
+	}
+
+	values := []int{}
+	codeC := digitRun(data, 0) >= 4 && digitRun(data, 0)%2 == 0
+	if codeC {
+		values = append(values, code128StartC)
+	} else {
+		values = append(values, code128StartB)
+	}
+
+	for i := 0; i < len(data); {
+		run := digitRun(data, i)
+		switch {
+		case codeC && run >= 2:
+			values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+			i += 2
+			continue
+		case codeC:
+			values = append(values, code128CodeB)
+			codeC = false
+		case run >= 6 || (run >= 4 && i+run == len(data)):
+			// an odd run keeps its first digit in code set B
+			if run%2 == 1 {
+				values = append(values, int(data[i]-' '))
+				i++
+			}
+			values = append(values, code128CodeC)
+			codeC = true
+			continue
+		}
+
+		values = append(values, int(data[i]-' '))
+		i++
+	}
+
+	checksum := values[0]
+	for i, value := range values[1:] {
+		checksum += (i + 1) * value
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	modules := []int{}
+	for _, value := range values {
+		for _, width := range code128Patterns[value] {
+			modules = append(modules, int(width-'0'))
+		}
+	}
+
+	return modules, nil
+}
+
+func digitRun(data string, start int) int {
+	run := 0
+	for i := start; i < len(data) && data[i] >= '0' && data[i] <= '9'; i++ {
+		run++
+	}
+
+	return run
+}
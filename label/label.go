@@ -0,0 +1,71 @@
+package label
+
+import (
+	"strings"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+)
+
+// Labels are 4x6 inch thermal labels
+const (
+	widthInches  = 4
+	heightInches = 6
+)
+
+type Label struct {
+	ShipTo         ups.PackageAddress
+	ShipFrom       *ups.PackageAddress
+	TrackingNumber string
+	Service        string
+}
+
+// FormatTrackingNumber groups a 1Z tracking number the way it is printed on
+// UPS labels, e.g. 1Z 999 AA1 01 2345 6784. Other tracking numbers are
+// returned unchanged.
+func FormatTrackingNumber(trackingNumber string) string {
+	if len(trackingNumber) != 18 || !strings.HasPrefix(strings.ToUpper(trackingNumber), "1Z") {
+		return trackingNumber
+	}
+
+	return strings.Join([]string{
+		trackingNumber[0:2],
+		trackingNumber[2:5],
+		trackingNumber[5:8],
+		trackingNumber[8:10],
+		trackingNumber[10:14],
+		trackingNumber[14:18],
+	}, " ")
+}
+
+// addressLines returns the printed lines of an address, skipping empty lines
+func addressLines(address ups.PackageAddress) []string {
+	cityLine := strings.TrimSpace(strings.Join(nonEmpty(address.Address.City, address.Address.StateProvince), " "))
+	if address.Address.PostalCode != "" {
+		cityLine = strings.TrimSpace(cityLine + " " + address.Address.PostalCode)
+	}
+
+	country := address.Address.Country
+	if country == "" {
+		country = address.Address.CountryCode
+	}
+
+	return nonEmpty(
+		address.Name,
+		address.AttentionName,
+		address.Address.AddressLine1,
+		address.Address.AddressLine2,
+		cityLine,
+		country,
+	)
+}
+
+func nonEmpty(values ...string) []string {
+	lines := []string{}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			lines = append(lines, value)
+		}
+	}
+
+	return lines
+}
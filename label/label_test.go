@@ -0,0 +1,149 @@
+package label
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
+)
+
+// decodeCode128 maps module widths back to symbol values
+func decodeCode128(t *testing.T, modules []int) []int {
+	t.Helper()
+	patterns := map[string]int{}
+	for value, pattern := range code128Patterns {
+		patterns[pattern] = value
+	}
+
+	values := []int{}
+	for i := 0; i < len(modules); {
+		size := 6
+		if len(modules)-i == 7 {
+			size = 7
+		}
+		pattern := ""
+		for _, width := range modules[i : i+size] {
+			pattern += string(rune('0' + width))
+		}
+		value, ok := patterns[pattern]
+		if !ok {
+			t.Fatalf("no symbol has pattern %s", pattern)
+		}
+		values = append(values, value)
+		i += size
+	}
+
+	return values
+}
+
+func TestCode128(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// want are the symbol values without the checksum and stop
+		want []int
+	}{
+		{"tracking number", "1Z999AA10123456784", []int{104, 17, 58, 25, 25, 25, 33, 33, 17, 99, 1, 23, 45, 67, 84}},
+		{"even digits", "123456", []int{105, 12, 34, 56}},
+		{"odd trailing digits", "12345", []int{104, 17, 99, 23, 45}},
+		{"trailing digits", "AB1234", []int{104, 33, 34, 99, 12, 34}},
+		{"leading digits", "1234AB", []int{105, 12, 34, 100, 33, 34}},
+		{"short digits", "12", []int{104, 17, 18}},
+		{"short inner digits", "A1234B", []int{104, 33, 17, 18, 19, 20, 34}},
+		{"punctuation", " ~", []int{104, 0, 94}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules, err := code128(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			values := decodeCode128(t, modules)
+			if values[len(values)-1] != code128Stop {
+				t.Fatalf("last symbol = %d, want stop", values[len(values)-1])
+			}
+			got := values[:len(values)-2]
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("values = %v, want %v", got, tt.want)
+			}
+
+			checksum := got[0]
+			for i, value := range got[1:] {
+				checksum += (i + 1) * value
+			}
+			if values[len(values)-2] != checksum%103 {
+				t.Errorf("checksum = %d, want %d", values[len(values)-2], checksum%103)
+			}
+
+			// each symbol is 11 modules wide and the stop pattern 13
+			width := 0
+			for _, module := range modules {
+				width += module
+			}
+			if want := 11*(len(values)-1) + 13; width != want {
+				t.Errorf("width = %d modules, want %d", width, want)
+			}
+		})
+	}
+}
+
+func TestCode128RejectsUnsupportedData(t *testing.T) {
+	for _, data := range []string{"", "1Z\n", "Zoë"} {
+		if _, err := code128(data); err == nil {
+			t.Errorf("code128(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestFormatTrackingNumber(t *testing.T) {
+	tests := []struct {
+		trackingNumber string
+		want           string
+	}{
+		{"1Z999AA10123456784", "1Z 999 AA1 01 2345 6784"},
+		{"1z999aa10123456784", "1z 999 aa1 01 2345 6784"},
+		{"1Z999AA1012345678", "1Z999AA1012345678"},
+		{"9400100000000000000000", "9400100000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := FormatTrackingNumber(tt.trackingNumber); got != tt.want {
+			t.Errorf("FormatTrackingNumber(%s) = %s, want %s", tt.trackingNumber, got, tt.want)
+		}
+	}
+}
+
+func testLabel() Label {
+	shipTo := ups.PackageAddress{Name: "Jane Doe"}
+	shipTo.Address = ups.Address{AddressLine1: "1 Main St", City: "Springfield", StateProvince: "IL", PostalCode: "62701", CountryCode: "US"}
+	return Label{ShipTo: shipTo, TrackingNumber: "1Z999AA10123456784", Service: "Ground"}
+}
+
+func TestZPL(t *testing.T) {
+	zpl, err := ZPL(testLabel())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"^XA", "^FDJane Doe^FS", "^FDSpringfield IL 62701^FS", "^FDGROUND^FS", "^FD1Z999AA10123456784^FS", "^XZ"} {
+		if !strings.Contains(string(zpl), want) {
+			t.Errorf("zpl is missing %q", want)
+		}
+	}
+
+	if _, err := ZPL(Label{}); err == nil {
+		t.Error("expected an error without a tracking number")
+	}
+}
+
+func TestPDF(t *testing.T) {
+	pdf, err := PDF(testLabel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.Contains(pdf, []byte("%%EOF")) {
+		t.Error("output is not a PDF document")
+	}
+}
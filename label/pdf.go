@@ -0,0 +1,138 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPointsPerInch = 72
+	// pdfModuleWidth is the width in points of the narrowest barcode bar
+	pdfModuleWidth = 1.2
+)
+
+// PDF renders the label as a single 4x6 inch page for laser printers. It uses
+// the standard Helvetica fonts so no fonts are embedded.
+func PDF(label Label) ([]byte, error) {
+	if label.TrackingNumber == "" {
+		return nil, fmt.Errorf("pdf: tracking number is required")
+	}
+
+	modules, err := code128(label.TrackingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	width := float64(widthInches * pdfPointsPerInch)
+	height := float64(heightInches * pdfPointsPerInch)
+
+	content := new(bytes.Buffer)
+	text := func(font string, size float64, x float64, y float64, value string) {
+		fmt.Fprintf(content, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(value))
+	}
+	rule := func(y float64) {
+		fmt.Fprintf(content, "0 %.1f %.1f 1.5 re f\n", y, width)
+	}
+
+	y := height - 24
+	if label.ShipFrom != nil {
+		for _, line := range addressLines(*label.ShipFrom) {
+			text("F1", 8, 12, y, line)
+			y -= 10
+		}
+	}
+
+	y = min(y-12, height-90)
+	text("F2", 10, 12, y, "SHIP TO:")
+	y -= 18
+	for _, line := range addressLines(label.ShipTo) {
+		text("F2", 14, 28, y, line)
+		y -= 17
+	}
+
+	y = min(y-8, height-220)
+	rule(y)
+	y -= 24
+	if label.Service != "" {
+		text("F2", 18, 12, y, strings.ToUpper(label.Service))
+		y -= 20
+	}
+	text("F1", 11, 12, y, "TRACKING #: "+FormatTrackingNumber(label.TrackingNumber))
+	y -= 12
+	rule(y)
+
+	// center the barcode below the tracking number
+	barcodeWidth := 0.0
+	for _, module := range modules {
+		barcodeWidth += float64(module) * pdfModuleWidth
+	}
+	barcodeHeight := 80.0
+	x := (width - barcodeWidth) / 2
+	y -= 16 + barcodeHeight
+	for i, module := range modules {
+		w := float64(module) * pdfModuleWidth
+		if i%2 == 0 {
+			fmt.Fprintf(content, "%.2f %.1f %.2f %.1f re f\n", x, y, w, barcodeHeight)
+		}
+		x += w
+	}
+
+	rule(28)
+	text("F1", 8, 12, 14, "REPRINTED LABEL")
+
+	return pdfDocument(width, height, content.Bytes()), nil
+}
+
+// pdfDocument writes a single page PDF with the given content stream
+func pdfDocument(width float64, height float64, content []byte) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>", width, height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	b := new(bytes.Buffer)
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return b.Bytes()
+}
+
+// pdfEscape escapes a string for a PDF literal string. Characters outside
+// Latin-1 cannot be shown with the standard fonts and are replaced.
+func pdfEscape(value string) string {
+	b := new(strings.Builder)
+	for _, r := range value {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ':
+			b.WriteByte(' ')
+		case r < 0x80:
+			b.WriteRune(r)
+		case r <= 0xff:
+			fmt.Fprintf(b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// zplDPI is the resolution of the thermal printers labels are rendered for
+const zplDPI = 203
+
+// ZPL renders the label as ZPL II for a 203 dpi thermal printer
+func ZPL(label Label) ([]byte, error) {
+	if label.TrackingNumber == "" {
+		return nil, fmt.Errorf("zpl: tracking number is required")
+	}
+
+	width := widthInches * zplDPI
+	height := heightInches * zplDPI
+
+	b := new(bytes.Buffer)
+	// ^CI28 selects UTF-8 so accented names and cities print correctly
+	fmt.Fprintf(b, "^XA\n^CI28\n^PW%d\n^LL%d\n^LH0,0\n", width, height)
+
+	y := 30
+	if label.ShipFrom != nil {
+		for _, line := range addressLines(*label.ShipFrom) {
+			fmt.Fprintf(b, "^FO30,%d^A0N,24,24^FD%s^FS\n", y, zplEscape(line))
+			y += 28
+		}
+	}
+
+	y = max(y+20, 220)
+	fmt.Fprintf(b, "^FO30,%d^A0N,28,28^FDSHIP TO:^FS\n", y)
+	y += 40
+	for _, line := range addressLines(label.ShipTo) {
+		fmt.Fprintf(b, "^FO60,%d^A0N,40,40^FD%s^FS\n", y, zplEscape(line))
+		y += 46
+	}
+
+	y = max(y+20, 600)
+	fmt.Fprintf(b, "^FO0,%d^GB%d,4,4^FS\n", y, width)
+	y += 20
+	if label.Service != "" {
+		fmt.Fprintf(b, "^FO30,%d^A0N,50,50^FD%s^FS\n", y, zplEscape(strings.ToUpper(label.Service)))
+		y += 60
+	}
+	fmt.Fprintf(b, "^FO30,%d^A0N,30,30^FDTRACKING #: %s^FS\n", y, zplEscape(FormatTrackingNumber(label.TrackingNumber)))
+	y += 40
+	fmt.Fprintf(b, "^FO0,%d^GB%d,4,4^FS\n", y, width)
+	y += 40
+
+	// ^BC in automatic mode picks the Code 128 code sets on the printer
+	fmt.Fprintf(b, "^FO60,%d^BY3^BCN,220,N,N,N,A^FD%s^FS\n", y, zplEscape(label.TrackingNumber))
+	fmt.Fprintf(b, "^FO0,%d^GB%d,4,4^FS\n", height-80, width)
+	fmt.Fprintf(b, "^FO30,%d^A0N,24,24^FDREPRINTED LABEL^FS\n", height-60)
+	b.WriteString("^XZ\n")
+
+	return b.Bytes(), nil
+}
+
+// zplEscape removes the characters that start ZPL commands from field data
+func zplEscape(value string) string {
+	return strings.NewReplacer("^", " ", "~", " ").Replace(value)
+}
@@ -0,0 +1,20 @@
+package logging
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New returns a JSON logger writing to w at the named level, redacting
+// addresses and tracking numbers
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); level != "" && err != nil {
+		return nil, errors.New("log level must be debug, info, warn or error")
+	}
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: l})
+	return slog.New(&redactingHandler{handler: handler}), nil
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery responds with a 500 when a handler panics and logs the panic with
+// its stack. Panics writing to a client which has gone are logged as warnings
+// without a response.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			ctx := c.Request.Context()
+			if err, ok := recovered.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				slog.WarnContext(ctx, "client connection closed", "route", c.FullPath(), "error", err)
+				c.Error(err)
+				c.Abort()
+				return
+			}
+
+			slog.ErrorContext(ctx, "panic serving request",
+				"route", c.FullPath(),
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()),
+			)
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecovery(t *testing.T) {
+	b := new(bytes.Buffer)
+	logger, err := New(b, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(), Recovery())
+	router.GET("/panic", func(*gin.Context) { panic("no label for 1Z999AA10123456784") })
+	router.GET("/closed", func(*gin.Context) { panic(fmt.Errorf("write: %w", syscall.EPIPE)) })
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantLevel  string
+		wantMsg    string
+	}{
+		{"panic", "/panic", http.StatusInternalServerError, "ERROR", "panic serving request"},
+		{"client gone", "/closed", http.StatusOK, "WARN", "client connection closed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b.Reset()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(RequestIDHeader, "request-1")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			// the panic is logged, then the request
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("log = %q, want the panic and the request", b.String())
+			}
+			record := map[string]any{}
+			if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+				t.Fatal(err)
+			}
+			if record["level"] != tt.wantLevel || record["msg"] != tt.wantMsg || record["request_id"] != "request-1" || record["route"] != tt.path {
+				t.Errorf("record = %v, want a %s %q record of the request", record, tt.wantLevel, tt.wantMsg)
+			}
+			if strings.Contains(b.String(), "1Z999AA10123456784") {
+				t.Errorf("log = %q, leaks the tracking number", b.String())
+			}
+		})
+	}
+
+	b.Reset()
+	record := map[string]any{}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	json.Unmarshal([]byte(strings.Split(b.String(), "\n")[0]), &record)
+	if stack, _ := record["stack"].(string); !strings.Contains(stack, "recovery_test.go") {
+		t.Errorf("stack = %q, want the panicking handler", stack)
+	}
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	upsTrackingNumber = regexp.MustCompile(`(?i)\b1Z[0-9A-Z]{12}([0-9A-Z]{4})\b`)
+	// addressFields matches names and address fields in JSON, such as upstream
+	// response bodies quoted in error messages
+	addressFields = regexp.MustCompile(`(?i)("(?:name|attentionName|address|addressLine\d|city|stateProvince|postalCode)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// sensitiveKeys are attribute keys whose values are always redacted, compared
+// in lower case without separators
+var sensitiveKeys = map[string]bool{
+	"name":            true,
+	"attentionname":   true,
+	"address":         true,
+	"addressline1":    true,
+	"addressline2":    true,
+	"addressline3":    true,
+	"street":          true,
+	"scannedaddress":  true,
+	"expectedaddress": true,
+}
+
+// Redact masks tracking numbers and JSON address fields in free text
+func Redact(s string) string {
+	s = upsTrackingNumber.ReplaceAllString(s, "1Z************$1")
+	return addressFields.ReplaceAllString(s, `$1"`+redacted+`"`)
+}
+
+// MaskTrackingNumber keeps the last four characters of a tracking number
+func MaskTrackingNumber(trackingNumber string) string {
+	if len(trackingNumber) <= 4 {
+		return strings.Repeat("*", len(trackingNumber))
+	}
+
+	return strings.Repeat("*", len(trackingNumber)-4) + trackingNumber[len(trackingNumber)-4:]
+}
+
+// redactingHandler redacts records before passing them on and adds the
+// request and trace IDs of the record's context
+type redactingHandler struct {
+	handler slog.Handler
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redactedRecord := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redactedRecord.AddAttrs(redactAttr(attr))
+		return true
+	})
+
+	if id := RequestID(ctx); id != "" {
+		redactedRecord.AddAttrs(slog.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		redactedRecord.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	return h.handler.Handle(ctx, redactedRecord)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redactedAttrs[i] = redactAttr(attr)
+	}
+
+	return &redactingHandler{handler: h.handler.WithAttrs(redactedAttrs)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{handler: h.handler.WithGroup(name)}
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	key := strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(attr.Key))
+
+	switch {
+	case attr.Value.Kind() == slog.KindGroup:
+		group := attr.Value.Group()
+		redactedGroup := make([]slog.Attr, len(group))
+		for i, groupAttr := range group {
+			redactedGroup[i] = redactAttr(groupAttr)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redactedGroup...)}
+	case sensitiveKeys[key]:
+		return slog.String(attr.Key, redacted)
+	case key == "trackingnumber":
+		return slog.String(attr.Key, MaskTrackingNumber(attr.Value.String()))
+	case attr.Value.Kind() == slog.KindString:
+		return slog.String(attr.Key, Redact(attr.Value.String()))
+	case attr.Value.Kind() == slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+
+	return attr
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"tracking number", "no details for 1Z999AA10123456784", "no details for 1Z************6784"},
+		{"lower case tracking number", "1z999aa10123456784 not found", "1Z************6784 not found"},
+		{"tracking number in a word", "X1Z999AA10123456784", "X1Z999AA10123456784"},
+		{"address fields", `{"addressLine1":"1 Main St","city": "Springfield","postalCode":"62701","countryCode":"US"}`, `{"addressLine1":"[REDACTED]","city": "[REDACTED]","postalCode":"[REDACTED]","countryCode":"US"}`},
+		{"escaped quotes", `{"name":"Jane \"JD\" Doe"}`, `{"name":"[REDACTED]"}`},
+		{"plain text", "upstream timed out", "upstream timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskTrackingNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1Z999AA10123456784", "**************6784"},
+		{"6784", "****"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := MaskTrackingNumber(tt.in); got != tt.want {
+			t.Errorf("MaskTrackingNumber(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+type address struct {
+	City string
+}
+
+func TestRedactAttr(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want slog.Value
+	}{
+		{"sensitive key", slog.String("address_line1", "1 Main St"), slog.StringValue(redacted)},
+		{"sensitive key of any kind", slog.Any("scannedAddress", address{City: "Springfield"}), slog.StringValue(redacted)},
+		{"tracking number key", slog.String("tracking_number", "1Z999AA10123456784"), slog.StringValue("**************6784")},
+		{"string value", slog.String("message", "lookup of 1Z999AA10123456784 failed"), slog.StringValue("lookup of 1Z************6784 failed")},
+		{"error value", slog.Any("error", errors.New(`ups: {"city":"Springfield"}`)), slog.StringValue(`ups: {"city":"[REDACTED]"}`)},
+		{"other value", slog.Int("status", 502), slog.IntValue(502)},
+		{"group", slog.Group("request", slog.String("name", "Jane Doe"), slog.Int("attempt", 2)), slog.GroupValue(slog.String("name", redacted), slog.Int("attempt", 2))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactAttr(tt.attr)
+			if got.Key != tt.attr.Key || !got.Value.Equal(tt.want) {
+				t.Errorf("redactAttr(%v) = %v, want %s=%v", tt.attr, got, tt.attr.Key, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	b := new(bytes.Buffer)
+	logger, err := New(b, "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger = logger.With("station_id", "station-1", "name", "Jane Doe")
+
+	ctx := WithRequestID(context.Background(), "request-1")
+	logger.DebugContext(ctx, "not logged")
+	logger.InfoContext(ctx, "validated 1Z999AA10123456784", "trackingNumber", "1Z999AA10123456784")
+
+	record := map[string]any{}
+	if err := json.Unmarshal(b.Bytes(), &record); err != nil {
+		t.Fatalf("log = %q, want one JSON record: %v", b.String(), err)
+	}
+	want := map[string]any{
+		"msg":            "validated 1Z************6784",
+		"trackingNumber": "**************6784",
+		"station_id":     "station-1",
+		"name":           redacted,
+		"request_id":     "request-1",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+	if strings.Contains(b.String(), "1Z999AA10123456784") {
+		t.Errorf("log = %q, leaks the tracking number", b.String())
+	}
+
+	if _, err := New(b, "verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
@@ -1,19 +1,44 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/JoshuaPackardHR/shipping-label-validator/database"
 	"github.com/JoshuaPackardHR/shipping-label-validator/docs"
 	"github.com/JoshuaPackardHR/shipping-label-validator/gpt"
+	"github.com/JoshuaPackardHR/shipping-label-validator/health"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/auth"
+	authmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/auth/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/events"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/manifests"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/printing"
+	printingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/printing/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/reports"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/scanner"
 	"github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping"
+	shippingmodels "github.com/JoshuaPackardHR/shipping-label-validator/internal/shipping/models"
+	"github.com/JoshuaPackardHR/shipping-label-validator/internal/usage"
+	"github.com/JoshuaPackardHR/shipping-label-validator/logging"
+	"github.com/JoshuaPackardHR/shipping-label-validator/metrics"
+	"github.com/JoshuaPackardHR/shipping-label-validator/printer"
+	"github.com/JoshuaPackardHR/shipping-label-validator/prompts"
+	"github.com/JoshuaPackardHR/shipping-label-validator/reporting"
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
 	"github.com/JoshuaPackardHR/shipping-label-validator/ups"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	swaggerFiles "github.com/swaggo/files"     // swagger embed files
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
@@ -42,13 +67,23 @@ import (
 //	@in							header
 //	@name						Authorization
 
+//	@securityDefinitions.apiKey	ApiKey
+//	@in							header
+//	@name						X-API-Key
+
 // @externalDocs.description	OpenAPI
 // @externalDocs.url			https://swagger.io/resources/open-api/
 func main() {
 	// Load environment variables
 	err := godotenv.Load()
+
+	logger, loggerErr := logging.New(os.Stdout, os.Getenv("LOG_LEVEL"))
+	if loggerErr != nil {
+		fatal("Failed to initialize logging", loggerErr)
+	}
+	slog.SetDefault(logger)
 	if err != nil {
-		fmt.Println("Error loading .env file: " + err.Error())
+		slog.Warn("Error loading .env file", "error", err)
 	}
 
 	environment := os.Getenv("APP_ENV")
@@ -67,35 +102,148 @@ func main() {
 	if environment != "local" {
 		gin.SetMode(gin.ReleaseMode)
 	}
-	router := gin.Default()
+	flush, err := reporting.Init(reporting.Config{
+		DSN:         os.Getenv("SENTRY_DSN"),
+		Environment: environment,
+		Name:        os.Getenv("APP_NAME"),
+	})
+	if err != nil {
+		fatal("Failed to initialize error reporting", err)
+	}
+	flushReports = flush
+	defer flushReports()
+
+	shutdownTracing, err := initTracing(environment)
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+
+	router := gin.New()
+	// handlers pass the gin context on, which must carry the request's span
+	router.ContextWithFallback = true
+	router.Use(logging.Middleware(), logging.Recovery(), metrics.Middleware(), otelgin.Middleware(os.Getenv("APP_NAME")), tracing.TraceID(), reporting.Middleware())
 
 	// Enable CORS for all origins
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"POST", "PUT", "PATCH", "DELETE"},
-		AllowHeaders:     []string{"Authorization,Content-Type,access-control-allow-origin,access-control-allow-headers"},
+		AllowHeaders:     []string{"Authorization,X-API-Key,Content-Type,X-Request-Id,access-control-allow-origin,access-control-allow-headers"},
+		ExposeHeaders:    []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", tracing.TraceIDHeader, logging.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
 	router.GET("/heartbeat", func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
+	checker, err := initHealth()
+	if err != nil {
+		fatal("Failed to initialize health checks", err)
+	}
+	router.GET("/healthz", checker.Live())
+	router.GET("/readyz", checker.Ready())
+	router.GET("/metrics", metrics.Handler(os.Getenv("METRICS_TOKEN")))
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	latest := router.Group("/api/latest")
+	db, err := database.NewMongo(context.Background(), os.Getenv("MONGO_URI"), os.Getenv("MONGO_DATABASE"))
+	if err != nil {
+		fatal("Failed to connect to Mongo", err)
+	}
+
 	upsClient, err := ups.NewClient(os.Getenv("UPS_CLIENT_ID"), os.Getenv("UPS_CLIENT_SECRET"))
 	if err != nil {
-		log.Fatalf("Failed to initialize UPS client: %v", err)
+		fatal("Failed to initialize UPS client", err)
+	}
+	upsClient, err = initUPSCache(upsClient)
+	if err != nil {
+		fatal("Failed to initialize UPS cache", err)
 	}
 
 	gptClient, err := initGPTClient()
 	if err != nil {
-		log.Fatalf("Failed to initialize GPT client: %v", err)
+		fatal("Failed to initialize GPT client", err)
+	}
+	gptClient, err = initGPTCache(gptClient, db)
+	if err != nil {
+		fatal("Failed to initialize GPT cache", err)
+	}
+	gptClient = gpt.NewTraced(gptClient)
+
+	checker.Add("mongo", func(ctx context.Context) error { return db.Client().Ping(ctx, nil) })
+	checker.Add("ups", upsClient.CheckToken)
+	checker.Add("llm", gptClient.Ping)
+
+	promptExperiment, err := prompts.ParseExperiment(os.Getenv("PROMPT_EXPERIMENT"))
+	if err != nil {
+		fatal("Failed to parse prompt experiment", err)
+	}
+	promptRegistry, err := prompts.NewRegistry(os.Getenv("PROMPTS_DIR"), os.Getenv("PROMPT_VERSION"), promptExperiment)
+	if err != nil {
+		fatal("Failed to initialize prompts", err)
+	}
+
+	prices, err := gpt.LoadPriceTable(os.Getenv("GPT_PRICES_FILE"))
+	if err != nil {
+		fatal("Failed to load GPT prices", err)
+	}
+	usageRepository, err := usage.NewRepository(context.Background(), db)
+	if err != nil {
+		fatal("Failed to initialize usage repository", err)
+	}
+	usageManager := usage.NewManager(usageRepository, prices)
+
+	shippingRepository, err := shipping.NewRepository(context.Background(), db)
+	if err != nil {
+		fatal("Failed to initialize validation repository", err)
+	}
+
+	authManager, err := initAuth(db)
+	if err != nil {
+		fatal("Failed to initialize auth", err)
+	}
+	auth.NewHandler(authManager).RegisterRoutes(latest.Group("/auth"))
+	authenticated := latest.Group("", auth.Middleware(authManager))
+
+	broker := events.NewBroker()
+	events.NewHandler(broker).RegisterRoutes(latest.Group("/events", auth.QueryToken(), auth.Middleware(authManager)))
+
+	manifestRepository, err := manifests.NewRepository(context.Background(), db)
+	if err != nil {
+		fatal("Failed to initialize manifest repository", err)
+	}
+	manifestManager := manifests.NewManager(manifestRepository, shippingRepository)
+	manifests.NewHandler(manifestManager).RegisterRoutes(authenticated.Group("/manifests"))
+
+	duplicates, err := shipping.LoadDuplicateConfig(os.Getenv("DUPLICATES_FILE"))
+	if err != nil {
+		fatal("Failed to load duplicate detection config", err)
 	}
+	shippingManager := shipping.NewManager(shippingRepository, upsClient, gptClient, promptRegistry, usageManager, broker, manifestManager, duplicates)
+	jobManager, err := initValidationJobs(shippingManager, shippingRepository)
+	if err != nil {
+		fatal("Failed to initialize validation jobs", err)
+	}
+	batchManager, err := initBatches(shippingManager)
+	if err != nil {
+		fatal("Failed to initialize batch validation", err)
+	}
+	shippingHandler := shipping.NewHandler(shippingManager, jobManager, batchManager, authManager)
+	shippingHandler.RegisterRoutes(authenticated.Group("/shipping"))
+	shippingHandler.RegisterValidationRoutes(authenticated.Group("/validations"))
 
-	shipping.NewHandler(
-		shipping.NewManager(upsClient, gptClient),
-	).RegisterRoutes(latest.Group("/shipping"))
+	printingManager, err := initPrinting(db, shippingManager)
+	if err != nil {
+		fatal("Failed to initialize printing", err)
+	}
+	printing.NewHandler(printingManager).RegisterRoutes(authenticated.Group("/print-jobs"))
+	scannerGateway := scanner.NewGateway(shippingManager, authManager)
+	scanner.NewHandler(scannerGateway).RegisterRoutes(latest.Group("/scanners", auth.QueryToken(), auth.Middleware(authManager)))
+
+	reports.NewHandler(reports.NewManager(reports.NewRepository(db))).RegisterRoutes(authenticated.Group("/reports"))
+
+	admin := authenticated.Group("/admin")
+	usage.NewHandler(usageManager).RegisterRoutes(admin)
+	auth.NewHandler(authManager).RegisterAdminRoutes(admin)
 
 	httpPort := ":" + os.Getenv("HTTP_PORT")
 	if httpPort == ":" {
@@ -106,28 +254,126 @@ func main() {
 		bind = fmt.Sprintf("127.0.0.1%s", httpPort)
 	}
 
+	shutdownTimeout, err := envDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		fatal("Failed to parse shutdown timeout", err)
+	}
+	jobDrainTimeout, err := envDuration("JOB_DRAIN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		fatal("Failed to parse job drain timeout", err)
+	}
+	flushTimeout, err := envDuration("FLUSH_TIMEOUT", 5*time.Second)
+	if err != nil {
+		fatal("Failed to parse flush timeout", err)
+	}
+	preStopDelay, err := envDuration("PRE_STOP_DELAY", 5*time.Second)
+	if err != nil {
+		fatal("Failed to parse pre-stop delay", err)
+	}
+	readHeaderTimeout, err := envDuration("READ_HEADER_TIMEOUT", 10*time.Second)
+	if err != nil {
+		fatal("Failed to parse read header timeout", err)
+	}
+
+	server := &http.Server{
+		Addr:    bind,
+		Handler: router,
+		// bodies are read by handlers with their own limits, but clients
+		// trickling headers would otherwise hold connections open
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	// event streams and scanner connections stay open until closed, so they
+	// end as the server shuts down instead of holding up the drain. Validation
+	// jobs drained after it record their progress without the broker.
+	server.RegisterOnShutdown(broker.Close)
+	server.RegisterOnShutdown(scannerGateway.Close)
 	go func() {
-		err := router.Run(bind)
-		if err != nil {
-			log.Fatal(err)
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("Failed to serve HTTP", err)
 		}
 	}()
+	slog.Info("Serving HTTP", "address", bind)
+
+	// Kubernetes sends SIGTERM before killing the pod
+	stop, cancelStop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancelStop()
+	<-stop.Done()
+	slog.Info("Shutting down", "timeout", shutdownTimeout)
 
-	// Capture Ctrl-c to shut down
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
-	<-stop
+	// in-flight scanner validations finish while their agents are still
+	// connected, then in-flight requests, then queued jobs, their callbacks
+	// and print jobs. Each step has its own timeout so a slow one cannot use
+	// up the time of those after it.
+	checker.Drain()
+	// keep serving until load balancers have seen readiness fail and stopped
+	// routing requests here, which would otherwise be refused
+	slog.Info("Waiting for traffic to stop", "delay", preStopDelay)
+	time.Sleep(preStopDelay)
+	shutdownStep("drain scanner validations", shutdownTimeout, scannerGateway.Shutdown)
+	shutdownStep("drain HTTP requests", shutdownTimeout, server.Shutdown)
+	shutdownStep("drain validation jobs", jobDrainTimeout, jobManager.Shutdown)
+	shutdownStep("drain print jobs", jobDrainTimeout, printingManager.Shutdown)
+	shutdownStep("flush traces", flushTimeout, shutdownTracing)
+	shutdownStep("disconnect from Mongo", flushTimeout, db.Client().Disconnect)
+}
+
+func shutdownStep(name string, timeout time.Duration, step func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := step(ctx); err != nil {
+		slog.Error("Failed to "+name, "error", err)
+	}
+}
+
+func initHealth() (*health.Checker, error) {
+	ttl, err := envDuration("HEALTH_CACHE_TTL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := envDuration("HEALTH_CHECK_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
 
+	return health.NewChecker(ttl, timeout), nil
+}
+
+func initTracing(environment string) (func(context.Context) error, error) {
+	sampleRatio := 1.0
+	if value := os.Getenv("TRACE_SAMPLE_RATIO"); value != "" {
+		var err error
+		if sampleRatio, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("TRACE_SAMPLE_RATIO: %w", err)
+		}
+	}
+
+	return tracing.Init(context.Background(), tracing.Config{
+		ServiceName: os.Getenv("APP_NAME"),
+		Environment: environment,
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		SampleRatio: sampleRatio,
+	})
 }
 
 func initGPTClient() (gpt.GPT, error) {
+	if os.Getenv("GPT") == "fake" {
+		fake, err := gpt.NewFake(os.Getenv("GPT_RECORDINGS_DIR"))
+		if err != nil {
+			return nil, err
+		}
+
+		return gpt.NewInstrumented(fake, "fake", "fake"), nil
+	}
+
 	if os.Getenv("GPT") == "gemini" {
 		gemini, err := gpt.NewGemini(os.Getenv("GEMINI_MODEL"), os.Getenv("GEMINI_API_KEY"))
 		if err != nil {
 			return nil, err
 		}
 
-		return gemini, nil
+		return gpt.NewInstrumented(gemini, "gemini", os.Getenv("GEMINI_MODEL")), nil
 	}
 
 	openAi, err := gpt.NewOpenAI(os.Getenv("OPENAI_MODEL"), os.Getenv("OPENAI_API_KEY"))
@@ -135,5 +381,158 @@ func initGPTClient() (gpt.GPT, error) {
 		return nil, err
 	}
 
-	return openAi, nil
+	return gpt.NewInstrumented(openAi, "openai", os.Getenv("OPENAI_MODEL")), nil
+}
+
+func initAuth(db *mongo.Database) (authmodels.Manager, error) {
+	keys, err := auth.LoadKeys(os.Getenv("JWT_SIGNING_KEY"), os.Getenv("JWT_PUBLIC_KEY"), os.Getenv("JWT_PRIVATE_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := envDuration("JWT_TTL", 12*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	repository, err := auth.NewRepository(context.Background(), db)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewManager(repository, keys, ttl), nil
+}
+
+func initValidationJobs(
+	shippingManager shippingmodels.Manager,
+	repository shippingmodels.Repository,
+) (shippingmodels.JobManager, error) {
+	workers, err := envInt("VALIDATION_WORKERS", 4)
+	if err != nil {
+		return nil, err
+	}
+	queueSize, err := envInt("VALIDATION_QUEUE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipping.NewJobManager(
+		context.Background(),
+		shippingManager,
+		repository,
+		shipping.NewWebhook(os.Getenv("WEBHOOK_SECRET")),
+		workers,
+		queueSize,
+	)
+}
+
+func initBatches(shippingManager shippingmodels.Manager) (shippingmodels.BatchManager, error) {
+	parallelism, err := envInt("BATCH_PARALLELISM", 4)
+	if err != nil {
+		return nil, err
+	}
+	maxItems, err := envInt("BATCH_MAX_ITEMS", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipping.NewBatchManager(shippingManager, parallelism, maxItems), nil
+}
+
+func initPrinting(db *mongo.Database, shippingManager shippingmodels.Manager) (printingmodels.Manager, error) {
+	config, err := printer.LoadConfig(os.Getenv("PRINTERS_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	repository, err := printing.NewRepository(context.Background(), db)
+	if err != nil {
+		return nil, err
+	}
+
+	return printing.NewManager(context.Background(), repository, shippingManager, config)
+}
+
+func initGPTCache(gptClient gpt.GPT, db *mongo.Database) (gpt.GPT, error) {
+	ttl, err := envDuration("GPT_CACHE_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	switch os.Getenv("GPT_CACHE") {
+	case "none":
+		return gptClient, nil
+	case "mongo":
+		store, err := gpt.NewMongoCache(context.Background(), db)
+		if err != nil {
+			return nil, err
+		}
+
+		return gpt.NewCache(gptClient, store, ttl), nil
+	}
+
+	size, err := envInt("GPT_CACHE_SIZE", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	return gpt.NewCache(gptClient, gpt.NewMemoryCache(size), ttl), nil
+}
+
+func initUPSCache(upsClient ups.Client) (ups.Client, error) {
+	ttl, err := envDuration("UPS_CACHE_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		return upsClient, nil
+	}
+
+	notFoundTTL, err := envDuration("UPS_NOT_FOUND_TTL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	size, err := envInt("UPS_CACHE_SIZE", 10000)
+	if err != nil {
+		return nil, err
+	}
+
+	return ups.NewCachingClient(upsClient, ttl, notFoundTTL, size), nil
+}
+
+func envDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return duration, nil
+}
+
+func envInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return i, nil
+}
+
+// flushReports sends buffered error reports. Exiting skips deferred calls, so
+// fatal flushes them itself.
+var flushReports = func() {}
+
+// fatal logs and reports err, then exits
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	reporting.CaptureError(fmt.Errorf("%s: %w", message, err))
+	flushReports()
+	os.Exit(1)
 }
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "slv"
+
+// Collectors are recorded to directly by the packages they measure
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route"})
+	HTTPInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+
+	Validations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validations_total",
+		Help:      "Label validations by facility and verdict: valid, invalid or error.",
+	}, []string{"facility", "verdict"})
+	ValidationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "validation_duration_seconds",
+		Help:      "Time taken to reach a validation verdict.",
+		Buckets:   []float64{.5, 1, 2, 3, 5, 8, 13, 20, 30, 60},
+	})
+	DuplicateScans = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "duplicate_scans_total",
+		Help:      "Validations of a tracking number already validated, by kind.",
+	}, []string{"kind"})
+
+	LLMRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_requests_total",
+		Help:      "LLM provider requests by provider, model and result: ok or the error kind.",
+	}, []string{"provider", "model", "result"})
+	LLMDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "LLM provider latency of successful requests.",
+		Buckets:   []float64{.5, 1, 2, 3, 5, 8, 13, 20, 30, 60},
+	}, []string{"provider", "model"})
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "LLM tokens by provider, model and type: prompt, cached, completion or reasoning.",
+	}, []string{"provider", "model", "type"})
+	LLMInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "llm_requests_in_flight",
+		Help:      "LLM provider requests awaiting a response.",
+	})
+
+	UPSRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ups_requests_total",
+		Help:      "UPS API requests by operation and status code, or error when no response was received.",
+	}, []string{"operation", "status"})
+	UPSDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ups_request_duration_seconds",
+		Help:      "UPS API latency by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result: hit or miss.",
+	}, []string{"cache", "result"})
+
+	Retries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retries_total",
+		Help:      "Retried attempts by operation.",
+	}, []string{"operation"})
+)
+
+// CacheLookup counts a cache hit or miss
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// Middleware records the count, latency and concurrency of requests by route.
+// Panicking requests are recorded as a 500 before the panic continues to the
+// recovery middleware.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		HTTPInFlight.Inc()
+		defer func() {
+			HTTPInFlight.Dec()
+
+			status := c.Writer.Status()
+			recovered := recover()
+			if recovered != nil {
+				status = http.StatusInternalServerError
+			}
+
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(status)).Inc()
+			HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+
+			if recovered != nil {
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+// Handler serves the metrics. A non-empty token is required as a bearer token.
+func Handler(token string) gin.HandlerFunc {
+	handler := promhttp.Handler()
+	return func(c *gin.Context) {
+		if token != "" {
+			expected := []byte("Bearer " + token)
+			if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery(), Middleware())
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/panic", func(*gin.Context) { panic("handler failed") })
+
+	tests := []struct {
+		name   string
+		path   string
+		route  string
+		status int
+	}{
+		{"served", "/ok", "/ok", http.StatusNoContent},
+		{"panicked", "/panic", "/panic", http.StatusInternalServerError},
+		{"unmatched", "/missing", "unmatched", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := HTTPRequests.WithLabelValues(http.MethodGet, tt.route, strconv.Itoa(tt.status))
+			before := testutil.ToFloat64(requests)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+
+			if got := testutil.ToFloat64(requests) - before; got != 1 {
+				t.Errorf("requests recorded = %v, want 1", got)
+			}
+			if got := testutil.ToFloat64(HTTPInFlight); got != 0 {
+				t.Errorf("in flight = %v, want 0", got)
+			}
+		})
+	}
+}
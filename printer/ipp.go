@@ -0,0 +1,133 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+)
+
+const (
+	ippDefaultPort = "631"
+	ippContentType = "application/ipp"
+
+	ippOperationPrintJob = 0x0002
+
+	ippTagOperation     = 0x01
+	ippTagEnd           = 0x03
+	ippTagName          = 0x42
+	ippTagURI           = 0x45
+	ippTagCharset       = 0x47
+	ippTagLanguage      = 0x48
+	ippTagMimeMediaType = 0x49
+
+	ippStatusServiceUnavailable = 0x0502
+	ippStatusBusy               = 0x0507
+)
+
+type ippPrinter struct {
+	uri        string
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewIPPPrinter returns a printer which submits documents with the IPP
+// Print-Job operation to an ipp:// or ipps:// printer URI
+func NewIPPPrinter(uri string) (Printer, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("ipp: %w", err)
+	}
+
+	endpoint := *u
+	switch u.Scheme {
+	case "ipp":
+		endpoint.Scheme = "http"
+	case "ipps":
+		endpoint.Scheme = "https"
+	default:
+		return nil, fmt.Errorf("ipp: unsupported scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		endpoint.Host = u.Hostname() + ":" + ippDefaultPort
+	}
+
+	return &ippPrinter{
+		uri:        uri,
+		endpoint:   endpoint.String(),
+		httpClient: &http.Client{Timeout: writeTimeout},
+	}, nil
+}
+
+func (p *ippPrinter) Print(ctx context.Context, document Document) error {
+	body := new(bytes.Buffer)
+	body.Write([]byte{1, 1})
+	binary.Write(body, binary.BigEndian, uint16(ippOperationPrintJob))
+	binary.Write(body, binary.BigEndian, rand.Int32N(1<<30)+1)
+	body.WriteByte(ippTagOperation)
+	ippAttribute(body, ippTagCharset, "attributes-charset", "utf-8")
+	ippAttribute(body, ippTagLanguage, "attributes-natural-language", "en")
+	ippAttribute(body, ippTagURI, "printer-uri", p.uri)
+	ippAttribute(body, ippTagName, "requesting-user-name", "shipping-label-validator")
+	if document.Name != "" {
+		ippAttribute(body, ippTagName, "job-name", document.Name)
+	}
+	if document.ContentType != "" {
+		ippAttribute(body, ippTagMimeMediaType, "document-format", document.ContentType)
+	}
+	body.WriteByte(ippTagEnd)
+	body.Write(document.Data)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ippContentType)
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return transportError(err)
+	}
+	defer res.Body.Close()
+
+	response, err := io.ReadAll(res.Body)
+	if err != nil {
+		return transportError(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		if retry.ShouldRetryStatus(res.StatusCode, false) {
+			return retry.Retryable(fmt.Errorf("%w: http status %d", ErrUnavailable, res.StatusCode),
+				retry.ParseRetryAfter(res.Header.Get("Retry-After")))
+		}
+		return fmt.Errorf("%w: http status %d", ErrRejected, res.StatusCode)
+	}
+	if len(response) < 8 {
+		return fmt.Errorf("%w: invalid ipp response", ErrRejected)
+	}
+
+	status := binary.BigEndian.Uint16(response[2:4])
+	switch {
+	case status < 0x0100:
+		return nil
+	case status == ippStatusBusy || status == ippStatusServiceUnavailable:
+		// the printer did not accept the job so it is safe to send again
+		return retry.Retryable(fmt.Errorf("%w: ipp status 0x%04x", ErrUnavailable, status), 0)
+	}
+
+	return fmt.Errorf("%w: ipp status 0x%04x", ErrRejected, status)
+}
+
+func ippAttribute(b *bytes.Buffer, tag byte, name string, value string) {
+	b.WriteByte(tag)
+	binary.Write(b, binary.BigEndian, uint16(len(name)))
+	b.WriteString(name)
+	binary.Write(b, binary.BigEndian, uint16(len(value)))
+	b.WriteString(value)
+}
@@ -0,0 +1,162 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+)
+
+// ippResponse returns an IPP response with the status code and request ID
+func ippResponse(status uint16, requestID uint32) []byte {
+	b := new(bytes.Buffer)
+	b.Write([]byte{1, 1})
+	binary.Write(b, binary.BigEndian, status)
+	binary.Write(b, binary.BigEndian, requestID)
+	b.WriteByte(ippTagEnd)
+	return b.Bytes()
+}
+
+// newIPPServer answers each Print-Job with the next of statuses, repeating
+// the last, and records the last request body
+func newIPPServer(t *testing.T, statuses ...uint16) (*httptest.Server, *atomic.Int32, *atomic.Value) {
+	t.Helper()
+	var calls atomic.Int32
+	var body atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		data, _ := io.ReadAll(r.Body)
+		body.Store(data)
+		if r.Header.Get("Content-Type") != ippContentType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		status := statuses[min(call, len(statuses))-1]
+		w.Header().Set("Content-Type", ippContentType)
+		w.Write(ippResponse(status, binary.BigEndian.Uint32(data[4:8])))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls, &body
+}
+
+func newTestIPPPrinter(t *testing.T, server *httptest.Server) Printer {
+	t.Helper()
+	p, err := NewIPPPrinter(strings.Replace(server.URL, "http://", "ipp://", 1) + "/ipp/print")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestIPPPrinter(t *testing.T) {
+	pdf := []byte("%PDF-1.4 label")
+	tests := []struct {
+		name      string
+		statuses  []uint16
+		wantCalls int32
+		wantErr   error
+	}{
+		{"accepted", []uint16{0x0000}, 1, nil},
+		{"accepted with ignored attributes", []uint16{0x0001}, 1, nil},
+		{"busy then accepted", []uint16{ippStatusBusy, ippStatusBusy, 0x0000}, 3, nil},
+		{"service unavailable then accepted", []uint16{ippStatusServiceUnavailable, 0x0000}, 2, nil},
+		{"busy throughout", []uint16{ippStatusBusy}, 3, ErrUnavailable},
+		{"document format not supported", []uint16{0x040A}, 1, ErrRejected},
+		{"not authorized", []uint16{0x0403}, 1, ErrRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls, body := newIPPServer(t, tt.statuses...)
+			p := newTestIPPPrinter(t, server)
+
+			policy := retry.Policy{MaxAttempts: 3}
+			_, err := policy.Do(context.Background(), "test", func(ctx context.Context) error {
+				return p.Print(ctx, Document{Name: "1Z999AA10123456784", ContentType: "application/pdf", Data: pdf})
+			})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("err = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("requests = %d, want %d", got, tt.wantCalls)
+			}
+
+			data := body.Load().([]byte)
+			if operation := binary.BigEndian.Uint16(data[2:4]); operation != ippOperationPrintJob {
+				t.Errorf("operation = 0x%04x, want Print-Job", operation)
+			}
+			for _, attribute := range []string{"printer-uri", "job-name", "1Z999AA10123456784", "document-format", "application/pdf"} {
+				if !bytes.Contains(data, []byte(attribute)) {
+					t.Errorf("request is missing %q", attribute)
+				}
+			}
+			if !bytes.HasSuffix(data, append([]byte{ippTagEnd}, pdf...)) {
+				t.Error("request does not end with the document after the attributes")
+			}
+		})
+	}
+}
+
+func TestIPPPrinterHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantErr   error
+		retryable bool
+	}{
+		{"service unavailable", http.StatusServiceUnavailable, ErrUnavailable, true},
+		{"not found", http.StatusNotFound, ErrRejected, false},
+		{"server error", http.StatusInternalServerError, ErrRejected, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := newTestIPPPrinter(t, server).Print(context.Background(), Document{Data: []byte("label")})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			policy := retry.Policy{MaxAttempts: 2}
+			attempts, _ := policy.Do(context.Background(), "test", func(context.Context) error { return err })
+			if retryable := attempts == 2; retryable != tt.retryable {
+				t.Errorf("retryable = %v, want %v", retryable, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestNewIPPPrinter(t *testing.T) {
+	tests := []struct {
+		uri          string
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{"ipp://printer.local/ipp/print", "http://printer.local:631/ipp/print", false},
+		{"ipps://printer.local:8631/ipp/print", "https://printer.local:8631/ipp/print", false},
+		{"http://printer.local/ipp/print", "", true},
+	}
+	for _, tt := range tests {
+		p, err := NewIPPPrinter(tt.uri)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("NewIPPPrinter(%s) err = %v, wantErr %v", tt.uri, err, tt.wantErr)
+		}
+		if err == nil && p.(*ippPrinter).endpoint != tt.wantEndpoint {
+			t.Errorf("endpoint = %s, want %s", p.(*ippPrinter).endpoint, tt.wantEndpoint)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	ProtocolRaw = "raw"
+	ProtocolIPP = "ipp"
+
+	FormatZPL = "zpl"
+	FormatPDF = "pdf"
+)
+
+var (
+	ErrUnavailable = errors.New("printer is unavailable")
+	ErrRejected    = errors.New("printer rejected the job")
+	ErrTimeout     = errors.New("printer timed out")
+)
+
+// Document is a rendered document to print
+type Document struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type Printer interface {
+	Print(ctx context.Context, document Document) error
+}
+
+type PrinterConfig struct {
+	Name string `json:"name"`
+	// Protocol is raw for ZPL sent over TCP, usually to port 9100, or ipp
+	Protocol string `json:"protocol"`
+	// Address is host:port for raw printers and an ipp:// or ipps:// URI for IPP printers
+	Address string `json:"address"`
+	// Format is the label format the printer accepts, zpl or pdf
+	Format string `json:"format"`
+	// Facility is the facility the printer is in. Only its users and admins
+	// may print to it.
+	Facility string `json:"facility"`
+}
+
+// Config maps stations to the printer next to them
+type Config struct {
+	Printers []PrinterConfig   `json:"printers"`
+	Stations map[string]string `json:"stations"`
+}
+
+// LoadConfig loads the printer config from a JSON file. An empty path returns
+// a config without printers.
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{Stations: map[string]string{}}
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("printers: %w", err)
+	}
+
+	printers := map[string]bool{}
+	for _, printer := range config.Printers {
+		if printer.Name == "" || printer.Address == "" || printer.Facility == "" {
+			return nil, fmt.Errorf("printers: name, address and facility are required")
+		}
+		if printer.Protocol != ProtocolRaw && printer.Protocol != ProtocolIPP {
+			return nil, fmt.Errorf("printers: %s: unknown protocol %q", printer.Name, printer.Protocol)
+		}
+		if printer.Format != FormatZPL && printer.Format != FormatPDF {
+			return nil, fmt.Errorf("printers: %s: unknown format %q", printer.Name, printer.Format)
+		}
+		printers[printer.Name] = true
+	}
+	for station, printer := range config.Stations {
+		if !printers[printer] {
+			return nil, fmt.Errorf("printers: station %s uses unknown printer %q", station, printer)
+		}
+	}
+
+	return config, nil
+}
+
+// Printer returns the config of the named printer
+func (c *Config) Printer(name string) (PrinterConfig, bool) {
+	for _, printer := range c.Printers {
+		if printer.Name == name {
+			return printer, true
+		}
+	}
+
+	return PrinterConfig{}, false
+}
+
+// New returns a printer for the config
+func New(config PrinterConfig) (Printer, error) {
+	switch config.Protocol {
+	case ProtocolRaw:
+		return NewRawPrinter(config.Address), nil
+	case ProtocolIPP:
+		return NewIPPPrinter(config.Address)
+	}
+
+	return nil, fmt.Errorf("unknown printer protocol %q", config.Protocol)
+}
@@ -0,0 +1,47 @@
+package printer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"valid", `{"printers":[{"name":"zebra","protocol":"raw","address":"10.0.0.5:9100","format":"zpl","facility":"ATL1"}],"stations":{"S1":"zebra"}}`, false},
+		{"missing address", `{"printers":[{"name":"zebra","protocol":"raw","format":"zpl","facility":"ATL1"}]}`, true},
+		{"missing facility", `{"printers":[{"name":"zebra","protocol":"raw","address":"10.0.0.5:9100","format":"zpl"}]}`, true},
+		{"unknown protocol", `{"printers":[{"name":"zebra","protocol":"lpd","address":"10.0.0.5","format":"zpl","facility":"ATL1"}]}`, true},
+		{"unknown format", `{"printers":[{"name":"zebra","protocol":"raw","address":"10.0.0.5:9100","format":"epl","facility":"ATL1"}]}`, true},
+		{"station with unknown printer", `{"printers":[],"stations":{"S1":"zebra"}}`, true},
+		{"invalid json", `{`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "printers.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, ok := config.Printer(config.Stations["S1"]); !ok {
+				t.Error("station printer not found")
+			}
+		})
+	}
+
+	config, err := LoadConfig("")
+	if err != nil || len(config.Printers) != 0 {
+		t.Errorf("LoadConfig(\"\") = %+v, %v, want an empty config", config, err)
+	}
+}
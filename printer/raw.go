@@ -0,0 +1,72 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+)
+
+const (
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 30 * time.Second
+)
+
+type rawPrinter struct {
+	address string
+}
+
+// NewRawPrinter returns a printer which writes documents to a TCP socket, as
+// accepted by Zebra printers on port 9100
+func NewRawPrinter(address string) Printer {
+	return &rawPrinter{address: address}
+}
+
+func (p *rawPrinter) Print(ctx context.Context, document Document) error {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return transportError(err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(writeTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return transportError(err)
+	}
+
+	// raw printing has no acknowledgement so a write error may still have
+	// printed the label and is not retried
+	if _, err := conn.Write(document.Data); err != nil {
+		return transportError(err)
+	}
+
+	return nil
+}
+
+// transportError wraps an error reaching a printer as ErrTimeout or
+// ErrUnavailable, marked retryable if the printer cannot have received the job
+func transportError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	typed := ErrUnavailable
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		typed = ErrTimeout
+	}
+
+	wrapped := fmt.Errorf("%w: %w", typed, err)
+	if retry.ShouldRetryError(err, false) {
+		return retry.Retryable(wrapped, 0)
+	}
+
+	return wrapped
+}
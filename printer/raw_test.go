@@ -0,0 +1,71 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+)
+
+func TestRawPrinterSendsDocument(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	zpl := []byte("^XA^FO50,50^BCN,100,Y,N,N^FD1Z999AA10123456784^FS^XZ")
+	p := NewRawPrinter(listener.Addr().String())
+	if err := p.Print(context.Background(), Document{Name: "1Z999AA10123456784", Data: zpl}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-received:
+		if !bytes.Equal(data, zpl) {
+			t.Errorf("printer received %q, want %q", data, zpl)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("printer received nothing")
+	}
+}
+
+func TestRawPrinterUnreachable(t *testing.T) {
+	// a closed listener leaves a port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	err = NewRawPrinter(address).Print(context.Background(), Document{Data: []byte("^XA^XZ")})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want %v", err, ErrUnavailable)
+	}
+
+	// the printer cannot have received the job so it is retried
+	policy := retry.Policy{MaxAttempts: 2}
+	attempts, _ := policy.Do(context.Background(), "test", func(ctx context.Context) error {
+		return NewRawPrinter(address).Print(ctx, Document{Data: []byte("^XA^XZ")})
+	})
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
@@ -0,0 +1,233 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+)
+
+// Templates are named <version>[.<carrier>][.<locale>].tmpl, for example
+// v1.tmpl, v1.ups.tmpl, v1.en-CA.tmpl or v1.ups.en-CA.tmpl. The most specific
+// template available for the carrier and locale is used.
+//
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+const templateExt = ".tmpl"
+
+// ErrUnknownCarrier is returned when rendering a prompt for a carrier missing
+// from Carriers, whose tracking format the prompt cannot describe
+var ErrUnknownCarrier error = helpers.NewClientError("unknown carrier", http.StatusBadRequest, "unknown_carrier")
+
+type Carrier struct {
+	Name           string
+	TrackingFormat string
+}
+
+var Carriers = map[string]Carrier{
+	"ups": {
+		Name:           "UPS",
+		TrackingFormat: `always starts with the two characters "1Z" and is always 18 characters in length`,
+	},
+}
+
+// Vars are the values available to a template
+type Vars struct {
+	Carrier        string
+	CarrierName    string
+	TrackingFormat string
+	Locale         string
+}
+
+type Prompt struct {
+	// Version is the resolved template name, e.g. v1.ups.en-CA
+	Version string
+	Text    string
+}
+
+// Experiment sends Percent of selections to Version instead of the default version
+type Experiment struct {
+	Version string
+	Percent int
+}
+
+type Registry struct {
+	templates      map[string]*template.Template
+	defaultVersion string
+	experiment     *Experiment
+}
+
+// NewRegistry loads the embedded templates and, if dir is set, any templates in
+// dir which replace embedded templates of the same name
+func NewRegistry(dir string, defaultVersion string, experiment *Experiment) (*Registry, error) {
+	r := &Registry{
+		templates:      map[string]*template.Template{},
+		defaultVersion: defaultVersion,
+		experiment:     experiment,
+	}
+
+	templatesFS, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(templatesFS); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := r.load(os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.defaultVersion == "" {
+		r.defaultVersion = "v1"
+	}
+	if !r.hasVersion(r.defaultVersion) {
+		return nil, fmt.Errorf("prompt version %s not found", r.defaultVersion)
+	}
+	if experiment != nil {
+		if !r.hasVersion(experiment.Version) {
+			return nil, fmt.Errorf("prompt version %s not found", experiment.Version)
+		}
+		if experiment.Percent < 0 || experiment.Percent > 100 {
+			return nil, errors.New("experiment percent must be between 0 and 100")
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Registry) load(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != templateExt {
+			continue
+		}
+
+		text, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(entry.Name(), templateExt)
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(text))
+		if err != nil {
+			return err
+		}
+		r.templates[name] = tmpl
+	}
+
+	return nil
+}
+
+func (r *Registry) hasVersion(version string) bool {
+	_, ok := r.templates[version]
+	return ok
+}
+
+// Select picks the prompt version for key, applying the experiment split, and
+// renders it. The same key always selects the same version.
+func (r *Registry) Select(key string, vars Vars) (*Prompt, error) {
+	version := r.defaultVersion
+	if r.experiment != nil && bucket(key) < r.experiment.Percent {
+		version = r.experiment.Version
+	}
+
+	return r.Render(version, vars)
+}
+
+// Render renders the most specific template of version for the carrier and locale
+func (r *Registry) Render(version string, vars Vars) (*Prompt, error) {
+	carrier, ok := Carriers[vars.Carrier]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownCarrier, vars.Carrier)
+	}
+	if vars.CarrierName == "" {
+		vars.CarrierName = carrier.Name
+	}
+	if vars.TrackingFormat == "" {
+		vars.TrackingFormat = carrier.TrackingFormat
+	}
+
+	candidates := []string{
+		join(version, vars.Carrier, vars.Locale),
+		join(version, vars.Carrier),
+		join(version, vars.Locale),
+		version,
+	}
+	for _, name := range candidates {
+		tmpl, ok := r.templates[name]
+		if !ok {
+			continue
+		}
+
+		text := new(bytes.Buffer)
+		if err := tmpl.Execute(text, vars); err != nil {
+			return nil, err
+		}
+
+		return &Prompt{
+			Version: name,
+			Text:    text.String(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("prompt version %s not found", version)
+}
+
+// ParseExperiment parses an experiment in the form <version>:<percent>, e.g. v2:10
+func ParseExperiment(value string) (*Experiment, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	version, percent, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid prompt experiment %q", value)
+	}
+	p, err := strconv.Atoi(percent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt experiment %q: %w", value, err)
+	}
+
+	return &Experiment{
+		Version: version,
+		Percent: p,
+	}, nil
+}
+
+func join(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+
+	return strings.Join(nonEmpty, ".")
+}
+
+func bucket(key string) int {
+	if key == "" {
+		return rand.IntN(100)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
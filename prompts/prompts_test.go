@@ -0,0 +1,209 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry(t *testing.T, experiment *Experiment) *Registry {
+	t.Helper()
+	dir := t.TempDir()
+	templates := map[string]string{
+		"v2.tmpl":           "v2 {{.CarrierName}}",
+		"v2.ups.tmpl":       "v2 ups {{.TrackingFormat}}",
+		"v2.en-CA.tmpl":     "v2 locale {{.Locale}}",
+		"v2.ups.fr-CA.tmpl": "v2 ups {{.Locale}}",
+	}
+	for name, text := range templates {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewRegistry(dir, "v1", experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestRender(t *testing.T) {
+	r := newTestRegistry(t, nil)
+
+	tests := []struct {
+		name        string
+		version     string
+		vars        Vars
+		wantVersion string
+		wantText    string
+		wantErr     error
+	}{
+		{"carrier and locale", "v2", Vars{Carrier: "ups", Locale: "fr-CA"}, "v2.ups.fr-CA", "v2 ups fr-CA", nil},
+		{"carrier", "v2", Vars{Carrier: "ups", Locale: "de-DE"}, "v2.ups", "v2 ups " + Carriers["ups"].TrackingFormat, nil},
+		{"carrier over locale", "v2", Vars{Carrier: "ups", Locale: "en-CA"}, "v2.ups", "v2 ups " + Carriers["ups"].TrackingFormat, nil},
+		{"embedded default", "v1", Vars{Carrier: "ups"}, "v1", Carriers["ups"].TrackingFormat, nil},
+		{"unknown carrier", "v2", Vars{Carrier: "fedex"}, "", "", ErrUnknownCarrier},
+		{"missing carrier", "v2", Vars{}, "", "", ErrUnknownCarrier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := r.Render(tt.version, tt.vars)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prompt.Version != tt.wantVersion {
+				t.Errorf("version = %s, want %s", prompt.Version, tt.wantVersion)
+			}
+			if !strings.Contains(prompt.Text, tt.wantText) {
+				t.Errorf("text = %q, want it to contain %q", prompt.Text, tt.wantText)
+			}
+		})
+	}
+
+	if _, err := r.Render("v9", Vars{Carrier: "ups"}); err == nil {
+		t.Error("expected an error rendering a missing version")
+	}
+}
+
+func TestRenderEmbeddedVariants(t *testing.T) {
+	r, err := NewRegistry("", "v1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		vars        Vars
+		wantVersion string
+		wantText    string
+	}{
+		{"locale variant", Vars{Carrier: "ups", Locale: "fr-CA"}, "v1.fr-CA", "Expédier à"},
+		{"unknown locale falls back", Vars{Carrier: "ups", Locale: "de-DE"}, "v1", "ship to"},
+		{"no locale", Vars{Carrier: "ups"}, "v1", "ship to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := r.Render("v1", tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prompt.Version != tt.wantVersion {
+				t.Errorf("version = %s, want %s", prompt.Version, tt.wantVersion)
+			}
+			if !strings.Contains(prompt.Text, tt.wantText) {
+				t.Errorf("text = %q, want it to contain %q", prompt.Text, tt.wantText)
+			}
+			if !strings.Contains(prompt.Text, Carriers["ups"].TrackingFormat) {
+				t.Errorf("text = %q, want the UPS tracking format", prompt.Text)
+			}
+		})
+	}
+
+	// a carrier and locale template in the override directory is more specific
+	// than the embedded locale variant
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "v1.ups.fr-CA.tmpl"), []byte("ups {{.Locale}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err = NewRegistry(dir, "v1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, err := r.Render("v1", Vars{Carrier: "ups", Locale: "fr-CA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prompt.Version != "v1.ups.fr-CA" || prompt.Text != "ups fr-CA" {
+		t.Errorf("prompt = %+v, want v1.ups.fr-CA", prompt)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name        string
+		experiment  *Experiment
+		wantVersion string
+	}{
+		{"no experiment", nil, "v1"},
+		{"experiment off", &Experiment{Version: "v2", Percent: 0}, "v1"},
+		{"experiment everyone", &Experiment{Version: "v2", Percent: 100}, "v2.ups"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry(t, tt.experiment)
+			for _, key := range []string{"1Z999AA10123456784", "abc", "def"} {
+				prompt, err := r.Select(key, Vars{Carrier: "ups"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if prompt.Version != tt.wantVersion {
+					t.Errorf("Select(%s) = %s, want %s", key, prompt.Version, tt.wantVersion)
+				}
+			}
+		})
+	}
+}
+
+func TestBucket(t *testing.T) {
+	counts := 0
+	for i := range 1000 {
+		key := "key-" + string(rune('a'+i%26)) + strings.Repeat("x", i)
+		b := bucket(key)
+		if b < 0 || b >= 100 {
+			t.Fatalf("bucket(%q) = %d, want 0-99", key, b)
+		}
+		if b != bucket(key) {
+			t.Fatalf("bucket(%q) is not stable", key)
+		}
+		if b < 10 {
+			counts++
+		}
+	}
+	// roughly 10% of keys fall in the first ten buckets
+	if counts < 50 || counts > 150 {
+		t.Errorf("%d of 1000 keys in buckets 0-9, want about 100", counts)
+	}
+}
+
+func TestParseExperiment(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *Experiment
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"v2:10", &Experiment{Version: "v2", Percent: 10}, false},
+		{"v2", nil, true},
+		{"v2:ten", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseExperiment(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRegistryRejectsInvalidExperiment(t *testing.T) {
+	if _, err := NewRegistry("", "v1", &Experiment{Version: "v1", Percent: 101}); err == nil {
+		t.Error("expected an error for percent over 100")
+	}
+	if _, err := NewRegistry("", "v1", &Experiment{Version: "v9", Percent: 10}); err == nil {
+		t.Error("expected an error for a missing experiment version")
+	}
+}
@@ -0,0 +1,189 @@
+package reporting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/logging"
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+const flushTimeout = 2 * time.Second
+
+// sensitiveHeaders are removed from reported requests
+var sensitiveHeaders = []string{"Authorization", "X-Api-Key", "Cookie", "Set-Cookie"}
+
+type Config struct {
+	// DSN is the Sentry DSN. Reporting is a no-op when empty.
+	DSN         string
+	Environment string
+	// Name prefixes the release, the VCS revision the binary was built from
+	Name string
+}
+
+// Init configures the Sentry client and returns a function flushing buffered
+// events on shutdown
+func Init(config Config) (func(), error) {
+	if config.DSN == "" {
+		return func() {}, nil
+	}
+
+	release := ""
+	if revision := buildRevision(); revision != "" {
+		release = config.Name + "@" + revision
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              config.DSN,
+		Environment:      config.Environment,
+		Release:          release,
+		AttachStacktrace: true,
+		SendDefaultPII:   false,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			return scrub(event)
+		},
+		BeforeBreadcrumb: func(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+			breadcrumb.Message = scrubString(breadcrumb.Message)
+			return breadcrumb
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { sentry.Flush(flushTimeout) }, nil
+}
+
+// Middleware reports panics and 5xx responses with the errors attached to the
+// request. Panics are raised again for the recovery middleware to respond.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetRequest(c.Request)
+		hub.Scope().SetTag("route", c.FullPath())
+		if requestID := logging.RequestID(c.Request.Context()); requestID != "" {
+			hub.Scope().SetTag("request_id", requestID)
+		}
+		c.Request = c.Request.WithContext(sentry.SetHubOnContext(c.Request.Context(), hub))
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				hub.RecoverWithContext(c.Request.Context(), recovered)
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() < http.StatusInternalServerError {
+			return
+		}
+		hub.Scope().SetTag("status", fmt.Sprint(c.Writer.Status()))
+		if len(c.Errors) == 0 {
+			hub.CaptureMessage(fmt.Sprintf("%s %s responded %d", c.Request.Method, c.FullPath(), c.Writer.Status()))
+			return
+		}
+		for _, err := range c.Errors {
+			hub.CaptureException(err.Err)
+		}
+	}
+}
+
+// CaptureError reports an error outside of a request, such as a failure to
+// start
+func CaptureError(err error) {
+	sentry.CaptureException(err)
+}
+
+// SetTag tags the error reports of the request of ctx
+func SetTag(ctx context.Context, key, value string) {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil && value != "" {
+		hub.Scope().SetTag(key, value)
+	}
+}
+
+// Breadcrumb records a step of the request of ctx, reported with its errors.
+// Data must not contain addresses.
+func Breadcrumb(ctx context.Context, category, message string, data map[string]any, err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		return
+	}
+
+	if data == nil {
+		data = map[string]any{}
+	}
+	level := sentry.LevelInfo
+	if err != nil {
+		level = sentry.LevelError
+		data["error"] = err.Error()
+	}
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Type:      "http",
+		Category:  category,
+		Message:   message,
+		Data:      data,
+		Level:     level,
+		Timestamp: time.Now(),
+	}, nil)
+}
+
+// Hash returns a short hash of a value to correlate reports without sending it
+func Hash(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:6])
+}
+
+// scrub removes request bodies, credentials and addresses from an event
+func scrub(event *sentry.Event) *sentry.Event {
+	if event.Request != nil {
+		event.Request.Data = ""
+		event.Request.Cookies = ""
+		event.Request.QueryString = ""
+		for _, header := range sensitiveHeaders {
+			delete(event.Request.Headers, header)
+		}
+	}
+
+	event.Message = scrubString(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = scrubString(event.Exception[i].Value)
+	}
+	for _, breadcrumb := range event.Breadcrumbs {
+		breadcrumb.Message = scrubString(breadcrumb.Message)
+		if err, ok := breadcrumb.Data["error"].(string); ok {
+			breadcrumb.Data["error"] = scrubString(err)
+		}
+	}
+
+	return event
+}
+
+func scrubString(value string) string {
+	return logging.Redact(value)
+}
+
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,167 @@
+package reporting
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+// sentryServer collects the events sent to it as a Sentry project
+type sentryServer struct {
+	mu     sync.Mutex
+	events []sentry.Event
+}
+
+func (s *sentryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	// an envelope is a header line followed by item header and payload lines
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		item := struct {
+			Type string `json:"type"`
+		}{}
+		if json.Unmarshal(scanner.Bytes(), &item) != nil || item.Type != "event" || !scanner.Scan() {
+			continue
+		}
+		event := sentry.Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err == nil {
+			s.mu.Lock()
+			s.events = append(s.events, event)
+			s.mu.Unlock()
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// take returns the events received since the last call
+func (s *sentryServer) take() []sentry.Event {
+	sentry.Flush(5 * time.Second)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func TestMiddleware(t *testing.T) {
+	collector := &sentryServer{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	flush, err := Init(Config{DSN: strings.Replace(server.URL, "http://", "http://public@", 1) + "/1", Environment: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer flush()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard), Middleware())
+	router.GET("/upstream", func(c *gin.Context) {
+		c.Error(errors.New(`ups responded {"addressLine1":"1 Main St","city":"Springfield"} for 1Z999AA10123456784`))
+		c.Status(http.StatusBadGateway)
+	})
+	router.GET("/unavailable", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+	router.GET("/invalid", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	router.GET("/panic", func(*gin.Context) { panic("label for 1Z999AA10123456784 failed") })
+
+	tests := []struct {
+		name       string
+		path       string
+		wantEvents int
+		// wantText is in the event's message or exception
+		wantText string
+	}{
+		{"server error", "/upstream", 1, "1Z************6784"},
+		{"server error without errors", "/unavailable", 1, "GET /unavailable responded 503"},
+		{"client error", "/invalid", 0, ""},
+		{"panic", "/panic", 1, "1Z************6784"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path+"?token=secret", nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			req.Header.Set("X-API-Key", "slv_secret")
+			req.Header.Set("User-Agent", "scanner/1.0")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			events := collector.take()
+			if len(events) != tt.wantEvents {
+				t.Fatalf("events = %d, want %d", len(events), tt.wantEvents)
+			}
+			if tt.wantEvents == 0 {
+				return
+			}
+
+			event := events[0]
+			text := event.Message
+			for _, exception := range event.Exception {
+				text += " " + exception.Value
+			}
+			if !strings.Contains(text, tt.wantText) {
+				t.Errorf("event text = %q, want it to contain %q", text, tt.wantText)
+			}
+			for _, leaked := range []string{"1Z999AA10123456784", "1 Main St", "Springfield"} {
+				if strings.Contains(text, leaked) {
+					t.Errorf("event text = %q, leaks %q", text, leaked)
+				}
+			}
+
+			if event.Request == nil {
+				t.Fatal("event has no request")
+			}
+			for header := range event.Request.Headers {
+				if strings.EqualFold(header, "Authorization") || strings.EqualFold(header, "X-Api-Key") {
+					t.Errorf("event request has the %s header", header)
+				}
+			}
+			if event.Request.Headers["User-Agent"] != "scanner/1.0" {
+				t.Errorf("event request headers = %v, want the User-Agent kept", event.Request.Headers)
+			}
+			if event.Request.QueryString != "" {
+				t.Errorf("event query string = %q, want it removed", event.Request.QueryString)
+			}
+		})
+	}
+}
+
+func TestCaptureError(t *testing.T) {
+	collector := &sentryServer{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	flush, err := Init(Config{DSN: strings.Replace(server.URL, "http://", "http://public@", 1) + "/1", Environment: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer flush()
+
+	CaptureError(errors.New("failed to connect to Mongo"))
+	events := collector.take()
+	if len(events) != 1 || len(events[0].Exception) == 0 || events[0].Exception[0].Value != "failed to connect to Mongo" {
+		t.Errorf("events = %+v, want the startup failure", events)
+	}
+}
+
+func TestHash(t *testing.T) {
+	if Hash("") != "" {
+		t.Error("Hash of an empty value is not empty")
+	}
+	if a, b := Hash("1Z999AA10123456784"), Hash("1Z999AA10123456785"); len(a) != 12 || a == b {
+		t.Errorf("Hash = %q and %q, want distinct 12 character hashes", a, b)
+	}
+}
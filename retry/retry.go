@@ -0,0 +1,162 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/metrics"
+)
+
+// Policy retries transient failures with jittered exponential backoff
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// OnRetry is called before waiting to retry an attempt. The name should not
+	// vary per call as the default policy counts retries by name.
+	OnRetry func(name string, attempt int, err error, delay time.Duration)
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   250 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		OnRetry: func(name string, attempt int, err error, delay time.Duration) {
+			metrics.Retries.WithLabelValues(name).Inc()
+			slog.Warn("attempt failed, retrying", "operation", name, "attempt", attempt, "delay", delay, "error", err)
+		},
+	}
+}
+
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// Retryable marks err as safe to retry. A non-zero retryAfter overrides the
+// backoff delay for the next attempt.
+func Retryable(err error, retryAfter time.Duration) error {
+	return &retryableError{
+		err:        err,
+		retryAfter: retryAfter,
+	}
+}
+
+// Do calls fn until it succeeds, returns an error not marked Retryable, the
+// policy runs out of attempts, the server asks for a wait longer than MaxDelay
+// or the next attempt could not start before the context deadline. It returns
+// the number of attempts made.
+func (p Policy) Do(ctx context.Context, name string, fn func(ctx context.Context) error) (int, error) {
+	maxAttempts := max(p.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return attempt, nil
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) || attempt >= maxAttempts {
+			return attempt, err
+		}
+
+		delay := retryable.retryAfter
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			return attempt, err
+		}
+		if delay <= 0 {
+			delay = p.backoff(attempt)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return attempt, err
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(name, attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a random delay up to BaseDelay * 2^(attempt-1), capped at MaxDelay
+func (p Policy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int64N(int64(delay))) + 1
+}
+
+// ShouldRetryStatus reports whether a response status is transient. Requests
+// which are not idempotent are only retried when the server rejected them
+// without processing them.
+func ShouldRetryStatus(code int, idempotent bool) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+
+	return false
+}
+
+// ShouldRetryError reports whether a transport error is transient. Requests
+// which are not idempotent are only retried when the connection could not be
+// made.
+func ShouldRetryError(err error, idempotent bool) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var netErr net.Error
+	return idempotent && errors.As(err, &netErr)
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,180 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error
+		maxAttempts  int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"succeeds first time", nil, 3, 1, false},
+		{"retries retryable errors", []error{Retryable(errTransient, 0), Retryable(errTransient, 0)}, 3, 3, false},
+		{"stops at max attempts", []error{Retryable(errTransient, 0), Retryable(errTransient, 0), Retryable(errTransient, 0)}, 3, 3, true},
+		{"does not retry other errors", []error{errTransient}, 3, 1, true},
+		{"honours a short retry after", []error{Retryable(errTransient, time.Millisecond)}, 3, 2, false},
+		{"fails fast on a retry after over max delay", []error{Retryable(errTransient, time.Hour)}, 3, 1, true},
+		{"always makes one attempt", []error{Retryable(errTransient, 0)}, 0, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := Policy{MaxAttempts: tt.maxAttempts, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+			calls := 0
+			attempts, err := policy.Do(context.Background(), "test", func(context.Context) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if attempts != tt.wantAttempts || calls != tt.wantAttempts {
+				t.Errorf("attempts = %d, calls = %d, want %d", attempts, calls, tt.wantAttempts)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errTransient) {
+				t.Errorf("err = %v, want it to wrap %v", err, errTransient)
+			}
+		})
+	}
+}
+
+func TestDoStopsBeforeDeadline(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Second}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	attempts, err := policy.Do(ctx, "test", func(context.Context) error {
+		return Retryable(errTransient, time.Second)
+	})
+	if attempts != 1 || !errors.Is(err, errTransient) {
+		t.Errorf("attempts = %d, err = %v, want 1, %v", attempts, err, errTransient)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("waited %s for a retry which could not start before the deadline", elapsed)
+	}
+}
+
+func TestDoCallsOnRetry(t *testing.T) {
+	var delays []time.Duration
+	policy := Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+		OnRetry: func(_ string, _ int, _ error, delay time.Duration) {
+			delays = append(delays, delay)
+		},
+	}
+	_, _ = policy.Do(context.Background(), "test", func(context.Context) error {
+		return Retryable(errTransient, 2*time.Millisecond)
+	})
+	if len(delays) != 2 || delays[0] != 2*time.Millisecond {
+		t.Errorf("delays = %v, want two delays of 2ms", delays)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{64, time.Second},
+	}
+	for _, tt := range tests {
+		for range 100 {
+			delay := policy.backoff(tt.attempt)
+			if delay <= 0 || delay > tt.max {
+				t.Fatalf("backoff(%d) = %s, want (0, %s]", tt.attempt, delay, tt.max)
+			}
+		}
+	}
+
+	if delay := (Policy{}).backoff(1); delay != 0 {
+		t.Errorf("backoff without delays = %s, want 0", delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "3", 3 * time.Second, 3 * time.Second},
+		{"date", time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{"past date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+		{"invalid", "soon", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("ParseRetryAfter(%q) = %s, want [%s, %s]", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestShouldRetryStatus(t *testing.T) {
+	tests := []struct {
+		code       int
+		idempotent bool
+		want       bool
+	}{
+		{http.StatusTooManyRequests, false, true},
+		{http.StatusServiceUnavailable, false, true},
+		{http.StatusBadGateway, true, true},
+		{http.StatusBadGateway, false, false},
+		{http.StatusInternalServerError, true, true},
+		{http.StatusBadRequest, true, false},
+		{http.StatusNotFound, true, false},
+	}
+	for _, tt := range tests {
+		if got := ShouldRetryStatus(tt.code, tt.idempotent); got != tt.want {
+			t.Errorf("ShouldRetryStatus(%d, %v) = %v, want %v", tt.code, tt.idempotent, got, tt.want)
+		}
+	}
+}
+
+func TestShouldRetryError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Err: errors.New("connection reset")}
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{"dial", dialErr, false, true},
+		{"read idempotent", readErr, true, true},
+		{"read not idempotent", readErr, false, false},
+		{"canceled", context.Canceled, true, false},
+		{"deadline", context.DeadlineExceeded, true, false},
+		{"other", errTransient, true, false},
+	}
+	for _, tt := range tests {
+		if got := ShouldRetryError(tt.err, tt.idempotent); got != tt.want {
+			t.Errorf("%s: ShouldRetryError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/JoshuaPackardHR/shipping-label-validator"
+
+	// TraceIDHeader returns the trace ID of a request to quote in support tickets
+	TraceIDHeader = "X-Trace-Id"
+)
+
+type Config struct {
+	ServiceName string
+	Environment string
+	// Endpoint is the URL of the OTLP HTTP collector. Tracing is disabled when empty.
+	Endpoint string
+	// SampleRatio is the fraction of traces started here which are sampled.
+	// Traces started by a caller follow the caller's decision.
+	SampleRatio float64
+}
+
+// Init installs the global tracer provider and returns a function flushing
+// spans on shutdown
+func Init(ctx context.Context, config Config) (func(context.Context) error, error) {
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, errors.New("sample ratio must be between 0 and 1")
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(config.ServiceName),
+			semconv.DeploymentEnvironment(config.Environment),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span, which is a no-op while tracing is disabled
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End records a non-nil error on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SetAttributes is shorthand for setting attributes on the span of ctx
+func SetAttributes(ctx context.Context, attributes ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+}
+
+// TraceID sets the trace ID response header of sampled requests. It runs after
+// the middleware starting the request span.
+func TraceID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		spanContext := trace.SpanContextFromContext(c.Request.Context())
+		if spanContext.IsSampled() {
+			c.Header(TraceIDHeader, spanContext.TraceID().String())
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,136 @@
+package ups
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedLookupTimeout bounds a lookup shared by concurrent callers, which runs
+// detached from the cancellation of the caller which started it
+const sharedLookupTimeout = time.Minute
+
+type cacheEntry struct {
+	trackingNumber string
+	details        *TrackingDetails
+	err            error
+	expiresAt      time.Time
+}
+
+type cachingClient struct {
+	client      Client
+	ttl         time.Duration
+	notFoundTTL time.Duration
+	size        int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	group   singleflight.Group
+}
+
+// NewCachingClient wraps a Client so tracking details are cached for ttl and
+// not found tracking numbers for notFoundTTL. At most size tracking numbers are
+// cached. Concurrent lookups of the same tracking number share one request.
+func NewCachingClient(client Client, ttl time.Duration, notFoundTTL time.Duration, size int) Client {
+	return &cachingClient{
+		client:      client,
+		ttl:         ttl,
+		notFoundTTL: notFoundTTL,
+		size:        size,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+	}
+}
+
+func (c *cachingClient) GetTrackingDetails(ctx context.Context, trackingNumber string) (*TrackingDetails, error) {
+	trackingNumber = NormalizeTrackingNumber(trackingNumber)
+
+	if entry, ok := c.get(trackingNumber); ok {
+		metrics.CacheLookup("ups", true)
+		return entry.details, entry.err
+	}
+
+	called := false
+	shared := c.group.DoChan(trackingNumber, func() (any, error) {
+		called = true
+		// a caller leaving must not fail the others waiting on the lookup
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedLookupTimeout)
+		defer cancel()
+
+		details, err := c.client.GetTrackingDetails(ctx, trackingNumber)
+		switch {
+		case err == nil:
+			c.set(trackingNumber, details, nil, c.ttl)
+		case errors.Is(err, ErrNotFound) && c.notFoundTTL > 0:
+			c.set(trackingNumber, nil, err, c.notFoundTTL)
+		}
+
+		return details, err
+	})
+
+	select {
+	case res := <-shared:
+		// a lookup sharing a concurrent request is counted as a hit
+		metrics.CacheLookup("ups", !called)
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*TrackingDetails), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *cachingClient) CheckToken(ctx context.Context) error {
+	return c.client.CheckToken(ctx)
+}
+
+func (c *cachingClient) get(trackingNumber string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[trackingNumber]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.lru.Remove(element)
+		delete(c.entries, trackingNumber)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	return entry, true
+}
+
+func (c *cachingClient) set(trackingNumber string, details *TrackingDetails, err error, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{
+		trackingNumber: trackingNumber,
+		details:        details,
+		err:            err,
+		expiresAt:      time.Now().Add(ttl),
+	}
+	if element, ok := c.entries[trackingNumber]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[trackingNumber] = c.lru.PushFront(entry)
+	for c.size > 0 && c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).trackingNumber)
+	}
+}
@@ -0,0 +1,127 @@
+package ups
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubClient counts lookups and blocks each one until release is closed
+type stubClient struct {
+	calls   atomic.Int32
+	release chan struct{}
+	errs    map[string]error
+}
+
+func (s *stubClient) GetTrackingDetails(ctx context.Context, trackingNumber string) (*TrackingDetails, error) {
+	s.calls.Add(1)
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := s.errs[trackingNumber]; err != nil {
+		return nil, err
+	}
+
+	return &TrackingDetails{}, nil
+}
+
+func (s *stubClient) CheckToken(context.Context) error {
+	return nil
+}
+
+func TestCachingClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		lookups     []string
+		errs        map[string]error
+		notFoundTTL time.Duration
+		wantCalls   int32
+	}{
+		{"caches details", []string{"1Z1", "1Z1"}, nil, time.Minute, 1},
+		{"normalizes tracking numbers", []string{"1z1", " 1Z1 "}, nil, time.Minute, 1},
+		{"caches not found", []string{"1Z1", "1Z1"}, map[string]error{"1Z1": ErrNotFound}, time.Minute, 1},
+		{"not found caching disabled", []string{"1Z1", "1Z1"}, map[string]error{"1Z1": ErrNotFound}, 0, 2},
+		{"does not cache other errors", []string{"1Z1", "1Z1"}, map[string]error{"1Z1": ErrUnavailable}, time.Minute, 2},
+		{"evicts least recently used", []string{"1Z1", "1Z2", "1Z3", "1Z1"}, nil, time.Minute, 4},
+		{"reads refresh recency", []string{"1Z1", "1Z2", "1Z1", "1Z3", "1Z1"}, nil, time.Minute, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubClient{errs: tt.errs}
+			c := NewCachingClient(stub, time.Minute, tt.notFoundTTL, 2)
+			for _, trackingNumber := range tt.lookups {
+				_, err := c.GetTrackingDetails(context.Background(), trackingNumber)
+				if want := tt.errs["1Z1"]; trackingNumber == "1Z1" && !errors.Is(err, want) {
+					t.Fatalf("err = %v, want %v", err, want)
+				}
+			}
+			if got := stub.calls.Load(); got != tt.wantCalls {
+				t.Errorf("lookups = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCachingClientExpires(t *testing.T) {
+	stub := &stubClient{}
+	c := NewCachingClient(stub, -time.Second, 0, 10)
+	for range 2 {
+		if _, err := c.GetTrackingDetails(context.Background(), "1Z1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := stub.calls.Load(); got != 2 {
+		t.Errorf("lookups = %d, want 2", got)
+	}
+}
+
+func TestCachingClientSharesConcurrentLookups(t *testing.T) {
+	stub := &stubClient{release: make(chan struct{})}
+	c := NewCachingClient(stub, time.Minute, time.Minute, 10)
+
+	// the first caller leaves before the shared lookup finishes
+	leaving, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.GetTrackingDetails(leaving, "1Z1")
+		first <- err
+	}()
+	for stub.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetTrackingDetails(context.Background(), "1Z1")
+			errs <- err
+		}()
+	}
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("leaving caller err = %v, want %v", err, context.Canceled)
+	}
+	close(stub.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("waiting caller err = %v, want nil", err)
+		}
+	}
+	if got := stub.calls.Load(); got != 1 {
+		t.Errorf("lookups = %d, want 1", got)
+	}
+}
@@ -0,0 +1,86 @@
+package ups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/helpers"
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+)
+
+var (
+	ErrNotFound              error = helpers.NewClientError("tracking number not found", http.StatusNotFound, "tracking_number_not_found")
+	ErrInvalidTrackingNumber error = helpers.NewClientError("invalid tracking number", http.StatusUnprocessableEntity, "invalid_tracking_number")
+	ErrUnauthorized          error = helpers.NewClientError("ups rejected the credentials", http.StatusBadGateway, "carrier_unauthorized")
+	ErrRateLimited           error = helpers.NewClientError("ups rate limit exceeded", http.StatusTooManyRequests, "carrier_rate_limited")
+	ErrUnavailable           error = helpers.NewClientError("ups is unavailable", http.StatusBadGateway, "carrier_unavailable")
+	ErrTimeout               error = helpers.NewClientError("ups request timed out", http.StatusGatewayTimeout, "carrier_timeout")
+)
+
+// Error is an error response from UPS. The body is kept for logging and must
+// not be returned to API clients.
+type Error struct {
+	Err        error
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Err, e.StatusCode, e.Body)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(statusCode int, body string) *Error {
+	var err error
+	switch {
+	case statusCode == http.StatusNotFound:
+		err = ErrNotFound
+	case statusCode == http.StatusBadRequest:
+		err = ErrInvalidTrackingNumber
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		err = ErrUnauthorized
+	case statusCode == http.StatusTooManyRequests:
+		err = ErrRateLimited
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		err = ErrTimeout
+	default:
+		err = ErrUnavailable
+	}
+
+	return &Error{
+		Err:        err,
+		StatusCode: statusCode,
+		Body:       body,
+	}
+}
+
+// statusError returns the typed error for a non-2xx UPS response, marked
+// retryable if the status is transient
+func statusError(res *http.Response, body string) error {
+	err := newError(res.StatusCode, body)
+	if retry.ShouldRetryStatus(res.StatusCode, true) {
+		return retry.Retryable(err, retry.ParseRetryAfter(res.Header.Get("Retry-After")))
+	}
+
+	return err
+}
+
+// transportError wraps an error sending a request to UPS as ErrTimeout or ErrUnavailable
+func transportError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
@@ -1,15 +1,26 @@
 package ups
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/JoshuaPackardHR/shipping-label-validator/logging"
+	"github.com/JoshuaPackardHR/shipping-label-validator/metrics"
+	"github.com/JoshuaPackardHR/shipping-label-validator/reporting"
+	"github.com/JoshuaPackardHR/shipping-label-validator/retry"
+	"github.com/JoshuaPackardHR/shipping-label-validator/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -20,25 +31,80 @@ const (
 	expectContinueTimeout = 10 * time.Second
 	tokenUrl              = "https://onlinetools.ups.com/security/v1/oauth/token"
 	trackingUrl           = "https://onlinetools.ups.com/api/track/v1/details"
+	// tokenExpiryMargin renews tokens shortly before they expire
+	tokenExpiryMargin = time.Minute
 )
 
+var nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]`)
+
 type Client interface {
-	GetTrackingDetails(trackingNumber string) (*TrackingDetails, error)
+	GetTrackingDetails(ctx context.Context, trackingNumber string) (*TrackingDetails, error)
+	// CheckToken checks the client holds a valid access token, renewing it
+	// when expired
+	CheckToken(ctx context.Context) error
+}
+
+// NormalizeTrackingNumber returns the tracking number upper-cased without
+// spaces, as labels and scanners may print or read it either way
+func NormalizeTrackingNumber(trackingNumber string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(trackingNumber), " ", ""))
 }
 
 type client struct {
+	clientId     string
+	clientSecret string
+	retry        retry.Policy
+
+	mu          sync.Mutex
 	accessToken string
+	// expiresAt is zero when UPS did not say when the token expires
+	expiresAt time.Time
 }
 
 func NewClient(clientId string, clientSecret string) (Client, error) {
-	token, err := getAccessToken(nil, clientId, clientSecret, nil, nil)
-	if err != nil {
+	c := &client{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		retry:        retry.DefaultPolicy(),
+	}
+	if _, err := c.token(context.Background()); err != nil {
 		return nil, err
 	}
 
-	return &client{
-		accessToken: token.AccessToken,
-	}, nil
+	return c, nil
+}
+
+func (c *client) CheckToken(ctx context.Context) error {
+	_, err := c.token(ctx)
+	return err
+}
+
+// token returns the access token, requesting a new one when it has expired
+func (c *client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && (c.expiresAt.IsZero() || time.Now().Before(c.expiresAt)) {
+		return c.accessToken, nil
+	}
+
+	var token *TokenInfo
+	_, err := c.retry.Do(ctx, "ups token", func(ctx context.Context) error {
+		var err error
+		token, err = getAccessToken(ctx, nil, c.clientId, c.clientSecret, nil, nil)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.accessToken = token.AccessToken
+	c.expiresAt = time.Time{}
+	if seconds, err := strconv.Atoi(token.ExpiresIn); err == nil {
+		c.expiresAt = time.Now().Add(time.Duration(seconds)*time.Second - tokenExpiryMargin)
+	}
+
+	return c.accessToken, nil
 }
 
 type PackageAddressType string // @name PackageAddressType
@@ -56,6 +122,16 @@ type Address struct {
 	Country       string `json:"country"`
 } // @name Address
 
+// LogValue leaves street lines out of logs
+func (a Address) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("city", a.City),
+		slog.String("stateProvince", a.StateProvince),
+		slog.String("postalCode", a.PostalCode),
+		slog.String("countryCode", a.CountryCode),
+	)
+}
+
 type PackageAddress struct {
 	Type          PackageAddressType `json:"type"`
 	Name          string             `json:"name"`
@@ -63,6 +139,20 @@ type PackageAddress struct {
 	Address       Address            `json:"address"`
 } // @name PackageAddress
 
+// LogValue leaves names and street lines out of logs
+func (p PackageAddress) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("type", string(p.Type)),
+		slog.Any("address", p.Address),
+	)
+}
+
+type Service struct {
+	Code        string `json:"code"`
+	LevelCode   string `json:"levelCode"`
+	Description string `json:"description"`
+}
+
 type TrackingDetails struct {
 	TrackResponse struct {
 		Shipment []struct {
@@ -72,6 +162,7 @@ type TrackingDetails struct {
 			PickupDate    string `json:"pickupDate"`
 			Package       []struct {
 				TrackingNumber string           `json:"trackingNumber"`
+				Service        *Service         `json:"service"`
 				PackageAddress []PackageAddress `json:"packageAddress"`
 			} `json:"package"`
 			UserRelation []string `json:"userRelation"`
@@ -93,44 +184,81 @@ func (t TrackingDetails) GetPackageAddress(addressTypeType PackageAddressType) *
 	return nil
 }
 
-func (c *client) GetTrackingDetails(trackingNumber string) (*TrackingDetails, error) {
+func (t TrackingDetails) GetService() *Service {
+	for _, ship := range t.TrackResponse.Shipment {
+		for _, pkg := range ship.Package {
+			if pkg.Service != nil {
+				return pkg.Service
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *client) GetTrackingDetails(ctx context.Context, trackingNumber string) (*TrackingDetails, error) {
+	ctx, span := tracing.Start(ctx, "ups.GetTrackingDetails", trace.WithSpanKind(trace.SpanKindClient))
+	var data *TrackingDetails
+	attempts, err := c.retry.Do(ctx, "ups tracking", func(ctx context.Context) error {
+		var err error
+		data, err = c.getTrackingDetails(ctx, trackingNumber)
+		return err
+	})
+	span.SetAttributes(attribute.Int("ups.attempts", attempts))
+	tracing.End(span, err)
+	reporting.Breadcrumb(ctx, "ups", "tracking details", map[string]any{"attempts": attempts}, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *client) getTrackingDetails(ctx context.Context, trackingNumber string) (*TrackingDetails, error) {
 	var hClient *http.Client = setHttpClientTimeouts(nil)
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s", trackingUrl, trackingNumber), nil)
+	accessToken, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s", trackingUrl, trackingNumber), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	req.Header.Set("transId", fmt.Sprintf("%d", time.Now().Unix()))
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("transId", transactionID(ctx))
 	req.Header.Set("transactionSrc", "testing")
 
+	start := time.Now()
 	res, err := hClient.Do(req)
+	observeRequest("tracking", start, res)
 	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return nil, err
+		if retry.ShouldRetryError(err, true) {
+			return nil, retry.Retryable(transportError(err), 0)
 		}
-		return nil, err
+		return nil, transportError(err)
 	}
 
 	defer res.Body.Close()
 
 	response, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return nil, retry.Retryable(transportError(err), 0)
 	}
 
 	respString := string(response)
+	if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
+		return nil, statusError(res, respString)
+	}
+
 	var data TrackingDetails
 	err = json.Unmarshal([]byte(respString), &data)
 	if err != nil {
 		return nil, err
 	}
 
-	if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
-		return nil, errors.New(respString)
-	}
-
 	return &data, nil
 }
 
@@ -165,6 +293,28 @@ func setHttpClientTimeouts(httpClient *http.Client) *http.Client {
 	return httpClient
 }
 
+// transactionID returns the UPS transaction ID of a request, the request ID of
+// ctx so UPS calls can be matched to the requests making them
+func transactionID(ctx context.Context) string {
+	id := nonAlphanumeric.ReplaceAllString(logging.RequestID(ctx), "")
+	if id == "" {
+		id = logging.NewRequestID()
+	}
+
+	// UPS accepts up to 32 characters
+	return id[:min(len(id), 32)]
+}
+
+// observeRequest records the latency and status code of a UPS API request
+func observeRequest(operation string, start time.Time, res *http.Response) {
+	status := "error"
+	if res != nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+	metrics.UPSRequests.WithLabelValues(operation, status).Inc()
+	metrics.UPSDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
 type TokenInfo struct {
 	IssuedAt    string `json:"issued_at"`
 	TokenType   string `json:"token_type"`
@@ -174,7 +324,7 @@ type TokenInfo struct {
 	Status      string `json:"status"`
 }
 
-func getAccessToken(httpClient *http.Client, clientId string, clientSecret string, headers map[string]string, customClaims map[string]string) (*TokenInfo, error) {
+func getAccessToken(ctx context.Context, httpClient *http.Client, clientId string, clientSecret string, headers map[string]string, customClaims map[string]string) (*TokenInfo, error) {
 	var hClient *http.Client = setHttpClientTimeouts(httpClient)
 
 	body := url.Values{}
@@ -187,7 +337,7 @@ func getAccessToken(httpClient *http.Client, clientId string, clientSecret strin
 	}
 	encodedData := body.Encode()
 
-	req, err := http.NewRequest(http.MethodPost, tokenUrl, strings.NewReader(encodedData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenUrl, strings.NewReader(encodedData))
 	if err != nil {
 		return nil, err
 	}
@@ -199,30 +349,33 @@ func getAccessToken(httpClient *http.Client, clientId string, clientSecret strin
 		req.Header.Set(keys, headers[keys])
 	}
 
+	// requesting a client credentials token has no side effects so is safe to retry
+	start := time.Now()
 	res, err := hClient.Do(req)
+	observeRequest("token", start, res)
 	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return nil, err
+		if retry.ShouldRetryError(err, true) {
+			return nil, retry.Retryable(transportError(err), 0)
 		}
-		return nil, err
+		return nil, transportError(err)
 	}
 
 	defer res.Body.Close()
 
 	response, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return nil, retry.Retryable(transportError(err), 0)
 	}
 
 	respString := string(response)
-	var data TokenInfo
-	errr := json.Unmarshal([]byte(respString), &data)
-	if errr != nil {
-		return nil, err
+	if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
+		return nil, statusError(res, respString)
 	}
 
-	if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
-		return nil, errors.New(respString)
+	var data TokenInfo
+	err = json.Unmarshal([]byte(respString), &data)
+	if err != nil {
+		return nil, err
 	}
 
 	return &data, nil